@@ -7,6 +7,7 @@ import (
 
 	db "github.com/chensheep/simple-bank-backend/db/sqlc"
 	"github.com/chensheep/simple-bank-backend/token"
+	"github.com/chensheep/simple-bank-backend/util"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
@@ -72,7 +73,7 @@ func (server *Server) getAccount(ctx *gin.Context) {
 	}
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if account.Owner != authPayload.Username {
+	if !canViewAccount(authPayload, account) {
 		ctx.JSON(http.StatusUnauthorized, errorResponse(errors.New("account doesn't belong to the authenticated user")))
 		return
 	}
@@ -83,6 +84,8 @@ func (server *Server) getAccount(ctx *gin.Context) {
 type listAccountsRequest struct {
 	PageID   int32 `form:"page_id" binding:"required,min=1"`
 	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	// Owner lists the accounts of another user, only bankers and admins can set it
+	Owner string `form:"owner" binding:"omitempty,alphanum"`
 }
 
 func (server *Server) listAccounts(ctx *gin.Context) {
@@ -96,8 +99,18 @@ func (server *Server) listAccounts(ctx *gin.Context) {
 	}
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	owner := authPayload.Username
+	if req.Owner != "" && req.Owner != authPayload.Username {
+		if authPayload.Role != util.BankerRole && authPayload.Role != util.AdminRole {
+			err := errors.New("only bankers and admins can list the accounts of other users")
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		owner = req.Owner
+	}
+
 	arg := db.ListAccountsParams{
-		Owner:  authPayload.Username,
+		Owner:  owner,
 		Offset: (req.PageID - 1) * req.PageSize,
 		Limit:  req.PageSize,
 	}
@@ -110,13 +123,26 @@ func (server *Server) listAccounts(ctx *gin.Context) {
 	ctx.JSON(200, accounts)
 }
 
-type deleteAccountRequset struct {
+type accountStatusRequset struct {
 	ID int64 `uri:"id" binding:"required,min=1"`
 }
 
-func (server *Server) deleteAccount(ctx *gin.Context) {
+// closeAccount closes the account instead of deleting it, so its ledger history is kept
+func (server *Server) closeAccount(ctx *gin.Context) {
+	server.updateAccountStatus(ctx, db.AccountStatusClosed)
+}
+
+func (server *Server) freezeAccount(ctx *gin.Context) {
+	server.updateAccountStatus(ctx, db.AccountStatusFrozen)
+}
+
+func (server *Server) unfreezeAccount(ctx *gin.Context) {
+	server.updateAccountStatus(ctx, db.AccountStatusActive)
+}
+
+func (server *Server) updateAccountStatus(ctx *gin.Context, status string) {
 
-	var req deleteAccountRequset
+	var req accountStatusRequset
 
 	err := ctx.ShouldBindUri(&req)
 	if err != nil {
@@ -124,17 +150,7 @@ func (server *Server) deleteAccount(ctx *gin.Context) {
 		return
 	}
 
-	_, err = server.store.GetAccount(ctx, req.ID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
-	}
-
-	err = server.store.DeleteAccount(ctx, req.ID)
+	account, err := server.store.GetAccount(ctx, req.ID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
@@ -144,48 +160,32 @@ func (server *Server) deleteAccount(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusNoContent, nil)
-}
-
-type updateAccountUriRequset struct {
-	ID int64 `uri:"id" binding:"required,min=1"`
-}
-
-type updateAccountJsonRequset struct {
-	Balance int64 `json:"balance" binding:"required,min=0"`
-}
-
-func (server *Server) updateAccount(ctx *gin.Context) {
-
-	var reqUri updateAccountUriRequset
-	var reqJson updateAccountJsonRequset
-
-	err := ctx.ShouldBindUri(&reqUri)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
-		return
-	}
-
-	err = ctx.ShouldBindJSON(&reqJson)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errors.New("account doesn't belong to the authenticated user")))
 		return
 	}
 
-	arg := db.UpdateAccountParams{
-		ID:      reqUri.ID,
-		Balance: reqJson.Balance,
-	}
-
-	account, err := server.store.UpdateAccount(ctx, arg)
+	result, err := server.store.UpdateAccountStatusTx(ctx, db.UpdateAccountStatusTxParams{
+		AccountID: req.ID,
+		Status:    status,
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		if errors.Is(err, db.ErrAccountFrozenByAdmin) {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrInvalidAccountStatusTransition) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrAccountBalanceNotZero) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
 			return
 		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, result.Account)
 }
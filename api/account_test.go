@@ -41,13 +41,42 @@ func TestGetAccount(t *testing.T) {
 					GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
 			},
 			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
-				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 				requireBodyMatchAccount(t, recorder.Body, account)
 			},
 		},
+		{
+			name:      "Banker",
+			accountID: account.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+			},
+			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
+				addAuthorization(t, request, maker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name:      "UnauthorizedUser",
+			accountID: account.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+			},
+			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
+				addAuthorization(t, request, maker, authorizationTypeBearer, "otheruser", util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
 		{
 			name:      "NotFound",
 			accountID: account.ID,
@@ -56,7 +85,7 @@ func TestGetAccount(t *testing.T) {
 					GetAccount(gomock.Any(), account.ID).Times(1).Return(db.Account{}, sql.ErrNoRows)
 			},
 			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
-				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusNotFound, recorder.Code)
@@ -70,7 +99,7 @@ func TestGetAccount(t *testing.T) {
 					GetAccount(gomock.Any(), account.ID).Times(1).Return(db.Account{}, sql.ErrConnDone)
 			},
 			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
-				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
@@ -84,7 +113,7 @@ func TestGetAccount(t *testing.T) {
 					GetAccount(gomock.Any(), gomock.Any()).Times(0)
 			},
 			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
-				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
@@ -145,7 +174,7 @@ func TestCreateAccount(t *testing.T) {
 				"currency": account.Currency,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
-				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -164,7 +193,7 @@ func TestCreateAccount(t *testing.T) {
 				"currency": account.Currency,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
-				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
@@ -181,7 +210,7 @@ func TestCreateAccount(t *testing.T) {
 				"currency": "invalid",
 			},
 			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
-				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
@@ -220,6 +249,7 @@ func TestCreateAccount(t *testing.T) {
 func TestListAccount(t *testing.T) {
 
 	user, _ := createRandomUser(t)
+	otherUser, _ := createRandomUser(t)
 
 	n := 5
 	accounts := make([]db.Account, n)
@@ -230,6 +260,7 @@ func TestListAccount(t *testing.T) {
 	type Query struct {
 		pageID   int
 		pageSize int
+		owner    string
 	}
 
 	testCases := []struct {
@@ -257,13 +288,58 @@ func TestListAccount(t *testing.T) {
 					Return(accounts, nil)
 			},
 			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
-				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccounts(t, recorder.Body, accounts)
+			},
+		},
+		{
+			name: "BankerListsOtherOwner",
+			query: Query{
+				pageID:   1,
+				pageSize: n,
+				owner:    user.Username,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAccountsParams{
+					Owner:  user.Username,
+					Limit:  int32(n),
+					Offset: 0,
+				}
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(accounts, nil)
+			},
+			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
+				addAuthorization(t, request, maker, authorizationTypeBearer, otherUser.Username, util.BankerRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 				requireBodyMatchAccounts(t, recorder.Body, accounts)
 			},
 		},
+		{
+			name: "DepositorListsOtherOwner",
+			query: Query{
+				pageID:   1,
+				pageSize: n,
+				owner:    user.Username,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
+				addAuthorization(t, request, maker, authorizationTypeBearer, otherUser.Username, util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
 		{
 			name: "NoAuthorization",
 			query: Query{
@@ -293,7 +369,7 @@ func TestListAccount(t *testing.T) {
 					Return([]db.Account{}, sql.ErrConnDone)
 			},
 			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
-				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
@@ -310,7 +386,7 @@ func TestListAccount(t *testing.T) {
 					Times(0)
 			},
 			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
-				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
@@ -327,7 +403,7 @@ func TestListAccount(t *testing.T) {
 					Times(0)
 			},
 			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
-				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
@@ -354,6 +430,9 @@ func TestListAccount(t *testing.T) {
 			q := r.URL.Query()
 			q.Add("page_id", strconv.Itoa(tc.query.pageID))
 			q.Add("page_size", strconv.Itoa(tc.query.pageSize))
+			if tc.query.owner != "" {
+				q.Add("owner", tc.query.owner)
+			}
 			r.URL.RawQuery = q.Encode()
 
 			tc.setupAuth(t, r, server.tokenMaker)
@@ -364,12 +443,214 @@ func TestListAccount(t *testing.T) {
 	}
 }
 
+func TestUpdateAccountStatus(t *testing.T) {
+	user, _ := createRandomUser(t)
+	otherUser, _ := createRandomUser(t)
+	account := createRandomAccount(user.Username)
+
+	testCases := []struct {
+		name          string
+		accountID     int64
+		action        string
+		buildStubs    func(store *mockdb.MockStore)
+		setupAuth     func(t *testing.T, request *http.Request, maker token.Maker)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "Close",
+			accountID: account.ID,
+			action:    "close",
+			buildStubs: func(store *mockdb.MockStore) {
+				closedAccount := account
+				closedAccount.Balance = 0
+				closedAccount.Status = db.AccountStatusClosed
+
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := db.UpdateAccountStatusTxParams{
+					AccountID: account.ID,
+					Status:    db.AccountStatusClosed,
+				}
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.UpdateAccountStatusTxResult{Account: closedAccount}, nil)
+			},
+			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
+				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "Freeze",
+			accountID: account.ID,
+			action:    "freeze",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := db.UpdateAccountStatusTxParams{
+					AccountID: account.ID,
+					Status:    db.AccountStatusFrozen,
+				}
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1)
+			},
+			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
+				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "Unfreeze",
+			accountID: account.ID,
+			action:    "unfreeze",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := db.UpdateAccountStatusTxParams{
+					AccountID: account.ID,
+					Status:    db.AccountStatusActive,
+				}
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1)
+			},
+			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
+				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "UnauthorizedUser",
+			accountID: account.ID,
+			action:    "close",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
+				addAuthorization(t, request, maker, authorizationTypeBearer, otherUser.Username, util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "NotFound",
+			accountID: account.ID,
+			action:    "close",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
+				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "BalanceNotZero",
+			accountID: account.ID,
+			action:    "close",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateAccountStatusTxResult{}, db.ErrAccountBalanceNotZero)
+			},
+			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
+				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidTransition",
+			accountID: account.ID,
+			action:    "unfreeze",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateAccountStatusTxResult{}, db.ErrInvalidAccountStatusTransition)
+			},
+			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
+				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:      "FrozenByAdmin",
+			accountID: account.ID,
+			action:    "unfreeze",
+			buildStubs: func(store *mockdb.MockStore) {
+				frozenAccount := account
+				frozenAccount.Status = db.AccountStatusFrozen
+				frozenAccount.FrozenByAdmin = true
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(frozenAccount, nil)
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateAccountStatusTxResult{}, db.ErrAccountFrozenByAdmin)
+			},
+			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
+				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mockStore := mockdb.NewMockStore(mockCtrl)
+			// build stubs
+			tc.buildStubs(mockStore)
+
+			server := newTestServer(t, mockStore)
+			w := httptest.NewRecorder()
+
+			method, url := http.MethodPost, fmt.Sprintf("/accounts/%d/%s", tc.accountID, tc.action)
+			if tc.action == "close" {
+				method, url = http.MethodDelete, fmt.Sprintf("/accounts/%d", tc.accountID)
+			}
+			r, err := http.NewRequest(method, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, r, server.tokenMaker)
+			server.router.ServeHTTP(w, r)
+			// check response
+			tc.checkResponse(t, w)
+		})
+	}
+}
+
 func createRandomAccount(username string) db.Account {
+	balance := util.RandomBalance()
 	return db.Account{
-		ID:       util.RandomInt(1, 1000),
-		Owner:    username,
-		Balance:  util.RandomBalance(),
-		Currency: util.RandomCurrency(),
+		ID:               util.RandomInt(1, 1000),
+		Owner:            username,
+		Balance:          balance,
+		Currency:         util.RandomCurrency(),
+		Status:           db.AccountStatusActive,
+		AvailableBalance: balance,
 	}
 }
 
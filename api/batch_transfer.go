@@ -0,0 +1,182 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/token"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+const (
+	maxBatchTransferLegs = 1000
+
+	batchTransferModeAllOrNothing = "all_or_nothing"
+	batchTransferModeBestEffort   = "best_effort"
+)
+
+var errBatchTotalOverflow = errors.New("the legs add up to more than an amount can hold")
+
+type batchTransferLegRequest struct {
+	ToAccountID int64 `json:"to_account_id" binding:"required,min=1"`
+	Amount      int64 `json:"amount" binding:"required,gt=0"`
+}
+
+type createBatchTransferRequest struct {
+	FromAccountID int64                     `json:"from_account_id" binding:"required,min=1"`
+	Currency      string                    `json:"currency" binding:"required,currency"`
+	Mode          string                    `json:"mode" binding:"omitempty,oneof=all_or_nothing best_effort"`
+	Legs          []batchTransferLegRequest `json:"legs" binding:"required,min=1,max=1000,dive"`
+}
+
+// createBatchTransferFormRequest is the multipart form version of the request,
+// legs is a CSV file of to_account_id,amount rows
+type createBatchTransferFormRequest struct {
+	FromAccountID int64                 `form:"from_account_id" binding:"required,min=1"`
+	Currency      string                `form:"currency" binding:"required,currency"`
+	Mode          string                `form:"mode" binding:"omitempty,oneof=all_or_nothing best_effort"`
+	Legs          *multipart.FileHeader `form:"legs" binding:"required"`
+}
+
+// createBatchTransfer pays many accounts from one account in a single transaction
+func (server *Server) createBatchTransfer(ctx *gin.Context) {
+	var req createBatchTransferRequest
+
+	if ctx.ContentType() == binding.MIMEMultipartPOSTForm {
+		var formReq createBatchTransferFormRequest
+		err := ctx.ShouldBind(&formReq)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+
+		req.FromAccountID, req.Currency, req.Mode = formReq.FromAccountID, formReq.Currency, formReq.Mode
+		req.Legs, err = readBatchTransferCSV(formReq.Legs)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	} else {
+		err := ctx.ShouldBindJSON(&req)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
+	// the legs add up, so a payment over the threshold can't be split into legs under it
+	var total int64
+	for _, leg := range req.Legs {
+		if leg.Amount > math.MaxInt64-total {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errBatchTotalOverflow))
+			return
+		}
+		total += leg.Amount
+		if !server.checkStepUp(ctx, req.Currency, total) {
+			return
+		}
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+	if authPayload.Username != fromAccount.Owner {
+		err := fmt.Errorf("the from account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	arg := db.BatchTransferTxParams{
+		FromAccountID: req.FromAccountID,
+		BestEffort:    req.Mode == batchTransferModeBestEffort,
+	}
+	for _, leg := range req.Legs {
+		arg.Legs = append(arg.Legs, db.BatchTransferLeg{
+			ToAccountID: leg.ToAccountID,
+			Amount:      leg.Amount,
+		})
+	}
+
+	result, err := server.store.BatchTransferTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrInsufficientFunds) ||
+			errors.Is(err, db.ErrAccountNotActive) ||
+			errors.Is(err, db.ErrAccountNotFound) ||
+			errors.Is(err, db.ErrCurrencyMismatch) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// readBatchTransferCSV reads the legs of an uploaded CSV file,
+// the to_account_id,amount header row is optional
+func readBatchTransferCSV(fileHeader *multipart.FileHeader) ([]batchTransferLegRequest, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	var legs []batchTransferLegRequest
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && strings.EqualFold(record[0], "to_account_id") {
+			continue
+		}
+
+		toAccountID, err := strconv.ParseInt(record[0], 10, 64)
+		if err != nil || toAccountID <= 0 {
+			return nil, fmt.Errorf("line %d: invalid to_account_id %q", line, record[0])
+		}
+		amount, err := strconv.ParseInt(record[1], 10, 64)
+		if err != nil || amount <= 0 {
+			return nil, fmt.Errorf("line %d: invalid amount %q", line, record[1])
+		}
+
+		legs = append(legs, batchTransferLegRequest{
+			ToAccountID: toAccountID,
+			Amount:      amount,
+		})
+		if len(legs) > maxBatchTransferLegs {
+			return nil, fmt.Errorf("a batch can have at most %d legs", maxBatchTransferLegs)
+		}
+	}
+
+	if len(legs) == 0 {
+		return nil, errors.New("the legs file has no rows")
+	}
+
+	return legs, nil
+}
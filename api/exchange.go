@@ -0,0 +1,159 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/token"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/gin-gonic/gin"
+)
+
+type createExchangeQuoteRequest struct {
+	FromAccountID int64 `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64 `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64 `json:"amount" binding:"required,gt=0"`
+}
+
+// createExchangeQuote locks the current rate between the currencies of the two accounts,
+// the quote has to be executed before it expires
+func (server *Server) createExchangeQuote(ctx *gin.Context) {
+	var req createExchangeQuoteRequest
+
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	fromAccount, valid := server.activeAccount(ctx, req.FromAccountID)
+	if !valid {
+		return
+	}
+	if authPayload.Username != fromAccount.Owner {
+		err := fmt.Errorf("the from account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	if !server.checkStepUp(ctx, fromAccount.Currency, req.Amount) {
+		return
+	}
+	if fromAccount.AvailableBalance+fromAccount.OverdraftLimit < req.Amount {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(db.ErrInsufficientFunds))
+		return
+	}
+
+	toAccount, valid := server.activeAccount(ctx, req.ToAccountID)
+	if !valid {
+		return
+	}
+	if fromAccount.Currency == toAccount.Currency {
+		err := fmt.Errorf("both accounts use %s, make a transfer instead", fromAccount.Currency)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	rate, err := server.store.GetExchangeRate(ctx, db.GetExchangeRateParams{
+		FromCurrency: fromAccount.Currency,
+		ToCurrency:   toAccount.Currency,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err := fmt.Errorf("no exchange rate from %s to %s", fromAccount.Currency, toAccount.Currency)
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	toAmount, err := util.ConvertAmount(req.Amount, rate.Rate)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if toAmount <= 0 {
+		err := fmt.Errorf("amount is too small to be converted to %s", toAccount.Currency)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.CreateExchangeQuoteParams{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		FromAmount:    req.Amount,
+		ToAmount:      toAmount,
+		Rate:          rate.Rate,
+		ExpiredAt:     time.Now().Add(server.config.ExchangeQuoteDuration),
+	}
+	quote, err := server.store.CreateExchangeQuote(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, quote)
+}
+
+type executeExchangeQuoteRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// executeExchangeQuote makes the cross-currency transfer at the quoted rate
+func (server *Server) executeExchangeQuote(ctx *gin.Context) {
+	var req executeExchangeQuoteRequest
+
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	quote, err := server.store.GetExchangeQuote(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	fromAccount, err := server.store.GetAccount(ctx, quote.FromAccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Username != fromAccount.Owner {
+		err := fmt.Errorf("the from account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.ExchangeTransferTx(ctx, db.ExchangeTransferTxParams{
+		QuoteID: req.ID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrExchangeQuoteUsed):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, db.ErrExchangeQuoteExpired),
+			errors.Is(err, db.ErrInsufficientFunds),
+			errors.Is(err, db.ErrAccountNotActive):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
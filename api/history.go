@@ -0,0 +1,189 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/token"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/gin-gonic/gin"
+)
+
+type accountHistoryUriRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// accountHistoryQueryRequest filters the history of an account, newest first.
+// Pages are chained with the next_cursor of the previous response
+type accountHistoryQueryRequest struct {
+	PageSize  int32     `form:"page_size" binding:"required,min=1,max=100"`
+	Cursor    string    `form:"cursor"`
+	StartTime time.Time `form:"start_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	EndTime   time.Time `form:"end_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	Direction string    `form:"direction" binding:"omitempty,oneof=in out"`
+	MinAmount int64     `form:"min_amount" binding:"omitempty,min=1"`
+	MaxAmount int64     `form:"max_amount" binding:"omitempty,min=1"`
+}
+
+type listAccountEntriesResponse struct {
+	Entries    []db.Entry `json:"entries"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+func (server *Server) listAccountEntries(ctx *gin.Context) {
+	var reqUri accountHistoryUriRequest
+	var reqQuery accountHistoryQueryRequest
+
+	err := ctx.ShouldBindUri(&reqUri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	err = ctx.ShouldBindQuery(&reqQuery)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	cursorCreatedAt, cursorID, err := decodeHistoryCursor(reqQuery.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	_, valid := server.viewableAccount(ctx, reqUri.ID)
+	if !valid {
+		return
+	}
+
+	arg := db.ListAccountEntriesParams{
+		AccountID:       reqUri.ID,
+		Direction:       sql.NullString{String: reqQuery.Direction, Valid: reqQuery.Direction != ""},
+		StartTime:       sql.NullTime{Time: reqQuery.StartTime, Valid: !reqQuery.StartTime.IsZero()},
+		EndTime:         sql.NullTime{Time: reqQuery.EndTime, Valid: !reqQuery.EndTime.IsZero()},
+		MinAmount:       sql.NullInt64{Int64: reqQuery.MinAmount, Valid: reqQuery.MinAmount > 0},
+		MaxAmount:       sql.NullInt64{Int64: reqQuery.MaxAmount, Valid: reqQuery.MaxAmount > 0},
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		PageSize:        reqQuery.PageSize,
+	}
+	entries, err := server.store.ListAccountEntries(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := listAccountEntriesResponse{
+		Entries: entries,
+	}
+	if len(entries) == int(reqQuery.PageSize) {
+		last := entries[len(entries)-1]
+		rsp.NextCursor = util.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type listAccountTransfersResponse struct {
+	Transfers  []db.Transfer `json:"transfers"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+func (server *Server) listAccountTransfers(ctx *gin.Context) {
+	var reqUri accountHistoryUriRequest
+	var reqQuery accountHistoryQueryRequest
+
+	err := ctx.ShouldBindUri(&reqUri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	err = ctx.ShouldBindQuery(&reqQuery)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	cursorCreatedAt, cursorID, err := decodeHistoryCursor(reqQuery.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	_, valid := server.viewableAccount(ctx, reqUri.ID)
+	if !valid {
+		return
+	}
+
+	arg := db.ListAccountTransfersParams{
+		AccountID:       reqUri.ID,
+		Direction:       sql.NullString{String: reqQuery.Direction, Valid: reqQuery.Direction != ""},
+		StartTime:       sql.NullTime{Time: reqQuery.StartTime, Valid: !reqQuery.StartTime.IsZero()},
+		EndTime:         sql.NullTime{Time: reqQuery.EndTime, Valid: !reqQuery.EndTime.IsZero()},
+		MinAmount:       sql.NullInt64{Int64: reqQuery.MinAmount, Valid: reqQuery.MinAmount > 0},
+		MaxAmount:       sql.NullInt64{Int64: reqQuery.MaxAmount, Valid: reqQuery.MaxAmount > 0},
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		PageSize:        reqQuery.PageSize,
+	}
+	transfers, err := server.store.ListAccountTransfers(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := listAccountTransfersResponse{
+		Transfers: transfers,
+	}
+	if len(transfers) == int(reqQuery.PageSize) {
+		last := transfers[len(transfers)-1]
+		rsp.NextCursor = util.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// viewableAccount loads the account and checks the authenticated user can read it,
+// that is the owner or a banker. Closed accounts are still returned so their history stays readable
+func (server *Server) viewableAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return account, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !canViewAccount(authPayload, account) {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errors.New("account doesn't belong to the authenticated user")))
+		return account, false
+	}
+
+	return account, true
+}
+
+func decodeHistoryCursor(cursor string) (sql.NullTime, sql.NullInt64, error) {
+	if cursor == "" {
+		return sql.NullTime{}, sql.NullInt64{}, nil
+	}
+
+	createdAt, id, err := util.DecodeCursor(cursor)
+	if err != nil {
+		return sql.NullTime{}, sql.NullInt64{}, err
+	}
+
+	return sql.NullTime{Time: createdAt, Valid: true}, sql.NullInt64{Int64: id, Valid: true}, nil
+}
+
+// canViewAccount reports whether the account can be read with the token, bankers can read any account
+func canViewAccount(authPayload *token.Payload, account db.Account) bool {
+	return account.Owner == authPayload.Username || authPayload.Role == util.BankerRole
+}
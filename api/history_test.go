@@ -0,0 +1,263 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	mockdb "github.com/chensheep/simple-bank-backend/db/mock"
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/token"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAccountEntriesAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	user2, _ := createRandomUser(t)
+	account := createRandomAccount(user.Username)
+
+	n := 3
+	entries := make([]db.Entry, n)
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	for i := 0; i < n; i++ {
+		entries[i] = db.Entry{
+			ID:        int64(n - i),
+			AccountID: account.ID,
+			Amount:    -util.RandomMoney(),
+			CreatedAt: createdAt.Add(-time.Duration(i) * time.Minute),
+		}
+	}
+	last := entries[n-1]
+	startTime := createdAt.Add(-time.Hour)
+
+	testCases := []struct {
+		name          string
+		accountID     int64
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		setupAuth     func(t *testing.T, request *http.Request, maker token.Maker)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			accountID: account.ID,
+			query: url.Values{
+				"page_size":  {fmt.Sprint(n)},
+				"direction":  {"out"},
+				"start_time": {startTime.Format(time.RFC3339)},
+				"min_amount": {"1"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := db.ListAccountEntriesParams{
+					AccountID: account.ID,
+					Direction: sql.NullString{String: "out", Valid: true},
+					StartTime: sql.NullTime{Time: startTime, Valid: true},
+					MinAmount: sql.NullInt64{Int64: 1, Valid: true},
+					PageSize:  int32(n),
+				}
+				store.EXPECT().
+					ListAccountEntries(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, got db.ListAccountEntriesParams) ([]db.Entry, error) {
+						require.True(t, arg.StartTime.Time.Equal(got.StartTime.Time))
+						got.StartTime.Time = arg.StartTime.Time
+						require.Equal(t, arg, got)
+						return entries, nil
+					})
+			},
+			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
+				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp listAccountEntriesResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Len(t, rsp.Entries, n)
+				require.Equal(t, util.EncodeCursor(last.CreatedAt, last.ID), rsp.NextCursor)
+			},
+		},
+		{
+			name:      "NextPage",
+			accountID: account.ID,
+			query: url.Values{
+				"page_size": {fmt.Sprint(n + 1)},
+				"cursor":    {util.EncodeCursor(last.CreatedAt, last.ID)},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					ListAccountEntries(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, got db.ListAccountEntriesParams) ([]db.Entry, error) {
+						require.True(t, got.CursorCreatedAt.Valid)
+						require.True(t, last.CreatedAt.Equal(got.CursorCreatedAt.Time))
+						require.Equal(t, sql.NullInt64{Int64: last.ID, Valid: true}, got.CursorID)
+						require.False(t, got.Direction.Valid)
+						return entries, nil
+					})
+			},
+			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
+				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp listAccountEntriesResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Len(t, rsp.Entries, n)
+				require.Empty(t, rsp.NextCursor)
+			},
+		},
+		{
+			name:      "InvalidCursor",
+			accountID: account.ID,
+			query: url.Values{
+				"page_size": {fmt.Sprint(n)},
+				"cursor":    {"invalid"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
+				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidDirection",
+			accountID: account.ID,
+			query: url.Values{
+				"page_size": {fmt.Sprint(n)},
+				"direction": {"sideways"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
+				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "UnauthorizedUser",
+			accountID: account.ID,
+			query: url.Values{
+				"page_size": {fmt.Sprint(n)},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
+				addAuthorization(t, request, maker, authorizationTypeBearer, user2.Username, util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "AccountNotFound",
+			accountID: account.ID,
+			query: url.Values{
+				"page_size": {fmt.Sprint(n)},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
+				addAuthorization(t, request, maker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/entries?%s", tc.accountID, tc.query.Encode())
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListAccountTransfersAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	account1 := createRandomAccount(user.Username)
+	account2 := createRandomAccount(user.Username)
+
+	transfers := []db.Transfer{
+		{
+			ID:            util.RandomInt(1, 1000),
+			FromAccountID: account2.ID,
+			ToAccountID:   account1.ID,
+			Amount:        10,
+			ToAmount:      10,
+			CreatedAt:     time.Now().UTC().Truncate(time.Second),
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+	arg := db.ListAccountTransfersParams{
+		AccountID: account1.ID,
+		Direction: sql.NullString{String: "in", Valid: true},
+		MaxAmount: sql.NullInt64{Int64: 100, Valid: true},
+		PageSize:  5,
+	}
+	store.EXPECT().ListAccountTransfers(gomock.Any(), gomock.Eq(arg)).Times(1).Return(transfers, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/accounts/%d/transfers?page_size=5&direction=in&max_amount=100", account1.ID)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp listAccountTransfersResponse
+	err = json.Unmarshal(recorder.Body.Bytes(), &rsp)
+	require.NoError(t, err)
+	require.Equal(t, transfers, rsp.Transfers)
+	require.Empty(t, rsp.NextCursor)
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/token"
+	"github.com/gin-gonic/gin"
+)
+
+type authorizeHoldRequest struct {
+	AccountID   int64  `json:"account_id" binding:"required,min=1"`
+	ToAccountID int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount      int64  `json:"amount" binding:"required,gt=0"`
+	Currency    string `json:"currency" binding:"required,currency"`
+}
+
+// authorizeHold reserves funds of the authenticated user's account for the destination account
+func (server *Server) authorizeHold(ctx *gin.Context) {
+	var req authorizeHoldRequest
+
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !server.checkStepUp(ctx, req.Currency, req.Amount) {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	account, valid := server.validAccount(ctx, req.AccountID, req.Currency)
+	if !valid {
+		return
+	}
+	if authPayload.Username != account.Owner {
+		err := fmt.Errorf("the account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	arg := db.AuthorizeHoldTxParams{
+		AccountID:   req.AccountID,
+		ToAccountID: req.ToAccountID,
+		Amount:      req.Amount,
+		ExpiredAt:   time.Now().Add(server.config.HoldDuration),
+	}
+	result, err := server.store.AuthorizeHoldTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, db.ErrAccountNotActive) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+type holdUriRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type captureHoldRequest struct {
+	Amount int64 `json:"amount" binding:"min=0"`
+}
+
+// captureHold turns the hold into a transfer, only the destination account's owner can capture it
+func (server *Server) captureHold(ctx *gin.Context) {
+	var reqUri holdUriRequest
+	var reqJson captureHoldRequest
+
+	err := ctx.ShouldBindUri(&reqUri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	err = ctx.ShouldBindJSON(&reqJson)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	_, valid := server.validHoldReceiver(ctx, reqUri.ID)
+	if !valid {
+		return
+	}
+
+	result, err := server.store.CaptureHoldTx(ctx, db.CaptureHoldTxParams{
+		HoldID: reqUri.ID,
+		Amount: reqJson.Amount,
+	})
+	if err != nil {
+		server.handleHoldError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// voidHold releases the hold, only the destination account's owner can void it
+func (server *Server) voidHold(ctx *gin.Context) {
+	var req holdUriRequest
+
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	_, valid := server.validHoldReceiver(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	result, err := server.store.VoidHoldTx(ctx, db.VoidHoldTxParams{
+		HoldID: req.ID,
+	})
+	if err != nil {
+		server.handleHoldError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// check the hold's destination account belongs to the authenticated user
+func (server *Server) validHoldReceiver(ctx *gin.Context, holdID int64) (db.Hold, bool) {
+	hold, err := server.store.GetHold(ctx, holdID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return hold, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return hold, false
+	}
+
+	toAccount, err := server.store.GetAccount(ctx, hold.ToAccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return hold, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Username != toAccount.Owner {
+		err := fmt.Errorf("the hold's destination account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return hold, false
+	}
+
+	return hold, true
+}
+
+func (server *Server) handleHoldError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, db.ErrHoldNotAuthorized),
+		errors.Is(err, db.ErrHoldStepUpRequired):
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+	case errors.Is(err, db.ErrHoldExpired),
+		errors.Is(err, db.ErrHoldAmountExceeded),
+		errors.Is(err, db.ErrInsufficientFunds),
+		errors.Is(err, db.ErrAccountNotActive):
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+	default:
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
+}
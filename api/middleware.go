@@ -1,11 +1,13 @@
 package api
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
 	"github.com/chensheep/simple-bank-backend/token"
 	"github.com/gin-gonic/gin"
 )
@@ -52,3 +54,48 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// roleMiddleware lets through only the access tokens granting one of the roles,
+// it must run after authMiddleware
+func roleMiddleware(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+		for _, role := range roles {
+			if payload.Role == role {
+				c.Next()
+				return
+			}
+		}
+
+		err := fmt.Errorf("role %s is not allowed", payload.Role)
+		c.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+	}
+}
+
+// verifiedEmailMiddleware lets through only the users who have verified their email when required is set,
+// it must run after authMiddleware
+func verifiedEmailMiddleware(store db.Store, required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !required {
+			c.Next()
+			return
+		}
+
+		payload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+		user, err := store.GetUser(c, payload.Username)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.AbortWithStatusJSON(http.StatusNotFound, errorResponse(err))
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		if !user.IsEmailVerified {
+			c.AbortWithStatusJSON(http.StatusForbidden, errorResponse(errors.New("email must be verified first")))
+			return
+		}
+		c.Next()
+	}
+}
@@ -7,8 +7,11 @@ import (
 	"testing"
 	"time"
 
+	mockdb "github.com/chensheep/simple-bank-backend/db/mock"
 	"github.com/chensheep/simple-bank-backend/token"
+	"github.com/chensheep/simple-bank-backend/util"
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -16,11 +19,12 @@ func addAuthorization(
 	t *testing.T,
 	request *http.Request,
 	tokenMaker token.Maker,
-	authorizationType, username string,
+	authorizationType, username, role string,
 	duration time.Duration,
 ) {
 	accessToken, payload, err := tokenMaker.CreateToken(
 		username,
+		role,
 		duration,
 	)
 	require.NoError(t, err)
@@ -39,7 +43,7 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name: "OK",
 			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
-				addAuthorization(t, request, maker, authorizationTypeBearer, "testuser", time.Minute)
+				addAuthorization(t, request, maker, authorizationTypeBearer, "testuser", util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -56,7 +60,7 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name: "UnsupportedAuthorization",
 			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
-				addAuthorization(t, request, maker, "unsupportedauthtype", "testuser", time.Minute)
+				addAuthorization(t, request, maker, "unsupportedauthtype", "testuser", util.DepositorRole, time.Minute)
 
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -66,7 +70,7 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name: "InvalidAuthorizationFormat",
 			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
-				addAuthorization(t, request, maker, "", "testuser", time.Minute)
+				addAuthorization(t, request, maker, "", "testuser", util.DepositorRole, time.Minute)
 
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -76,7 +80,7 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name: "ExpiredToken",
 			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
-				addAuthorization(t, request, maker, authorizationTypeBearer, "testuser", -time.Minute)
+				addAuthorization(t, request, maker, authorizationTypeBearer, "testuser", util.DepositorRole, -time.Minute)
 
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -115,3 +119,88 @@ func TestAuthMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestVerifiedEmailMiddleware(t *testing.T) {
+	user, _ := createRandomUser(t)
+	verifiedUser := user
+	verifiedUser.IsEmailVerified = true
+
+	testCases := []struct {
+		name          string
+		required      bool
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			required: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(verifiedUser, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "EmailNotVerified",
+			required: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "NotRequired",
+			required: false,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+
+			verifiedPath := "/verified"
+			server.router.GET(
+				verifiedPath,
+				authMiddleware(server.tokenMaker),
+				verifiedEmailMiddleware(store, tc.required),
+				func(c *gin.Context) {
+					c.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, verifiedPath, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
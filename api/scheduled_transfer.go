@@ -0,0 +1,277 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/token"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/gin-gonic/gin"
+)
+
+type createScheduledTransferRequest struct {
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	Currency      string `json:"currency" binding:"required,currency"`
+	Schedule      string `json:"schedule" binding:"required"`
+}
+
+func (server *Server) createScheduledTransfer(ctx *gin.Context) {
+	var req createScheduledTransferRequest
+
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	nextRunAt, err := util.NextScheduleTime(req.Schedule, time.Now())
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !server.checkStepUp(ctx, req.Currency, req.Amount) {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+	if authPayload.Username != fromAccount.Owner {
+		err := fmt.Errorf("the from account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	arg := db.CreateScheduledTransferParams{
+		Owner:         authPayload.Username,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Schedule:      req.Schedule,
+		NextRunAt:     nextRunAt,
+	}
+	scheduledTransfer, err := server.store.CreateScheduledTransfer(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduledTransfer)
+}
+
+type scheduledTransferUriRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getScheduledTransfer(ctx *gin.Context) {
+	var req scheduledTransferUriRequest
+
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scheduledTransfer, valid := server.ownScheduledTransfer(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduledTransfer)
+}
+
+type listScheduledTransfersRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (server *Server) listScheduledTransfers(ctx *gin.Context) {
+	var req listScheduledTransfersRequest
+
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.ListScheduledTransfersParams{
+		Owner:  authPayload.Username,
+		Offset: (req.PageID - 1) * req.PageSize,
+		Limit:  req.PageSize,
+	}
+	scheduledTransfers, err := server.store.ListScheduledTransfers(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduledTransfers)
+}
+
+type updateScheduledTransferRequest struct {
+	Amount   *int64  `json:"amount" binding:"omitempty,gt=0"`
+	Schedule *string `json:"schedule"`
+	IsActive *bool   `json:"is_active"`
+}
+
+func (server *Server) updateScheduledTransfer(ctx *gin.Context) {
+	var reqUri scheduledTransferUriRequest
+	var reqJson updateScheduledTransferRequest
+
+	err := ctx.ShouldBindUri(&reqUri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	err = ctx.ShouldBindJSON(&reqJson)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scheduledTransfer, valid := server.ownScheduledTransfer(ctx, reqUri.ID)
+	if !valid {
+		return
+	}
+
+	arg := db.UpdateScheduledTransferParams{
+		ID: reqUri.ID,
+	}
+	if reqJson.Amount != nil {
+		if len(server.stepUpThresholds) > 0 {
+			fromAccount, valid := server.existingAccount(ctx, scheduledTransfer.FromAccountID)
+			if !valid || !server.checkStepUp(ctx, fromAccount.Currency, *reqJson.Amount) {
+				return
+			}
+		}
+		arg.Amount = sql.NullInt64{Int64: *reqJson.Amount, Valid: true}
+	}
+	if reqJson.IsActive != nil {
+		arg.IsActive = sql.NullBool{Bool: *reqJson.IsActive, Valid: true}
+	}
+
+	// a new schedule, or resuming a paused one, starts from now instead of catching up
+	schedule := scheduledTransfer.Schedule
+	if reqJson.Schedule != nil {
+		schedule = *reqJson.Schedule
+		arg.Schedule = sql.NullString{String: schedule, Valid: true}
+	}
+	if reqJson.Schedule != nil || (reqJson.IsActive != nil && *reqJson.IsActive && !scheduledTransfer.IsActive) {
+		nextRunAt, err := util.NextScheduleTime(schedule, time.Now())
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		arg.NextRunAt = sql.NullTime{Time: nextRunAt, Valid: true}
+	}
+
+	scheduledTransfer, err = server.store.UpdateScheduledTransfer(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduledTransfer)
+}
+
+func (server *Server) deleteScheduledTransfer(ctx *gin.Context) {
+	var req scheduledTransferUriRequest
+
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	_, valid := server.ownScheduledTransfer(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	err = server.store.DeleteScheduledTransfer(ctx, req.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{})
+}
+
+type listScheduledTransferRunsRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// listScheduledTransferRuns returns the executions of a scheduled transfer, latest first
+func (server *Server) listScheduledTransferRuns(ctx *gin.Context) {
+	var reqUri scheduledTransferUriRequest
+	var reqQuery listScheduledTransferRunsRequest
+
+	err := ctx.ShouldBindUri(&reqUri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	err = ctx.ShouldBindQuery(&reqQuery)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	_, valid := server.ownScheduledTransfer(ctx, reqUri.ID)
+	if !valid {
+		return
+	}
+
+	arg := db.ListScheduledTransferRunsParams{
+		ScheduledTransferID: reqUri.ID,
+		Offset:              (reqQuery.PageID - 1) * reqQuery.PageSize,
+		Limit:               reqQuery.PageSize,
+	}
+	runs, err := server.store.ListScheduledTransferRuns(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, runs)
+}
+
+// check the scheduled transfer belongs to the authenticated user
+func (server *Server) ownScheduledTransfer(ctx *gin.Context, id int64) (db.ScheduledTransfer, bool) {
+	scheduledTransfer, err := server.store.GetScheduledTransfer(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return scheduledTransfer, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return scheduledTransfer, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if scheduledTransfer.Owner != authPayload.Username {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errors.New("scheduled transfer doesn't belong to the authenticated user")))
+		return scheduledTransfer, false
+	}
+
+	return scheduledTransfer, true
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/login"
 	"github.com/chensheep/simple-bank-backend/util"
 
 	"github.com/chensheep/simple-bank-backend/token"
@@ -17,6 +18,10 @@ type Server struct {
 	store      db.Store
 	router     *gin.Engine
 	tokenMaker token.Maker
+	// authenticator counts the failed logins like the gRPC server does, so a lockout applies to both
+	authenticator *login.Authenticator
+	// stepUpThresholds is the amount per currency from which moving money needs a second factor
+	stepUpThresholds map[string]int64
 }
 
 func NewServer(config util.Config, store db.Store) (*Server, error) {
@@ -25,10 +30,17 @@ func NewServer(config util.Config, store db.Store) (*Server, error) {
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
 
+	stepUpThresholds, err := util.ParseCurrencyAmounts(config.StepUpThresholds)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse step-up thresholds: %w", err)
+	}
+
 	server := &Server{
-		config:     config,
-		store:      store,
-		tokenMaker: tokenMaker,
+		config:           config,
+		store:            store,
+		tokenMaker:       tokenMaker,
+		authenticator:    login.NewAuthenticator(store, config),
+		stepUpThresholds: stepUpThresholds,
 	}
 
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
@@ -46,17 +58,43 @@ func (server *Server) setupRouter() {
 
 	router.POST("/users", server.createUser)
 	router.POST("/users/login", server.loginUser)
+	router.POST("/users/login/totp", server.verifyLoginTotp)
 	router.POST("/tokens/renew_access", server.renewAccessToken)
 
 	authRoute := router.Group("/").Use(authMiddleware(server.tokenMaker))
+	// opening accounts and sending money out of them need a verified email
+	verifiedEmail := verifiedEmailMiddleware(server.store, server.config.RequireVerifiedEmail)
 
-	authRoute.POST("/accounts", server.createAccount)
+	authRoute.POST("/users/:username/freeze", roleMiddleware(util.AdminRole), server.freezeUser)
+	authRoute.POST("/users/:username/unfreeze", roleMiddleware(util.AdminRole), server.unfreezeUser)
+
+	authRoute.POST("/accounts", verifiedEmail, server.createAccount)
 	authRoute.GET("/accounts/:id", server.getAccount)
 	authRoute.GET("/accounts", server.listAccounts)
-	authRoute.DELETE("/accounts/:id", server.deleteAccount)
-	authRoute.PUT("/accounts/:id", server.updateAccount)
-
-	authRoute.POST("/transfers", server.createTransfer)
+	authRoute.DELETE("/accounts/:id", server.closeAccount)
+	authRoute.POST("/accounts/:id/freeze", server.freezeAccount)
+	authRoute.POST("/accounts/:id/unfreeze", server.unfreezeAccount)
+	authRoute.GET("/accounts/:id/entries", server.listAccountEntries)
+	authRoute.GET("/accounts/:id/transfers", server.listAccountTransfers)
+	authRoute.GET("/accounts/:id/statements/:month", server.downloadStatement)
+
+	authRoute.POST("/transfers", verifiedEmail, server.createTransfer)
+	authRoute.POST("/transfers/batch", verifiedEmail, server.createBatchTransfer)
+	authRoute.POST("/transfers/:id/reverse", verifiedEmail, server.reverseTransfer)
+
+	authRoute.POST("/scheduled_transfers", verifiedEmail, server.createScheduledTransfer)
+	authRoute.GET("/scheduled_transfers/:id", server.getScheduledTransfer)
+	authRoute.GET("/scheduled_transfers", server.listScheduledTransfers)
+	authRoute.PATCH("/scheduled_transfers/:id", server.updateScheduledTransfer)
+	authRoute.DELETE("/scheduled_transfers/:id", server.deleteScheduledTransfer)
+	authRoute.GET("/scheduled_transfers/:id/runs", server.listScheduledTransferRuns)
+
+	authRoute.POST("/exchange_quotes", verifiedEmail, server.createExchangeQuote)
+	authRoute.POST("/exchange_quotes/:id/execute", verifiedEmail, server.executeExchangeQuote)
+
+	authRoute.POST("/holds", verifiedEmail, server.authorizeHold)
+	authRoute.POST("/holds/:id/capture", server.captureHold)
+	authRoute.POST("/holds/:id/void", server.voidHold)
 
 	server.router = router
 }
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/chensheep/simple-bank-backend/statement"
+	"github.com/gin-gonic/gin"
+)
+
+type downloadStatementUriRequest struct {
+	ID    int64  `uri:"id" binding:"required,min=1"`
+	Month string `uri:"month" binding:"required"`
+}
+
+type downloadStatementQueryRequest struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv pdf"`
+}
+
+func (server *Server) downloadStatement(ctx *gin.Context) {
+	var reqUri downloadStatementUriRequest
+	var reqQuery downloadStatementQueryRequest
+
+	err := ctx.ShouldBindUri(&reqUri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	err = ctx.ShouldBindQuery(&reqQuery)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	format := reqQuery.Format
+	if format == "" {
+		format = statement.FormatPDF
+	}
+
+	month, err := statement.ParseMonth(reqUri.Month)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if month.After(time.Now()) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("month %s has not started yet", reqUri.Month)))
+		return
+	}
+
+	account, valid := server.viewableAccount(ctx, reqUri.ID)
+	if !valid {
+		return
+	}
+
+	start, end := statement.MonthPeriod(month)
+	s, err := statement.Generate(ctx, server.store, account, start, end)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	var buf bytes.Buffer
+	err = s.Write(&buf, format)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", s.FileName(format)))
+	ctx.Data(http.StatusOK, statement.ContentType(format), buf.Bytes())
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errStepUpRequired rejects the amounts which need a second factor. This API has no way to ask for one,
+// transfers over the step-up threshold are made with the gRPC API which holds them until they are confirmed
+var errStepUpRequired = errors.New("the amount needs a second factor, make the transfer with the gRPC API")
+
+// requiresStepUp tells whether the amount reaches the step-up threshold of its currency,
+// currencies without a threshold never need a second factor
+func (server *Server) requiresStepUp(currency string, amount int64) bool {
+	threshold, ok := server.stepUpThresholds[currency]
+	return ok && amount >= threshold
+}
+
+// checkStepUp responds with 403 when the amount moved out of an account needs a second factor.
+// It guards every route moving money to another account: transfers, batch transfers, holds, whose
+// capture can't exceed the held amount, exchange quotes, and scheduled transfers, checked when they
+// are set up since nobody is there to answer a challenge when they run
+func (server *Server) checkStepUp(ctx *gin.Context, currency string, amount int64) bool {
+	if server.requiresStepUp(currency, amount) {
+		err := fmt.Errorf("%w: %d %s", errStepUpRequired, amount, currency)
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/chensheep/simple-bank-backend/db/mock"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepUpThreshold(t *testing.T) {
+	user, _ := createRandomUser(t)
+	threshold := int64(1000)
+
+	testCases := []struct {
+		name string
+		url  string
+		body gin.H
+	}{
+		{
+			name: "Transfer",
+			url:  "/transfers",
+			body: gin.H{"from_account_id": 1, "to_account_id": 2, "amount": threshold, "currency": util.USD},
+		},
+		{
+			name: "BatchTransfer",
+			url:  "/transfers/batch",
+			body: gin.H{
+				"from_account_id": 1,
+				"currency":        util.USD,
+				"legs": []gin.H{
+					{"to_account_id": 2, "amount": threshold / 2},
+					{"to_account_id": 3, "amount": threshold / 2},
+				},
+			},
+		},
+		{
+			name: "Hold",
+			url:  "/holds",
+			body: gin.H{"account_id": 1, "to_account_id": 2, "amount": threshold, "currency": util.USD},
+		},
+		{
+			name: "ScheduledTransfer",
+			url:  "/scheduled_transfers",
+			body: gin.H{"from_account_id": 1, "to_account_id": 2, "amount": threshold, "currency": util.USD, "schedule": "0 9 * * *"},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			// the amount is rejected before any account is loaded
+			store := mockdb.NewMockStore(mockCtrl)
+
+			server := newTestServer(t, store)
+			server.stepUpThresholds = map[string]int64{util.USD: threshold}
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, tc.url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusForbidden, recorder.Code)
+		})
+	}
+}
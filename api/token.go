@@ -6,7 +6,10 @@ import (
 	"net/http"
 	"time"
 
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/worker"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type renewAccessTokenRequest struct {
@@ -14,10 +17,15 @@ type renewAccessTokenRequest struct {
 }
 
 type renewAccessTokenResponse struct {
-	AccessToken          string    `json:"access_token"`
-	AccessTokenExpiredAt time.Time `json:"access_token_expired_at"`
+	SessionID             uuid.UUID `json:"session_id"`
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiredAt  time.Time `json:"access_token_expired_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiredAt time.Time `json:"refresh_token_expired_at"`
 }
 
+// renewAccessToken exchanges the refresh token for a new access token and a new refresh token.
+// Presenting a refresh token which was exchanged already signs out the whole session family
 func (server *Server) renewAccessToken(ctx *gin.Context) {
 	req := renewAccessTokenRequest{}
 
@@ -67,15 +75,78 @@ func (server *Server) renewAccessToken(ctx *gin.Context) {
 		return
 	}
 
-	accessToken, accessPayload, err := server.tokenMaker.CreateToken(payload.Username, server.config.AccessTokenDuration)
+	// the role is read again so a changed role applies from the next renewal
+	user, err := server.store.GetUser(ctx, payload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.IsFrozen {
+		err := errors.New("user is frozen")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.RefreshTokenDuration)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	reuseAlert, err := worker.NewOutboxMessage(worker.TaskSendRefreshTokenReuseAlert, &worker.SendRefreshTokenReuseAlertPayload{
+		Username:   payload.Username,
+		UserAgent:  ctx.Request.UserAgent(),
+		ClientIp:   ctx.ClientIP(),
+		DetectedAt: time.Now(),
+	}, worker.QueueCritical, 10)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.RotateSessionTx(ctx, db.RotateSessionTxParams{
+		SessionID: session.ID,
+		NewSession: db.CreateSessionParams{
+			ID:           refreshPayload.ID,
+			Username:     payload.Username,
+			RefreshToken: refreshToken,
+			UserAgent:    ctx.Request.UserAgent(),
+			ClientIp:     ctx.ClientIP(),
+			IsBlocked:    false,
+			ExpiredAt:    refreshPayload.ExpiredAt,
+		},
+		ReuseOutboxMessages: []db.CreateOutboxMessageParams{reuseAlert},
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRefreshTokenReused) || errors.Is(err, db.ErrSessionBlocked) {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.AccessTokenDuration)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
 	rep := renewAccessTokenResponse{
-		AccessToken:          accessToken,
-		AccessTokenExpiredAt: accessPayload.ExpiredAt,
+		SessionID:             result.Session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiredAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiredAt: refreshPayload.ExpiredAt,
 	}
 
 	ctx.JSON(http.StatusOK, rep)
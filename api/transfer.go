@@ -2,6 +2,7 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -10,6 +11,11 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	maxIdempotencyKeyLength = 255
+)
+
 type createTransferRequset struct {
 	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
 	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
@@ -26,9 +32,22 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		return
 	}
 
+	idempotencyKey := ctx.GetHeader(idempotencyKeyHeader)
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		err := fmt.Errorf("idempotency key must be at most %d characters", maxIdempotencyKeyLength)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !server.checkStepUp(ctx, req.Currency, req.Amount) {
+		return
+	}
+
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 
-	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	// the funds and the status of the accounts are checked by TransferTx, after an idempotent retry
+	// has been matched, so a retry gets the original result even if the account has changed since
+	fromAccount, valid := server.currencyAccount(ctx, req.FromAccountID, req.Currency)
 	if !valid {
 		return
 	}
@@ -38,18 +57,28 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		return
 	}
 
-	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
+	_, valid = server.currencyAccount(ctx, req.ToAccountID, req.Currency)
 	if !valid {
 		return
 	}
 
 	arg := db.TransferTxParams{
-		FromAccountID: req.FromAccountID,
-		ToAccountID:   req.ToAccountID,
-		Amount:        req.Amount,
+		FromAccountID:  req.FromAccountID,
+		ToAccountID:    req.ToAccountID,
+		Amount:         req.Amount,
+		IdempotencyKey: idempotencyKey,
+		Username:       authPayload.Username,
 	}
 	result, err := server.store.TransferTx(ctx, arg)
 	if err != nil {
+		if errors.Is(err, db.ErrIdempotencyKeyConflict) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, db.ErrAccountNotActive) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
@@ -57,15 +86,102 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, result)
 }
 
-// check the currency
-func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
-	account, err := server.store.GetAccount(ctx, accountID)
+type reverseTransferUriRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type reverseTransferJsonRequest struct {
+	Amount int64 `json:"amount" binding:"min=0"`
+}
+
+// reverseTransfer refunds the transfer, only the receiving account's owner can reverse it
+func (server *Server) reverseTransfer(ctx *gin.Context) {
+	var reqUri reverseTransferUriRequest
+	var reqJson reverseTransferJsonRequest
+
+	err := ctx.ShouldBindUri(&reqUri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	err = ctx.ShouldBindJSON(&reqJson)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, err := server.store.GetTransfer(ctx, reqUri.ID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return account, false
+			return
 		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	toAccount, err := server.store.GetAccount(ctx, transfer.ToAccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Username != toAccount.Owner {
+		err := fmt.Errorf("the to account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	arg := db.ReverseTransferTxParams{
+		TransferID: reqUri.ID,
+		Amount:     reqJson.Amount,
+	}
+	result, err := server.store.ReverseTransferTx(ctx, arg)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrTransferNotReversible),
+			errors.Is(err, db.ErrTransferFullyReversed),
+			errors.Is(err, db.ErrCrossCurrencyReversal):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, db.ErrReversalAmountExceeded),
+			errors.Is(err, db.ErrInsufficientFunds),
+			errors.Is(err, db.ErrAccountNotActive):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// check the currency and the status
+func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
+	account, valid := server.currencyAccount(ctx, accountID, currency)
+	if !valid {
+		return account, false
+	}
+
+	return account, checkActiveAccount(ctx, account)
+}
+
+// check the account exists and can send or receive money
+func (server *Server) activeAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
+	account, valid := server.existingAccount(ctx, accountID)
+	if !valid {
+		return account, false
+	}
+
+	return account, checkActiveAccount(ctx, account)
+}
+
+// check the account exists and has the currency, whatever its status
+func (server *Server) currencyAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
+	account, valid := server.existingAccount(ctx, accountID)
+	if !valid {
 		return account, false
 	}
 
@@ -77,3 +193,27 @@ func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency s
 
 	return account, true
 }
+
+func (server *Server) existingAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return account, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, false
+	}
+
+	return account, true
+}
+
+func checkActiveAccount(ctx *gin.Context, account db.Account) bool {
+	if account.Status != db.AccountStatusActive {
+		err := fmt.Errorf("account [%d] is %s", account.ID, account.Status)
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return false
+	}
+
+	return true
+}
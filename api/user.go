@@ -2,10 +2,12 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 	"time"
 
 	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/login"
 	"github.com/chensheep/simple-bank-backend/util"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -26,6 +28,8 @@ type userResponse struct {
 	Email             string    `json:"email"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
+	Role              string    `json:"role"`
+	IsFrozen          bool      `json:"is_frozen"`
 }
 
 func newUserReponse(user db.User) userResponse {
@@ -35,6 +39,8 @@ func newUserReponse(user db.User) userResponse {
 		Email:             user.Email,
 		PasswordChangedAt: user.PasswordChangedAt,
 		CreatedAt:         user.CreatedAt,
+		Role:              user.Role,
+		IsFrozen:          user.IsFrozen,
 	}
 }
 
@@ -88,6 +94,26 @@ type loginUserResponse struct {
 	RefreshToken         string       `json:"refresh_token"`
 	RefreshTokenExpired  time.Time    `json:"refresh_token_expired_at"`
 	User                 userResponse `json:"user"`
+	// TotpRequired is set when the user has two-factor authentication enabled, the tokens are
+	// then returned by POST /users/login/totp, with the challenge token and a code from the authenticator app
+	TotpRequired       bool      `json:"totp_required"`
+	ChallengeToken     string    `json:"challenge_token"`
+	ChallengeExpiredAt time.Time `json:"challenge_expired_at"`
+}
+
+// loginErrorResponse answers the errors of the authenticator
+func loginErrorResponse(ctx *gin.Context, err error) {
+	var lockedErr *login.LockedError
+	switch {
+	case errors.Is(err, login.ErrInvalidCredentials), errors.Is(err, login.ErrInvalidChallenge):
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	case errors.Is(err, login.ErrUserFrozen):
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+	case errors.As(err, &lockedErr):
+		ctx.JSON(http.StatusTooManyRequests, errorResponse(err))
+	default:
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
 }
 
 func (server *Server) loginUser(ctx *gin.Context) {
@@ -99,29 +125,77 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		return
 	}
 
-	user, err := server.store.GetUser(ctx, req.Username)
+	// failed logins are counted like on the gRPC server, which locks out the same usernames and client ips
+	user, err := server.authenticator.Authenticate(ctx, req.Username, req.Password, ctx.ClientIP())
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		loginErrorResponse(ctx, err)
+		return
+	}
+
+	totpRequired, err := server.authenticator.SecondFactorRequired(ctx, user.Username)
+	if err != nil {
+		loginErrorResponse(ctx, err)
+		return
+	}
+	if totpRequired {
+		// the password is right, but the login attempts are only reset once the code is
+		challenge, err := server.authenticator.CreateChallenge(ctx, user.Username)
+		if err != nil {
+			loginErrorResponse(ctx, err)
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+
+		ctx.JSON(http.StatusOK, loginUserResponse{
+			TotpRequired:       true,
+			ChallengeToken:     challenge.ID.String(),
+			ChallengeExpiredAt: challenge.ExpiredAt,
+		})
 		return
 	}
 
-	err = util.CheckPassword(req.Password, user.HashedPassword)
+	server.loginSession(ctx, user)
+}
+
+type verifyLoginTotpRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required,uuid"`
+	Code           string `json:"code" binding:"required,min=6,max=20"`
+}
+
+// verifyLoginTotp finishes the login started by loginUser for a user with two-factor authentication.
+// Wrong codes count as failed logins of the user, and use up the challenge after a few tries
+func (server *Server) verifyLoginTotp(ctx *gin.Context) {
+	var req verifyLoginTotpRequest
+
+	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
-	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, server.config.AccessTokenDuration)
+	user, err := server.authenticator.VerifyChallenge(ctx, uuid.MustParse(req.ChallengeToken), req.Code, ctx.ClientIP())
+	if err != nil {
+		loginErrorResponse(ctx, err)
+		return
+	}
+
+	server.loginSession(ctx, user)
+}
+
+// loginSession resets the failed logins of the user, and creates the session and its tokens
+func (server *Server) loginSession(ctx *gin.Context, user db.User) {
+	err := server.authenticator.ResetAttempts(ctx, user.Username)
+	if err != nil {
+		loginErrorResponse(ctx, err)
+		return
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.AccessTokenDuration)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.Username, server.config.RefreshTokenDuration)
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.RefreshTokenDuration)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
@@ -135,6 +209,7 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		ClientIp:     ctx.ClientIP(),
 		IsBlocked:    false,
 		ExpiredAt:    refreshPayload.ExpiredAt,
+		FamilyID:     refreshPayload.ID,
 	})
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
@@ -152,3 +227,41 @@ func (server *Server) loginUser(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, rep)
 }
+
+type userStatusRequest struct {
+	Username string `uri:"username" binding:"required,alphanum,min=4,max=255"`
+}
+
+// freezeUser stops the user from logging in and signs out all of the user's sessions
+func (server *Server) freezeUser(ctx *gin.Context) {
+	server.updateUserFrozen(ctx, true)
+}
+
+func (server *Server) unfreezeUser(ctx *gin.Context) {
+	server.updateUserFrozen(ctx, false)
+}
+
+func (server *Server) updateUserFrozen(ctx *gin.Context, frozen bool) {
+	var req userStatusRequest
+
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.FreezeUserTx(ctx, db.FreezeUserTxParams{
+		Username: req.Username,
+		Frozen:   frozen,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserReponse(result.User))
+}
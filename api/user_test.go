@@ -10,12 +10,15 @@ import (
 	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 
 	mockdb "github.com/chensheep/simple-bank-backend/db/mock"
 	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/token"
 	"github.com/chensheep/simple-bank-backend/util"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
 	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 )
@@ -227,6 +230,7 @@ func createRandomUser(t *testing.T) (db.User, string) {
 		HashedPassword: hashedPassword,
 		FullName:       util.RandomOwner(),
 		Email:          util.RandomEmail(),
+		Role:           util.DepositorRole,
 	}, password
 }
 
@@ -237,10 +241,21 @@ func TestLoginUser(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	mockStore := mockdb.NewMockStore(mockCtrl)
+	expectNoLoginAttempts(mockStore, user.Username)
 	mockStore.EXPECT().
 		GetUser(gomock.Any(), gomock.Eq(user.Username)).
 		Times(1).
 		Return(user, nil)
+	mockStore.EXPECT().
+		GetUserTotp(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(db.UserTotp{}, sql.ErrNoRows)
+	mockStore.EXPECT().
+		DeleteLoginAttempt(gomock.Any(), gomock.Eq(db.DeleteLoginAttemptParams{
+			Scope:      db.LoginScopeUsername,
+			Identifier: user.Username,
+		})).
+		Times(1)
 	mockStore.EXPECT().
 		CreateSession(gomock.Any(), gomock.Any()).Times(1)
 
@@ -263,3 +278,460 @@ func TestLoginUser(t *testing.T) {
 	t.Log(w.Body.String())
 
 }
+
+func TestLoginUserTotpRequired(t *testing.T) {
+	user, password := createRandomUser(t)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStore := mockdb.NewMockStore(mockCtrl)
+	expectNoLoginAttempts(mockStore, user.Username)
+	mockStore.EXPECT().
+		GetUser(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(user, nil)
+	mockStore.EXPECT().
+		GetUserTotp(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(db.UserTotp{
+			Username:    user.Username,
+			ConfirmedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		}, nil)
+	challenge := db.LoginChallenge{
+		ID:        uuid.New(),
+		Username:  user.Username,
+		ExpiredAt: time.Now().Add(5 * time.Minute),
+	}
+	mockStore.EXPECT().
+		CreateLoginChallenge(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(challenge, nil)
+	// no tokens until the challenge is answered with a code
+	mockStore.EXPECT().
+		DeleteLoginAttempt(gomock.Any(), gomock.Any()).Times(0)
+	mockStore.EXPECT().
+		CreateSession(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, mockStore)
+	w := httptest.NewRecorder()
+
+	data, err := json.Marshal(gin.H{
+		"username": user.Username,
+		"password": password,
+	})
+	require.NoError(t, err)
+
+	r, err := http.NewRequest("POST", "/users/login", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var rsp loginUserResponse
+	err = json.Unmarshal(w.Body.Bytes(), &rsp)
+	require.NoError(t, err)
+	require.True(t, rsp.TotpRequired)
+	require.Equal(t, challenge.ID.String(), rsp.ChallengeToken)
+	require.Empty(t, rsp.AccessToken)
+	require.Empty(t, rsp.RefreshToken)
+}
+
+func TestVerifyLoginTotpAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+
+	secret, err := util.NewTotpSecret()
+	require.NoError(t, err)
+	userTotp := db.UserTotp{
+		Username:    user.Username,
+		Secret:      secret,
+		ConfirmedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	}
+	code, err := util.TotpCode(secret, util.TotpStep(time.Now()))
+	require.NoError(t, err)
+
+	challenge := db.LoginChallenge{
+		ID:        uuid.New(),
+		Username:  user.Username,
+		ExpiredAt: time.Now().Add(5 * time.Minute),
+	}
+
+	testCases := []struct {
+		name          string
+		code          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			code: code,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginChallenge(gomock.Any(), gomock.Eq(challenge.ID)).
+					Times(1).
+					Return(challenge, nil)
+				expectNoLoginAttempts(store, user.Username)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetUserTotp(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(userTotp, nil)
+				store.EXPECT().
+					CompleteLoginChallengeTx(gomock.Any(), gomock.Eq(db.CompleteLoginChallengeTxParams{
+						ChallengeID:  challenge.ID,
+						Username:     user.Username,
+						SecondFactor: db.SecondFactor{Step: util.TotpStep(time.Now())},
+					})).
+					Times(1)
+				store.EXPECT().
+					DeleteLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(1)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp loginUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.AccessToken)
+				require.Equal(t, user.Username, rsp.User.Username)
+			},
+		},
+		{
+			name: "WrongCode",
+			code: "000000",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginChallenge(gomock.Any(), gomock.Eq(challenge.ID)).
+					Times(1).
+					Return(challenge, nil)
+				expectNoLoginAttempts(store, user.Username)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetUserTotp(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(userTotp, nil)
+				store.EXPECT().
+					RecordLoginChallengeFailure(gomock.Any(), gomock.Any()).
+					Times(1)
+				store.EXPECT().
+					RecordLoginFailureTx(gomock.Any(), gomock.Any()).
+					Times(1)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "UsedChallenge",
+			code: code,
+			buildStubs: func(store *mockdb.MockStore) {
+				usedChallenge := challenge
+				usedChallenge.IsUsed = true
+				store.EXPECT().
+					GetLoginChallenge(gomock.Any(), gomock.Eq(challenge.ID)).
+					Times(1).
+					Return(usedChallenge, nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mockStore := mockdb.NewMockStore(mockCtrl)
+			tc.buildStubs(mockStore)
+
+			server := newTestServer(t, mockStore)
+			w := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{
+				"challenge_token": challenge.ID.String(),
+				"code":            tc.code,
+			})
+			require.NoError(t, err)
+
+			r, err := http.NewRequest("POST", "/users/login/totp", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(w, r)
+			tc.checkResponse(w)
+		})
+	}
+}
+
+func TestLoginFrozenUser(t *testing.T) {
+	user, password := createRandomUser(t)
+	user.IsFrozen = true
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStore := mockdb.NewMockStore(mockCtrl)
+	expectNoLoginAttempts(mockStore, user.Username)
+	mockStore.EXPECT().
+		GetUser(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(user, nil)
+	mockStore.EXPECT().
+		CreateSession(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, mockStore)
+	w := httptest.NewRecorder()
+
+	body := gin.H{
+		"username": user.Username,
+		"password": password,
+	}
+
+	data, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	r, err := http.NewRequest("POST", "/users/login", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(w, r)
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestLoginInvalidCredentials(t *testing.T) {
+	user, password := createRandomUser(t)
+
+	testCases := []struct {
+		name       string
+		password   string
+		buildStubs func(store *mockdb.MockStore)
+	}{
+		{
+			name:     "UserNotFound",
+			password: password,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+			},
+		},
+		{
+			name:     "WrongPassword",
+			password: util.RandomString(8),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+			},
+		},
+	}
+
+	var bodies []string
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mockStore := mockdb.NewMockStore(mockCtrl)
+			expectNoLoginAttempts(mockStore, user.Username)
+			tc.buildStubs(mockStore)
+			// the failure is counted, an unknown username like the others
+			mockStore.EXPECT().
+				RecordLoginFailureTx(gomock.Any(), gomock.Any()).
+				Times(1).
+				Return(db.RecordLoginFailureTxResult{}, nil)
+			mockStore.EXPECT().
+				CreateSession(gomock.Any(), gomock.Any()).Times(0)
+
+			server := newTestServer(t, mockStore)
+			w := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{
+				"username": user.Username,
+				"password": tc.password,
+			})
+			require.NoError(t, err)
+
+			r, err := http.NewRequest("POST", "/users/login", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(w, r)
+			require.Equal(t, http.StatusUnauthorized, w.Code)
+			bodies = append(bodies, w.Body.String())
+		})
+	}
+
+	// the response must not tell an unknown username from a wrong password
+	require.Len(t, bodies, 2)
+	require.Equal(t, bodies[0], bodies[1])
+}
+
+func TestLoginLockedUser(t *testing.T) {
+	user, password := createRandomUser(t)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStore := mockdb.NewMockStore(mockCtrl)
+	mockStore.EXPECT().
+		GetLoginAttempt(gomock.Any(), gomock.Eq(db.GetLoginAttemptParams{
+			Scope:      db.LoginScopeUsername,
+			Identifier: user.Username,
+		})).
+		Times(1).
+		Return(db.LoginAttempt{
+			Scope:        db.LoginScopeUsername,
+			Identifier:   user.Username,
+			FailedCount:  5,
+			LastFailedAt: time.Now(),
+			LockedUntil:  sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+		}, nil)
+	// even the right password is refused while the username is locked
+	mockStore.EXPECT().
+		GetUser(gomock.Any(), gomock.Any()).Times(0)
+	mockStore.EXPECT().
+		CreateSession(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, mockStore)
+	w := httptest.NewRecorder()
+
+	data, err := json.Marshal(gin.H{
+		"username": user.Username,
+		"password": password,
+	})
+	require.NoError(t, err)
+
+	r, err := http.NewRequest("POST", "/users/login", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(w, r)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+// expectNoLoginAttempts stubs a username without failed logins, the test requests have no client ip
+func expectNoLoginAttempts(store *mockdb.MockStore, username string) {
+	store.EXPECT().
+		GetLoginAttempt(gomock.Any(), gomock.Eq(db.GetLoginAttemptParams{
+			Scope:      db.LoginScopeUsername,
+			Identifier: username,
+		})).
+		Times(1).
+		Return(db.LoginAttempt{}, sql.ErrNoRows)
+}
+
+func TestFreezeUserAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, maker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
+				addAuthorization(t, request, maker, authorizationTypeBearer, "admin", util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				frozenUser := user
+				frozenUser.IsFrozen = true
+				store.EXPECT().
+					FreezeUserTx(gomock.Any(), gomock.Eq(db.FreezeUserTxParams{Username: user.Username, Frozen: true})).
+					Times(1).
+					Return(db.FreezeUserTxResult{User: frozenUser, BlockedSessions: 2}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp userResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.True(t, rsp.IsFrozen)
+			},
+		},
+		{
+			name: "Banker",
+			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
+				addAuthorization(t, request, maker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().FreezeUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().FreezeUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "UserNotFound",
+			setupAuth: func(t *testing.T, request *http.Request, maker token.Maker) {
+				addAuthorization(t, request, maker, authorizationTypeBearer, "admin", util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					FreezeUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.FreezeUserTxResult{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/users/%s/freeze", user.Username)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,12 @@
+ALTER TABLE "accounts" ADD COLUMN "overdraft_limit" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "accounts" ADD CONSTRAINT "overdraft_limit_non_negative" CHECK ("overdraft_limit" >= 0);
+
+-- the balance could go negative before, those accounts get an overdraft covering what they owe,
+-- so the constraint holds for the existing rows without letting them go any further below zero
+UPDATE "accounts" SET "overdraft_limit" = -"balance" WHERE "balance" < 0;
+
+-- safeguard for every code path that changes the balance
+ALTER TABLE "accounts" ADD CONSTRAINT "balance_within_overdraft_limit" CHECK ("balance" + "overdraft_limit" >= 0);
+
+COMMENT ON COLUMN "accounts"."overdraft_limit" IS 'how far the balance may go below zero';
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS "holds";
+
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "balance_within_overdraft_limit";
+
+ALTER TABLE "accounts" ADD CONSTRAINT "balance_within_overdraft_limit" CHECK ("balance" + "overdraft_limit" >= 0);
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "held_amount_non_negative";
+
+ALTER TABLE "accounts" DROP COLUMN "available_balance";
+
+ALTER TABLE "accounts" DROP COLUMN "held_amount";
@@ -0,0 +1,42 @@
+ALTER TABLE "accounts" ADD COLUMN "held_amount" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "accounts" ADD COLUMN "available_balance" bigint NOT NULL GENERATED ALWAYS AS ("balance" - "held_amount") STORED;
+
+ALTER TABLE "accounts" ADD CONSTRAINT "held_amount_non_negative" CHECK ("held_amount" >= 0);
+
+-- held funds are no longer available, so they count against the overdraft limit
+ALTER TABLE "accounts" DROP CONSTRAINT "balance_within_overdraft_limit";
+
+ALTER TABLE "accounts" ADD CONSTRAINT "balance_within_overdraft_limit" CHECK ("balance" - "held_amount" + "overdraft_limit" >= 0);
+
+COMMENT ON COLUMN "accounts"."held_amount" IS 'sum of the authorized holds';
+
+COMMENT ON COLUMN "accounts"."available_balance" IS 'balance minus the authorized holds';
+
+CREATE TABLE "holds" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "captured_amount" bigint NOT NULL DEFAULT 0,
+  "status" varchar NOT NULL DEFAULT 'authorized',
+  "transfer_id" bigint,
+  "expired_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "holds" ADD CONSTRAINT "hold_amount_positive" CHECK ("amount" > 0);
+
+ALTER TABLE "holds" ADD CONSTRAINT "hold_status_check" CHECK ("status" IN ('authorized', 'captured', 'voided', 'expired'));
+
+CREATE INDEX ON "holds" ("account_id");
+
+CREATE INDEX ON "holds" ("status", "expired_at");
+
+COMMENT ON COLUMN "holds"."status" IS 'authorized, captured, voided or expired';
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
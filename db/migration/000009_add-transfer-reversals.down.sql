@@ -0,0 +1,5 @@
+ALTER TABLE "entries" DROP COLUMN "transfer_id";
+
+ALTER TABLE "transfers" DROP COLUMN "reversed_amount";
+
+ALTER TABLE "transfers" DROP COLUMN "reversed_transfer_id";
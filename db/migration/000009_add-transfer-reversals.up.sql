@@ -0,0 +1,40 @@
+-- the first TransferTx stored the transfers between a higher and a lower account id the other way around,
+-- with a negative amount. Turn them back into the transfer made, its entries already match either way
+UPDATE "transfers"
+SET
+  "from_account_id" = "to_account_id",
+  "to_account_id" = "from_account_id",
+  "amount" = -"amount"
+WHERE "amount" < 0;
+
+ALTER TABLE "transfers" ADD COLUMN "reversed_transfer_id" bigint;
+
+ALTER TABLE "transfers" ADD COLUMN "reversed_amount" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "transfers" ADD CONSTRAINT "reversed_amount_within_amount" CHECK ("reversed_amount" >= 0 AND "reversed_amount" <= "amount");
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("reversed_transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "transfers" ("reversed_transfer_id");
+
+COMMENT ON COLUMN "transfers"."reversed_transfer_id" IS 'the original transfer when this one is a reversal';
+
+COMMENT ON COLUMN "transfers"."reversed_amount" IS 'sum of the reversals of this transfer';
+
+ALTER TABLE "entries" ADD COLUMN "transfer_id" bigint;
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "entries" ("transfer_id");
+
+-- link the entries of the existing transfers, the entries and the transfer were written
+-- in the same transaction, so they share its timestamp
+UPDATE "entries" e
+SET "transfer_id" = t."id"
+FROM "transfers" t
+WHERE e."transfer_id" IS NULL
+  AND e."created_at" = t."created_at"
+  AND (
+    (e."account_id" = t."from_account_id" AND e."amount" = -t."amount")
+    OR (e."account_id" = t."to_account_id" AND e."amount" = t."amount")
+  );
@@ -0,0 +1,7 @@
+ALTER TABLE "transfers" DROP COLUMN "exchange_rate";
+
+ALTER TABLE "transfers" DROP COLUMN "to_amount";
+
+DROP TABLE IF EXISTS "exchange_quotes";
+
+DROP TABLE IF EXISTS "exchange_rates";
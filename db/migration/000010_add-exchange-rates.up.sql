@@ -0,0 +1,45 @@
+CREATE TABLE "exchange_rates" (
+  "from_currency" varchar NOT NULL,
+  "to_currency" varchar NOT NULL,
+  "rate" numeric(20,10) NOT NULL,
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("from_currency", "to_currency")
+);
+
+ALTER TABLE "exchange_rates" ADD CONSTRAINT "exchange_rate_positive" CHECK ("rate" > 0);
+
+COMMENT ON COLUMN "exchange_rates"."rate" IS 'amount of to_currency for one unit of from_currency';
+
+CREATE TABLE "exchange_quotes" (
+  "id" bigserial PRIMARY KEY,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "from_amount" bigint NOT NULL,
+  "to_amount" bigint NOT NULL,
+  "rate" numeric(20,10) NOT NULL,
+  "transfer_id" bigint,
+  "expired_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "exchange_quotes" ("from_account_id");
+
+COMMENT ON COLUMN "exchange_quotes"."transfer_id" IS 'set once the quote has been executed';
+
+ALTER TABLE "exchange_quotes" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "exchange_quotes" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "exchange_quotes" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "transfers" ADD COLUMN "to_amount" bigint;
+
+UPDATE "transfers" SET "to_amount" = "amount";
+
+ALTER TABLE "transfers" ALTER COLUMN "to_amount" SET NOT NULL;
+
+ALTER TABLE "transfers" ADD COLUMN "exchange_rate" numeric(20,10);
+
+COMMENT ON COLUMN "transfers"."to_amount" IS 'amount credited in the currency of the to account';
+
+COMMENT ON COLUMN "transfers"."exchange_rate" IS 'the rate used by a cross-currency transfer';
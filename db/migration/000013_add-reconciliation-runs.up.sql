@@ -0,0 +1,47 @@
+-- link the entries written before entries.transfer_id existed: a transfer and its
+-- entries are created in the same transaction, so they share the same created_at
+UPDATE "entries" AS e
+SET "transfer_id" = t."id"
+FROM "transfers" AS t
+WHERE e."transfer_id" IS NULL
+  AND e."created_at" = t."created_at"
+  AND ((e."account_id" = t."from_account_id" AND e."amount" = -t."amount")
+    OR (e."account_id" = t."to_account_id" AND e."amount" = t."to_amount"));
+
+CREATE TABLE "reconciliation_runs" (
+  "id" bigserial PRIMARY KEY,
+  "status" varchar NOT NULL,
+  "discrepancy_count" int NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "reconciliation_runs" ADD CONSTRAINT "reconciliation_run_status_check" CHECK ("status" IN ('balanced', 'mismatch'));
+
+COMMENT ON COLUMN "reconciliation_runs"."status" IS 'balanced or mismatch';
+
+CREATE TABLE "reconciliation_discrepancies" (
+  "id" bigserial PRIMARY KEY,
+  "run_id" bigint NOT NULL,
+  "kind" varchar NOT NULL,
+  "account_id" bigint,
+  "transfer_id" bigint,
+  "expected" bigint NOT NULL,
+  "actual" bigint NOT NULL,
+  "details" varchar NOT NULL DEFAULT ''
+);
+
+ALTER TABLE "reconciliation_discrepancies" ADD CONSTRAINT "reconciliation_discrepancy_kind_check" CHECK ("kind" IN ('account_balance', 'transfer_entries'));
+
+CREATE INDEX ON "reconciliation_discrepancies" ("run_id");
+
+COMMENT ON COLUMN "reconciliation_discrepancies"."kind" IS 'account_balance or transfer_entries';
+
+COMMENT ON COLUMN "reconciliation_discrepancies"."expected" IS 'sum of the entries, or the number of entries a transfer must have';
+
+COMMENT ON COLUMN "reconciliation_discrepancies"."actual" IS 'balance of the account, or the number of matching entries found';
+
+ALTER TABLE "reconciliation_discrepancies" ADD FOREIGN KEY ("run_id") REFERENCES "reconciliation_runs" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "reconciliation_discrepancies" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "reconciliation_discrepancies" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
@@ -0,0 +1,15 @@
+CREATE TABLE "outbox" (
+  "id" bigserial PRIMARY KEY,
+  "task_type" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "queue" varchar NOT NULL DEFAULT 'default',
+  "max_retry" int NOT NULL DEFAULT 25,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "published_at" timestamptz
+);
+
+CREATE INDEX ON "outbox" ("id") WHERE "published_at" IS NULL;
+
+COMMENT ON COLUMN "outbox"."task_type" IS 'the asynq task type the row is published as';
+
+COMMENT ON COLUMN "outbox"."published_at" IS 'set once the task has been enqueued';
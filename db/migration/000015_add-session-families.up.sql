@@ -0,0 +1,17 @@
+-- a session is rotated into a new one on every renewal, all the sessions
+-- rotated from the same login share its family_id
+ALTER TABLE "sessions" ADD COLUMN "family_id" uuid;
+
+UPDATE "sessions" SET "family_id" = "id";
+
+ALTER TABLE "sessions" ALTER COLUMN "family_id" SET NOT NULL;
+
+ALTER TABLE "sessions" ADD COLUMN "rotated_at" timestamptz;
+
+CREATE INDEX ON "sessions" ("family_id");
+
+CREATE INDEX ON "sessions" ("username");
+
+COMMENT ON COLUMN "sessions"."family_id" IS 'id of the session created at login';
+
+COMMENT ON COLUMN "sessions"."rotated_at" IS 'set once the refresh token has been exchanged for a new one';
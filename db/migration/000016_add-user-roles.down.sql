@@ -0,0 +1,5 @@
+ALTER TABLE IF EXISTS "users" DROP CONSTRAINT IF EXISTS "user_role_check";
+
+ALTER TABLE "users" DROP COLUMN "is_frozen";
+
+ALTER TABLE "users" DROP COLUMN "role";
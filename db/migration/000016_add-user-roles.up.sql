@@ -0,0 +1,9 @@
+ALTER TABLE "users" ADD COLUMN "role" varchar NOT NULL DEFAULT 'depositor';
+
+ALTER TABLE "users" ADD CONSTRAINT "user_role_check" CHECK ("role" IN ('depositor', 'banker', 'admin'));
+
+ALTER TABLE "users" ADD COLUMN "is_frozen" boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN "users"."role" IS 'depositor, banker or admin';
+
+COMMENT ON COLUMN "users"."is_frozen" IS 'frozen users cannot log in or renew their tokens';
@@ -0,0 +1,27 @@
+CREATE TABLE "ledger_adjustments" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "entry_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "reason" varchar NOT NULL,
+  "created_by" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "ledger_adjustments" ADD CONSTRAINT "ledger_adjustment_amount_non_zero" CHECK ("amount" <> 0);
+
+CREATE INDEX ON "ledger_adjustments" ("account_id");
+
+CREATE UNIQUE INDEX ON "ledger_adjustments" ("entry_id");
+
+COMMENT ON COLUMN "ledger_adjustments"."amount" IS 'credited when positive, debited when negative';
+
+COMMENT ON COLUMN "ledger_adjustments"."reason" IS 'why the balance was adjusted, kept for audit';
+
+COMMENT ON COLUMN "ledger_adjustments"."created_by" IS 'the admin who made the adjustment';
+
+ALTER TABLE "ledger_adjustments" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "ledger_adjustments" ADD FOREIGN KEY ("entry_id") REFERENCES "entries" ("id");
+
+ALTER TABLE "ledger_adjustments" ADD FOREIGN KEY ("created_by") REFERENCES "users" ("username");
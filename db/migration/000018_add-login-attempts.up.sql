@@ -0,0 +1,17 @@
+CREATE TABLE "login_attempts" (
+  "scope" varchar NOT NULL,
+  "identifier" varchar NOT NULL,
+  "failed_count" int NOT NULL DEFAULT 0,
+  "last_failed_at" timestamptz NOT NULL DEFAULT (now()),
+  "locked_until" timestamptz,
+  "unlock_code" varchar,
+  PRIMARY KEY ("scope", "identifier")
+);
+
+COMMENT ON COLUMN "login_attempts"."scope" IS 'username or client_ip';
+
+COMMENT ON COLUMN "login_attempts"."identifier" IS 'the username or the client ip the failures are counted for, the username may not exist';
+
+COMMENT ON COLUMN "login_attempts"."locked_until" IS 'logins are rejected until then';
+
+COMMENT ON COLUMN "login_attempts"."unlock_code" IS 'sent to the user to lift the lock early';
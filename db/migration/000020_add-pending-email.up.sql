@@ -0,0 +1,3 @@
+ALTER TABLE "users" ADD COLUMN "pending_email" varchar;
+
+COMMENT ON COLUMN "users"."pending_email" IS 'new email waiting to be verified before it replaces email';
@@ -0,0 +1,26 @@
+CREATE TABLE "transfer_challenges" (
+  "id" uuid PRIMARY KEY,
+  "hold_id" bigint UNIQUE NOT NULL,
+  "username" varchar NOT NULL,
+  "method" varchar NOT NULL,
+  "hashed_code" varchar NOT NULL DEFAULT '',
+  "idempotency_key" varchar,
+  "failed_count" int NOT NULL DEFAULT 0,
+  "is_used" bool NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "expired_at" timestamptz NOT NULL
+);
+
+CREATE UNIQUE INDEX ON "transfer_challenges" ("username", "idempotency_key");
+
+COMMENT ON COLUMN "transfer_challenges"."hold_id" IS 'the hold reserving the funds of the pending transfer';
+
+COMMENT ON COLUMN "transfer_challenges"."method" IS 'totp or email';
+
+COMMENT ON COLUMN "transfer_challenges"."hashed_code" IS 'sha256 of the emailed code, empty for totp';
+
+COMMENT ON COLUMN "transfer_challenges"."idempotency_key" IS 'idempotency key of the transfer, used once it is confirmed';
+
+ALTER TABLE "transfer_challenges" ADD FOREIGN KEY ("hold_id") REFERENCES "holds" ("id");
+
+ALTER TABLE "transfer_challenges" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
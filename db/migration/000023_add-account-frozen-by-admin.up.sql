@@ -0,0 +1,3 @@
+ALTER TABLE "accounts" ADD COLUMN "frozen_by_admin" boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN "accounts"."frozen_by_admin" IS 'set while the account is frozen by an admin, its owner can then neither unfreeze nor close it';
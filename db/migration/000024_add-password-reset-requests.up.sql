@@ -0,0 +1,12 @@
+CREATE TABLE "password_reset_requests" (
+  "id" bigserial PRIMARY KEY,
+  "email" varchar NOT NULL,
+  "client_ip" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "password_reset_requests" ("email", "created_at");
+
+CREATE INDEX ON "password_reset_requests" ("client_ip", "created_at");
+
+COMMENT ON COLUMN "password_reset_requests"."email" IS 'the email asked for, requests for unknown emails are counted too';
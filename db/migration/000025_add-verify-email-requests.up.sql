@@ -0,0 +1,9 @@
+CREATE TABLE "verify_email_requests" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "verify_email_requests" ("username", "created_at");
+
+ALTER TABLE "verify_email_requests" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
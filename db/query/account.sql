@@ -36,6 +36,26 @@ SET balance = balance + sqlc.arg(amount)
 WHERE id = sqlc.arg(id)
 RETURNING *;
 
--- name: DeleteAccount :exec
-DELETE FROM accounts
-WHERE id = $1;
\ No newline at end of file
+-- name: AddAccountHeldAmount :one
+UPDATE accounts
+SET held_amount = held_amount + sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $1, frozen_by_admin = $2
+WHERE id = $3
+RETURNING *;
+
+-- name: UpdateAccountOverdraftLimit :one
+UPDATE accounts
+SET overdraft_limit = $1
+WHERE id = $2
+RETURNING *;
+
+-- name: ListActiveAccounts :many
+SELECT * FROM accounts
+WHERE id > sqlc.arg(after_id) AND status <> 'closed'
+ORDER BY id
+LIMIT sqlc.arg(batch_size);
\ No newline at end of file
@@ -1,9 +1,10 @@
 -- name: CreateEntry :one
 INSERT INTO entries (
     account_id, 
-    amount
+    amount,
+    transfer_id
 ) VALUES (
-    $1, $2
+    $1, $2, $3
 )
 RETURNING *;
 
@@ -13,9 +14,32 @@ FROM entries
 WHERE id = $1
 LIMIT 1;
 
--- name: ListEntries :many
+-- name: ListAccountEntries :many
 SELECT *
 FROM entries
-ORDER BY id
-LIMIT $1
-OFFSET $2;
+WHERE account_id = sqlc.arg(account_id)
+  AND (sqlc.narg(direction)::varchar IS NULL
+    OR (sqlc.narg(direction) = 'in' AND amount > 0)
+    OR (sqlc.narg(direction) = 'out' AND amount < 0))
+  AND (sqlc.narg(start_time)::timestamptz IS NULL OR created_at >= sqlc.narg(start_time))
+  AND (sqlc.narg(end_time)::timestamptz IS NULL OR created_at < sqlc.narg(end_time))
+  AND (sqlc.narg(min_amount)::bigint IS NULL OR abs(amount) >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::bigint IS NULL OR abs(amount) <= sqlc.narg(max_amount))
+  AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL
+    OR (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: ListAccountEntriesInPeriod :many
+SELECT *
+FROM entries
+WHERE account_id = sqlc.arg(account_id)
+  AND created_at >= sqlc.arg(start_time)
+  AND created_at < sqlc.arg(end_time)
+ORDER BY created_at, id;
+
+-- name: SumAccountEntriesBefore :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total
+FROM entries
+WHERE account_id = sqlc.arg(account_id)
+  AND created_at < sqlc.arg(before);
\ No newline at end of file
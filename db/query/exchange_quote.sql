@@ -0,0 +1,30 @@
+-- name: CreateExchangeQuote :one
+INSERT INTO exchange_quotes (
+    from_account_id,
+    to_account_id,
+    from_amount,
+    to_amount,
+    rate,
+    expired_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetExchangeQuote :one
+SELECT * 
+FROM exchange_quotes 
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetExchangeQuoteForUpdate :one
+SELECT * 
+FROM exchange_quotes 
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: UpdateExchangeQuoteTransfer :one
+UPDATE exchange_quotes
+SET transfer_id = $2
+WHERE id = $1
+RETURNING *;
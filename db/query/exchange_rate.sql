@@ -0,0 +1,22 @@
+-- name: GetExchangeRate :one
+SELECT * 
+FROM exchange_rates 
+WHERE from_currency = $1 AND to_currency = $2
+LIMIT 1;
+
+-- name: ListExchangeRates :many
+SELECT *
+FROM exchange_rates
+ORDER BY from_currency, to_currency;
+
+-- name: UpsertExchangeRate :one
+INSERT INTO exchange_rates (
+    from_currency,
+    to_currency,
+    rate
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (from_currency, to_currency) DO UPDATE
+SET rate = EXCLUDED.rate, updated_at = now()
+RETURNING *;
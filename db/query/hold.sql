@@ -0,0 +1,34 @@
+-- name: CreateHold :one
+INSERT INTO holds (
+  account_id,
+  to_account_id,
+  amount,
+  expired_at
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetHold :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1;
+
+-- name: GetHoldForUpdate :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListExpiredHolds :many
+SELECT id FROM holds
+WHERE status = 'authorized'
+  AND expired_at <= now()
+ORDER BY expired_at
+LIMIT $1;
+
+-- name: UpdateHold :one
+UPDATE holds
+SET
+  status = $1,
+  captured_amount = $2,
+  transfer_id = $3
+WHERE id = $4
+RETURNING *;
@@ -0,0 +1,18 @@
+-- name: CreateLedgerAdjustment :one
+INSERT INTO ledger_adjustments (
+  account_id,
+  entry_id,
+  amount,
+  reason,
+  created_by
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: ListLedgerAdjustments :many
+SELECT * FROM ledger_adjustments
+WHERE account_id = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
\ No newline at end of file
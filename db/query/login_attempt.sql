@@ -0,0 +1,42 @@
+-- name: GetLoginAttempt :one
+SELECT * FROM login_attempts
+WHERE scope = $1 AND identifier = $2
+LIMIT 1;
+
+-- name: RecordFailedLoginAttempt :one
+INSERT INTO login_attempts (
+  scope,
+  identifier,
+  failed_count,
+  last_failed_at
+) VALUES (
+  @scope, @identifier, 1, now()
+)
+ON CONFLICT (scope, identifier) DO UPDATE
+SET
+  failed_count = CASE
+    WHEN login_attempts.last_failed_at < @window_start THEN 1
+    ELSE login_attempts.failed_count + 1
+  END,
+  last_failed_at = now()
+RETURNING *;
+
+-- name: LockLoginAttempt :one
+UPDATE login_attempts
+SET
+  locked_until = $3,
+  unlock_code = $4
+WHERE scope = $1 AND identifier = $2
+RETURNING *;
+
+-- name: UnlockLoginAttempt :one
+DELETE FROM login_attempts
+WHERE scope = $1
+  AND identifier = $2
+  AND unlock_code = $3
+  AND locked_until > now()
+RETURNING *;
+
+-- name: DeleteLoginAttempt :exec
+DELETE FROM login_attempts
+WHERE scope = $1 AND identifier = $2;
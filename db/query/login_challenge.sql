@@ -0,0 +1,29 @@
+-- name: CreateLoginChallenge :one
+INSERT INTO login_challenges (
+  id,
+  username,
+  expired_at
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: GetLoginChallenge :one
+SELECT * FROM login_challenges
+WHERE id = $1 LIMIT 1;
+
+-- name: RecordLoginChallengeFailure :one
+UPDATE login_challenges
+SET
+  failed_count = failed_count + 1,
+  is_used = failed_count + 1 >= @max_failures
+WHERE id = @id
+  AND is_used = FALSE
+RETURNING *;
+
+-- name: UseLoginChallenge :one
+UPDATE login_challenges
+SET is_used = TRUE
+WHERE id = $1
+  AND is_used = FALSE
+  AND expired_at > now()
+RETURNING *;
@@ -0,0 +1,23 @@
+-- name: CreateOutboxMessage :one
+INSERT INTO outbox (
+  task_type,
+  payload,
+  queue,
+  max_retry
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: ListUnpublishedOutboxMessages :many
+SELECT * FROM outbox
+WHERE published_at IS NULL
+ORDER BY id
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: MarkOutboxMessagePublished :one
+UPDATE outbox
+SET published_at = now()
+WHERE id = $1
+RETURNING *;
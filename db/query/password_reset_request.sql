@@ -0,0 +1,17 @@
+-- name: CreatePasswordResetRequest :one
+INSERT INTO password_reset_requests (
+  email,
+  client_ip
+) VALUES (
+  $1, $2
+) RETURNING *;
+
+-- name: CountPasswordResetRequestsByEmail :one
+SELECT count(*) FROM password_reset_requests
+WHERE email = $1
+  AND created_at > sqlc.arg(since);
+
+-- name: CountPasswordResetRequestsByClientIp :one
+SELECT count(*) FROM password_reset_requests
+WHERE client_ip = $1
+  AND created_at > sqlc.arg(since);
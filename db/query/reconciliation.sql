@@ -0,0 +1,54 @@
+-- name: CreateReconciliationDiscrepancy :one
+INSERT INTO reconciliation_discrepancies (
+  run_id,
+  kind,
+  account_id,
+  transfer_id,
+  expected,
+  actual,
+  details
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: CreateReconciliationRun :one
+INSERT INTO reconciliation_runs (
+  status,
+  discrepancy_count
+) VALUES (
+  $1, $2
+)
+RETURNING *;
+
+-- name: ListAccountBalanceMismatches :many
+SELECT a.id AS account_id, a.balance, COALESCE(SUM(e.amount), 0)::bigint AS entries_total
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id
+GROUP BY a.id
+HAVING a.balance <> COALESCE(SUM(e.amount), 0)
+ORDER BY a.id;
+
+-- name: ListReconciliationDiscrepancies :many
+SELECT * FROM reconciliation_discrepancies
+WHERE run_id = $1
+ORDER BY id;
+
+-- name: ListReconciliationRuns :many
+SELECT * FROM reconciliation_runs
+ORDER BY id DESC
+LIMIT $1
+OFFSET $2;
+
+-- name: ListTransferEntryMismatches :many
+SELECT t.id AS transfer_id,
+  COUNT(e.id) AS entry_count,
+  COUNT(e.id) FILTER (WHERE e.account_id = t.from_account_id AND e.amount = -t.amount) AS from_entry_count,
+  COUNT(e.id) FILTER (WHERE e.account_id = t.to_account_id AND e.amount = t.to_amount) AS to_entry_count
+FROM transfers t
+LEFT JOIN entries e ON e.transfer_id = t.id
+GROUP BY t.id
+HAVING COUNT(e.id) <> 2
+  OR COUNT(e.id) FILTER (WHERE e.account_id = t.from_account_id AND e.amount = -t.amount) <> 1
+  OR COUNT(e.id) FILTER (WHERE e.account_id = t.to_account_id AND e.amount = t.to_amount) <> 1
+ORDER BY t.id;
@@ -0,0 +1,25 @@
+-- name: CreateScheduledTransferRun :one
+INSERT INTO scheduled_transfer_runs (
+  scheduled_transfer_id,
+  run_at,
+  status,
+  transfer_id,
+  error
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (scheduled_transfer_id, run_at) DO UPDATE
+SET status = EXCLUDED.status, transfer_id = EXCLUDED.transfer_id, error = EXCLUDED.error
+RETURNING *;
+
+-- name: GetScheduledTransferRun :one
+SELECT * FROM scheduled_transfer_runs
+WHERE scheduled_transfer_id = $1 AND run_at = $2
+LIMIT 1;
+
+-- name: ListScheduledTransferRuns :many
+SELECT * FROM scheduled_transfer_runs
+WHERE scheduled_transfer_id = $1
+ORDER BY run_at DESC
+LIMIT $2
+OFFSET $3;
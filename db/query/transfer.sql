@@ -1,10 +1,19 @@
+-- name: AddTransferReversedAmount :one
+UPDATE transfers
+SET reversed_amount = reversed_amount + sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
 -- name: CreateTransfer :one
 INSERT INTO transfers (
     from_account_id, 
     to_account_id, 
-    amount
+    amount,
+    reversed_transfer_id,
+    to_amount,
+    exchange_rate
 ) VALUES (
-    $1, $2, $3
+    $1, $2, $3, $4, $5, $6
 ) RETURNING *;
 
 -- name: GetTransfer :one
@@ -13,8 +22,39 @@ FROM transfers
 WHERE id = $1
 LIMIT 1;
 
--- name: ListTransfers :many
-SELECT *
+-- name: GetTransferForUpdate :one
+SELECT * 
+FROM transfers 
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListAccountTransfers :many
+(SELECT *
+FROM transfers
+WHERE from_account_id = sqlc.arg(account_id)
+  AND (sqlc.narg(direction)::varchar IS NULL OR sqlc.narg(direction) = 'out')
+  AND (sqlc.narg(start_time)::timestamptz IS NULL OR created_at >= sqlc.narg(start_time))
+  AND (sqlc.narg(end_time)::timestamptz IS NULL OR created_at < sqlc.narg(end_time))
+  AND (sqlc.narg(min_amount)::bigint IS NULL OR amount >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::bigint IS NULL OR amount <= sqlc.narg(max_amount))
+  AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL
+    OR (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size))
+UNION ALL
+(SELECT *
 FROM transfers
-LIMIT $1
-OFFSET $2;
\ No newline at end of file
+WHERE to_account_id = sqlc.arg(account_id)
+  AND (sqlc.narg(direction) IS NULL OR sqlc.narg(direction) = 'in')
+  AND (sqlc.narg(direction) IS NOT NULL OR from_account_id <> sqlc.arg(account_id))
+  AND (sqlc.narg(start_time) IS NULL OR created_at >= sqlc.narg(start_time))
+  AND (sqlc.narg(end_time) IS NULL OR created_at < sqlc.narg(end_time))
+  AND (sqlc.narg(min_amount) IS NULL OR amount >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount) IS NULL OR amount <= sqlc.narg(max_amount))
+  AND (sqlc.narg(cursor_created_at) IS NULL
+    OR (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
\ No newline at end of file
@@ -0,0 +1,43 @@
+-- name: CreateTransferChallenge :one
+INSERT INTO transfer_challenges (
+  id,
+  hold_id,
+  username,
+  method,
+  hashed_code,
+  idempotency_key,
+  expired_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (username, idempotency_key) DO NOTHING
+RETURNING *;
+
+-- name: GetTransferChallenge :one
+SELECT * FROM transfer_challenges
+WHERE id = $1 LIMIT 1;
+
+-- name: GetTransferChallengeByHold :one
+SELECT * FROM transfer_challenges
+WHERE hold_id = $1 LIMIT 1;
+
+-- name: GetTransferChallengeByIdempotencyKey :one
+SELECT * FROM transfer_challenges
+WHERE username = $1 AND idempotency_key = $2 LIMIT 1;
+
+-- name: RecordTransferChallengeFailure :one
+UPDATE transfer_challenges
+SET
+  failed_count = failed_count + 1,
+  is_used = failed_count + 1 >= @max_failures
+WHERE id = @id
+  AND is_used = FALSE
+RETURNING *;
+
+-- name: UseTransferChallenge :one
+UPDATE transfer_challenges
+SET is_used = TRUE
+WHERE id = $1
+  AND is_used = FALSE
+  AND expired_at > now()
+RETURNING *;
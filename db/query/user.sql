@@ -13,6 +13,10 @@ RETURNING *;
 SELECT * FROM users
 WHERE username = $1 LIMIT 1;
 
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1 LIMIT 1;
+
 -- name: UpdateUser :one
 UPDATE users 
 SET
@@ -20,7 +24,34 @@ SET
   full_name = COALESCE(sqlc.narg('full_name'), full_name),
   email = COALESCE(sqlc.narg('email'), email),
   password_changed_at = COALESCE(sqlc.narg('password_changed_at'), password_changed_at),
-  is_email_verified = COALESCE(sqlc.narg('is_email_verified'), is_email_verified)
+  is_email_verified = COALESCE(sqlc.narg('is_email_verified'), is_email_verified),
+  role = COALESCE(sqlc.narg('role'), role),
+  is_frozen = COALESCE(sqlc.narg('is_frozen'), is_frozen)
 WHERE 
   username = @username
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: SetUserPendingEmail :one
+UPDATE users
+SET pending_email = $2
+WHERE username = $1
+RETURNING *;
+
+-- name: ApplyUserPendingEmail :one
+UPDATE users
+SET
+  email = pending_email,
+  pending_email = NULL,
+  is_email_verified = TRUE
+WHERE username = $1
+  AND pending_email = $2
+RETURNING *;
+
+-- name: SearchUsers :many
+SELECT * FROM users
+WHERE username ILIKE sqlc.arg(pattern)
+  OR email ILIKE sqlc.arg(pattern)
+  OR full_name ILIKE sqlc.arg(pattern)
+ORDER BY username
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
\ No newline at end of file
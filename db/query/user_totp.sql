@@ -0,0 +1,39 @@
+-- name: UpsertUserTotp :one
+INSERT INTO user_totps (
+  username,
+  secret
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (username) DO UPDATE
+SET
+  secret = EXCLUDED.secret,
+  last_used_step = 0,
+  created_at = now()
+WHERE user_totps.confirmed_at IS NULL
+RETURNING *;
+
+-- name: GetUserTotp :one
+SELECT * FROM user_totps
+WHERE username = $1 LIMIT 1;
+
+-- name: ConfirmUserTotp :one
+UPDATE user_totps
+SET
+  confirmed_at = now(),
+  last_used_step = $2
+WHERE username = $1
+  AND confirmed_at IS NULL
+RETURNING *;
+
+-- name: UseUserTotpStep :one
+UPDATE user_totps
+SET last_used_step = $2
+WHERE username = $1
+  AND confirmed_at IS NOT NULL
+  AND last_used_step < $2
+RETURNING *;
+
+-- name: DeleteUserTotp :exec
+DELETE FROM user_totps
+WHERE username = $1;
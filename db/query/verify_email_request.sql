@@ -0,0 +1,11 @@
+-- name: CreateVerifyEmailRequest :one
+INSERT INTO verify_email_requests (
+  username
+) VALUES (
+  $1
+) RETURNING *;
+
+-- name: CountVerifyEmailRequests :one
+SELECT count(*) FROM verify_email_requests
+WHERE username = $1
+  AND created_at > sqlc.arg(since);
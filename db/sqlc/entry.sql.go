@@ -7,37 +7,42 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 const createEntry = `-- name: CreateEntry :one
 INSERT INTO entries (
     account_id, 
-    amount
+    amount,
+    transfer_id
 ) VALUES (
-    $1, $2
+    $1, $2, $3
 )
-RETURNING id, account_id, amount, created_at
+RETURNING id, account_id, amount, created_at, transfer_id
 `
 
 type CreateEntryParams struct {
-	AccountID int64 `json:"account_id"`
-	Amount    int64 `json:"amount"`
+	AccountID  int64         `json:"account_id"`
+	Amount     int64         `json:"amount"`
+	TransferID sql.NullInt64 `json:"transfer_id"`
 }
 
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount, arg.TransferID)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
 	)
 	return i, err
 }
 
 const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, created_at 
+SELECT id, account_id, amount, created_at, transfer_id 
 FROM entries 
 WHERE id = $1
 LIMIT 1
@@ -51,25 +56,52 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
 	)
 	return i, err
 }
 
-const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, created_at
+const listAccountEntries = `-- name: ListAccountEntries :many
+SELECT id, account_id, amount, created_at, transfer_id
 FROM entries
-ORDER BY id
-LIMIT $1
-OFFSET $2
+WHERE account_id = $1
+  AND ($2::varchar IS NULL
+    OR ($2 = 'in' AND amount > 0)
+    OR ($2 = 'out' AND amount < 0))
+  AND ($3::timestamptz IS NULL OR created_at >= $3)
+  AND ($4::timestamptz IS NULL OR created_at < $4)
+  AND ($5::bigint IS NULL OR abs(amount) >= $5)
+  AND ($6::bigint IS NULL OR abs(amount) <= $6)
+  AND ($7::timestamptz IS NULL
+    OR (created_at, id) < ($7, $8::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT $9
 `
 
-type ListEntriesParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+type ListAccountEntriesParams struct {
+	AccountID       int64          `json:"account_id"`
+	Direction       sql.NullString `json:"direction"`
+	StartTime       sql.NullTime   `json:"start_time"`
+	EndTime         sql.NullTime   `json:"end_time"`
+	MinAmount       sql.NullInt64  `json:"min_amount"`
+	MaxAmount       sql.NullInt64  `json:"max_amount"`
+	CursorCreatedAt sql.NullTime   `json:"cursor_created_at"`
+	CursorID        sql.NullInt64  `json:"cursor_id"`
+	PageSize        int32          `json:"page_size"`
 }
 
-func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
-	rows, err := q.db.QueryContext(ctx, listEntries, arg.Limit, arg.Offset)
+func (q *Queries) ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]Entry, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountEntries,
+		arg.AccountID,
+		arg.Direction,
+		arg.StartTime,
+		arg.EndTime,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -82,6 +114,7 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.TransferID,
 		); err != nil {
 			return nil, err
 		}
@@ -95,3 +128,66 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 	}
 	return items, nil
 }
+
+const listAccountEntriesInPeriod = `-- name: ListAccountEntriesInPeriod :many
+SELECT id, account_id, amount, created_at, transfer_id
+FROM entries
+WHERE account_id = $1
+  AND created_at >= $2
+  AND created_at < $3
+ORDER BY created_at, id
+`
+
+type ListAccountEntriesInPeriodParams struct {
+	AccountID int64     `json:"account_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+func (q *Queries) ListAccountEntriesInPeriod(ctx context.Context, arg ListAccountEntriesInPeriodParams) ([]Entry, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountEntriesInPeriod, arg.AccountID, arg.StartTime, arg.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TransferID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const sumAccountEntriesBefore = `-- name: SumAccountEntriesBefore :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total
+FROM entries
+WHERE account_id = $1
+  AND created_at < $2
+`
+
+type SumAccountEntriesBeforeParams struct {
+	AccountID int64     `json:"account_id"`
+	Before    time.Time `json:"before"`
+}
+
+func (q *Queries) SumAccountEntriesBefore(ctx context.Context, arg SumAccountEntriesBeforeParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, sumAccountEntriesBefore, arg.AccountID, arg.Before)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
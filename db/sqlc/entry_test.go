@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 
 	"github.com/chensheep/simple-bank-backend/util"
@@ -46,21 +47,55 @@ func TestGetEntry(t *testing.T) {
 	require.Equal(t, entry1.CreatedAt, entry2.CreatedAt)
 }
 
-func TestListEntries(t *testing.T) {
+func TestListAccountEntries(t *testing.T) {
+	account := createRandomAccount(t)
 	for i := 0; i < 10; i++ {
-		createRandomEntry(t)
+		amount := int64(i + 1)
+		if i%2 == 1 {
+			amount = -amount
+		}
+		_, err := testQueries.CreateEntry(context.Background(), CreateEntryParams{
+			AccountID: account.ID,
+			Amount:    amount,
+		})
+		require.NoError(t, err)
 	}
 
-	arg := ListEntriesParams{
-		Limit:  5,
-		Offset: 5,
+	arg := ListAccountEntriesParams{
+		AccountID: account.ID,
+		PageSize:  3,
 	}
 
-	entries, err := testQueries.ListEntries(context.Background(), arg)
-	require.NoError(t, err)
-	require.Len(t, entries, 5)
+	var all []Entry
+	for {
+		entries, err := testQueries.ListAccountEntries(context.Background(), arg)
+		require.NoError(t, err)
+		all = append(all, entries...)
+		if len(entries) < int(arg.PageSize) {
+			break
+		}
+
+		last := entries[len(entries)-1]
+		arg.CursorCreatedAt = sql.NullTime{Time: last.CreatedAt, Valid: true}
+		arg.CursorID = sql.NullInt64{Int64: last.ID, Valid: true}
+	}
+	require.Len(t, all, 10)
+	for i := 1; i < len(all); i++ {
+		require.Equal(t, account.ID, all[i].AccountID)
+		require.False(t, all[i].CreatedAt.After(all[i-1].CreatedAt))
+		require.NotEqual(t, all[i-1].ID, all[i].ID)
+	}
 
+	entries, err := testQueries.ListAccountEntries(context.Background(), ListAccountEntriesParams{
+		AccountID: account.ID,
+		Direction: sql.NullString{String: "out", Valid: true},
+		MinAmount: sql.NullInt64{Int64: 4, Valid: true},
+		PageSize:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
 	for _, entry := range entries {
-		require.NotEmpty(t, entry)
+		require.Negative(t, entry.Amount)
+		require.GreaterOrEqual(t, -entry.Amount, int64(4))
 	}
 }
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+const balanceWithinOverdraftLimitConstraint = "balance_within_overdraft_limit"
+
+var (
+	ErrIdempotencyKeyConflict = errors.New("idempotency key has already been used with a different request")
+	ErrInsufficientFunds      = errors.New("insufficient funds")
+
+	ErrAccountNotActive               = errors.New("account is not active")
+	ErrAccountBalanceNotZero          = errors.New("account must have a zero balance and no holds to close it")
+	ErrInvalidAccountStatusTransition = errors.New("invalid account status transition")
+	ErrAccountFrozenByAdmin           = errors.New("account is frozen by an admin")
+
+	ErrHoldNotAuthorized  = errors.New("hold is not authorized")
+	ErrHoldExpired        = errors.New("hold has expired")
+	ErrHoldAmountExceeded = errors.New("amount exceeds the held amount")
+	ErrHoldStepUpRequired = errors.New("hold is a pending transfer waiting for the confirmation of the sender")
+
+	ErrTransferNotReversible  = errors.New("a reversal transfer can't be reversed")
+	ErrTransferFullyReversed  = errors.New("transfer has already been fully reversed")
+	ErrReversalAmountExceeded = errors.New("amount exceeds the remaining amount of the transfer")
+	ErrCrossCurrencyReversal  = errors.New("a cross-currency transfer can't be reversed")
+
+	ErrExchangeQuoteExpired = errors.New("exchange quote has expired")
+	ErrExchangeQuoteUsed    = errors.New("exchange quote has already been used")
+
+	ErrAccountNotFound  = errors.New("account not found")
+	ErrCurrencyMismatch = errors.New("accounts have different currencies")
+
+	ErrSessionBlocked     = errors.New("session is blocked")
+	ErrRefreshTokenReused = errors.New("refresh token has already been used")
+
+	ErrVerifyEmailOutdated = errors.New("the email address has changed since the verification was sent")
+)
+
+// translateError maps database constraint violations to the errors of this package
+func translateError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code.Name() == "check_violation" {
+		switch pqErr.Constraint {
+		case balanceWithinOverdraftLimitConstraint:
+			return ErrInsufficientFunds
+		}
+	}
+	return err
+}
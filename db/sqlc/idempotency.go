@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// requestHash fingerprints the transfer so a replayed key can be matched against the original request
+func (arg TransferTxParams) requestHash() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%d:%d", arg.FromAccountID, arg.ToAccountID, arg.Amount)))
+	return hex.EncodeToString(sum[:])
+}
+
+// replayTransfer loads the result stored for an idempotency key the user has used,
+// it fails with ErrIdempotencyKeyConflict if the key was used for another request
+func replayTransfer(ctx context.Context, q *Queries, username string, key string, requestHash string, result *TransferTxResult) error {
+	idempotencyKey, err := q.GetIdempotencyKey(ctx, GetIdempotencyKeyParams{
+		Username: username,
+		Key:      key,
+	})
+	if err != nil {
+		return err
+	}
+
+	if idempotencyKey.RequestHash != requestHash {
+		return ErrIdempotencyKeyConflict
+	}
+
+	return json.Unmarshal(idempotencyKey.Response, result)
+}
+
+// saveTransferResult stores the result of the transfer under the idempotency key of the user
+func saveTransferResult(ctx context.Context, q *Queries, username string, key string, result TransferTxResult) error {
+	response, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal transfer result: %w", err)
+	}
+
+	_, err = q.UpdateIdempotencyKeyResponse(ctx, UpdateIdempotencyKeyResponseParams{
+		Username: username,
+		Key:      key,
+		Response: response,
+	})
+	return err
+}
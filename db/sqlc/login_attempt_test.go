@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordLoginFailureTx(t *testing.T) {
+	store := NewSQLStore(testDB)
+	username := util.RandomOwner()
+	clientIp := util.RandomString(12)
+	lockedUntil := time.Now().Add(time.Minute)
+
+	recordFailure := func(unlockCode string, messages ...CreateOutboxMessageParams) RecordLoginFailureTxResult {
+		result, err := store.RecordLoginFailureTx(context.Background(), RecordLoginFailureTxParams{
+			Username:            username,
+			ClientIp:            clientIp,
+			WindowStart:         time.Now().Add(-time.Minute),
+			MaxUsernameFailures: 3,
+			MaxClientIpFailures: 5,
+			LockedUntil:         lockedUntil,
+			UnlockCode:          unlockCode,
+			LockOutboxMessages:  messages,
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	for i := 1; i < 3; i++ {
+		result := recordFailure(util.RandomString(32))
+		require.Equal(t, int32(i), result.UsernameAttempt.FailedCount)
+		require.Equal(t, int32(i), result.ClientIpAttempt.FailedCount)
+		require.False(t, result.UsernameLocked)
+		require.False(t, result.UsernameAttempt.LockedUntil.Valid)
+	}
+
+	// the third failure locks the username and sends the unlock email
+	unlockCode := util.RandomString(32)
+	message := randomOutboxMessage()
+	result := recordFailure(unlockCode, message)
+	require.True(t, result.UsernameLocked)
+	require.WithinDuration(t, lockedUntil, result.UsernameAttempt.LockedUntil.Time, time.Second)
+	require.Equal(t, unlockCode, result.UsernameAttempt.UnlockCode.String)
+	require.False(t, result.ClientIpAttempt.LockedUntil.Valid)
+	require.True(t, relayOutboxTaskTypes(t, store)[message.TaskType])
+
+	// a locked username keeps its lock and code
+	message2 := randomOutboxMessage()
+	result = recordFailure(util.RandomString(32), message2)
+	require.False(t, result.UsernameLocked)
+	require.Equal(t, unlockCode, result.UsernameAttempt.UnlockCode.String)
+	require.False(t, relayOutboxTaskTypes(t, store)[message2.TaskType])
+
+	// the client ip is locked on its own maximum, without an unlock code
+	result = recordFailure(util.RandomString(32))
+	require.Equal(t, int32(5), result.ClientIpAttempt.FailedCount)
+	require.True(t, result.ClientIpAttempt.LockedUntil.Valid)
+	require.False(t, result.ClientIpAttempt.UnlockCode.Valid)
+
+	_, err := store.UnlockLoginAttempt(context.Background(), UnlockLoginAttemptParams{
+		Scope:      LoginScopeUsername,
+		Identifier: username,
+		UnlockCode: sql.NullString{String: util.RandomString(32), Valid: true},
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = store.UnlockLoginAttempt(context.Background(), UnlockLoginAttemptParams{
+		Scope:      LoginScopeUsername,
+		Identifier: username,
+		UnlockCode: sql.NullString{String: unlockCode, Valid: true},
+	})
+	require.NoError(t, err)
+
+	_, err = store.GetLoginAttempt(context.Background(), GetLoginAttemptParams{
+		Scope:      LoginScopeUsername,
+		Identifier: username,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestRecordFailedLoginAttemptWindow(t *testing.T) {
+	arg := RecordFailedLoginAttemptParams{
+		Scope:       LoginScopeUsername,
+		Identifier:  util.RandomOwner(),
+		WindowStart: time.Now().Add(-time.Minute),
+	}
+
+	attempt, err := testQueries.RecordFailedLoginAttempt(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), attempt.FailedCount)
+
+	attempt, err = testQueries.RecordFailedLoginAttempt(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), attempt.FailedCount)
+
+	// failures before the window are forgotten
+	arg.WindowStart = time.Now().Add(time.Minute)
+	attempt, err = testQueries.RecordFailedLoginAttempt(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), attempt.FailedCount)
+}
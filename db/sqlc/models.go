@@ -5,6 +5,8 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,16 +18,191 @@ type Account struct {
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	// how far the balance may go below zero
+	OverdraftLimit int64 `json:"overdraft_limit"`
+	// active, frozen or closed
+	Status string `json:"status"`
+	// sum of the authorized holds
+	HeldAmount int64 `json:"held_amount"`
+	// balance minus the authorized holds
+	AvailableBalance int64 `json:"available_balance"`
+	// set while the account is frozen by an admin, its owner can then neither unfreeze nor close it
+	FrozenByAdmin bool `json:"frozen_by_admin"`
 }
 
 type Entry struct {
 	ID        int64 `json:"id"`
 	AccountID int64 `json:"account_id"`
 	// can be negative or positive
-	Amount    int64     `json:"amount"`
+	Amount     int64         `json:"amount"`
+	CreatedAt  time.Time     `json:"created_at"`
+	TransferID sql.NullInt64 `json:"transfer_id"`
+}
+
+type ExchangeQuote struct {
+	ID            int64  `json:"id"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	FromAmount    int64  `json:"from_amount"`
+	ToAmount      int64  `json:"to_amount"`
+	Rate          string `json:"rate"`
+	// set once the quote has been executed
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	ExpiredAt  time.Time     `json:"expired_at"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+type ExchangeRate struct {
+	FromCurrency string `json:"from_currency"`
+	ToCurrency   string `json:"to_currency"`
+	// amount of to_currency for one unit of from_currency
+	Rate      string    `json:"rate"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Hold struct {
+	ID             int64 `json:"id"`
+	AccountID      int64 `json:"account_id"`
+	ToAccountID    int64 `json:"to_account_id"`
+	Amount         int64 `json:"amount"`
+	CapturedAmount int64 `json:"captured_amount"`
+	// authorized, captured, voided or expired
+	Status     string        `json:"status"`
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	ExpiredAt  time.Time     `json:"expired_at"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+type IdempotencyKey struct {
+	Key         string          `json:"key"`
+	RequestHash string          `json:"request_hash"`
+	Response    json.RawMessage `json:"response"`
+	CreatedAt   time.Time       `json:"created_at"`
+	// the user who sent the key, different users can use the same key
+	Username string `json:"username"`
+}
+
+type LedgerAdjustment struct {
+	ID        int64 `json:"id"`
+	AccountID int64 `json:"account_id"`
+	EntryID   int64 `json:"entry_id"`
+	// credited when positive, debited when negative
+	Amount int64 `json:"amount"`
+	// why the balance was adjusted, kept for audit
+	Reason string `json:"reason"`
+	// the admin who made the adjustment
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LoginAttempt struct {
+	// username or client_ip
+	Scope string `json:"scope"`
+	// the username or the client ip the failures are counted for, the username may not exist
+	Identifier   string    `json:"identifier"`
+	FailedCount  int32     `json:"failed_count"`
+	LastFailedAt time.Time `json:"last_failed_at"`
+	// logins are rejected until then
+	LockedUntil sql.NullTime `json:"locked_until"`
+	// sent to the user to lift the lock early
+	UnlockCode sql.NullString `json:"unlock_code"`
+}
+
+type LoginChallenge struct {
+	// the challenge token returned by the login of a user with two-factor authentication
+	ID          uuid.UUID `json:"id"`
+	Username    string    `json:"username"`
+	FailedCount int32     `json:"failed_count"`
+	IsUsed      bool      `json:"is_used"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiredAt   time.Time `json:"expired_at"`
+}
+
+type Outbox struct {
+	ID int64 `json:"id"`
+	// the asynq task type the row is published as
+	TaskType  string          `json:"task_type"`
+	Payload   json.RawMessage `json:"payload"`
+	Queue     string          `json:"queue"`
+	MaxRetry  int32           `json:"max_retry"`
+	CreatedAt time.Time       `json:"created_at"`
+	// set once the task has been enqueued
+	PublishedAt sql.NullTime `json:"published_at"`
+}
+
+type PasswordReset struct {
+	ID         int64     `json:"id"`
+	Username   string    `json:"username"`
+	SecretCode string    `json:"secret_code"`
+	IsUsed     bool      `json:"is_used"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiredAt  time.Time `json:"expired_at"`
+}
+
+type PasswordResetRequest struct {
+	ID int64 `json:"id"`
+	// the email asked for, requests for unknown emails are counted too
+	Email     string    `json:"email"`
+	ClientIp  string    `json:"client_ip"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type ReconciliationDiscrepancy struct {
+	ID    int64 `json:"id"`
+	RunID int64 `json:"run_id"`
+	// account_balance or transfer_entries
+	Kind       string        `json:"kind"`
+	AccountID  sql.NullInt64 `json:"account_id"`
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	// sum of the entries, or the number of entries a transfer must have
+	Expected int64 `json:"expected"`
+	// balance of the account, or the number of matching entries found
+	Actual  int64  `json:"actual"`
+	Details string `json:"details"`
+}
+
+type ReconciliationRun struct {
+	ID int64 `json:"id"`
+	// balanced or mismatch
+	Status           string    `json:"status"`
+	DiscrepancyCount int32     `json:"discrepancy_count"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type RecoveryCode struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// sha256 of the code, shown once when generated
+	HashedCode string       `json:"hashed_code"`
+	UsedAt     sql.NullTime `json:"used_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+type ScheduledTransfer struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	// cron expression like 0 9 1 * *, or @every 24h
+	Schedule  string    `json:"schedule"`
+	NextRunAt time.Time `json:"next_run_at"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ScheduledTransferRun struct {
+	ID                  int64 `json:"id"`
+	ScheduledTransferID int64 `json:"scheduled_transfer_id"`
+	// the time the run was scheduled for
+	RunAt time.Time `json:"run_at"`
+	// succeeded or failed
+	Status     string        `json:"status"`
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	Error      string        `json:"error"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
 type Session struct {
 	ID           uuid.UUID `json:"id"`
 	Username     string    `json:"username"`
@@ -35,6 +212,10 @@ type Session struct {
 	IsBlocked    bool      `json:"is_blocked"`
 	ExpiredAt    time.Time `json:"expired_at"`
 	CreatedAt    time.Time `json:"created_at"`
+	// id of the session created at login
+	FamilyID uuid.UUID `json:"family_id"`
+	// set once the refresh token has been exchanged for a new one
+	RotatedAt sql.NullTime `json:"rotated_at"`
 }
 
 type Transfer struct {
@@ -44,6 +225,31 @@ type Transfer struct {
 	// must be positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// the original transfer when this one is a reversal
+	ReversedTransferID sql.NullInt64 `json:"reversed_transfer_id"`
+	// sum of the reversals of this transfer
+	ReversedAmount int64 `json:"reversed_amount"`
+	// amount credited in the currency of the to account
+	ToAmount int64 `json:"to_amount"`
+	// the rate used by a cross-currency transfer
+	ExchangeRate sql.NullString `json:"exchange_rate"`
+}
+
+type TransferChallenge struct {
+	ID uuid.UUID `json:"id"`
+	// the hold reserving the funds of the pending transfer
+	HoldID   int64  `json:"hold_id"`
+	Username string `json:"username"`
+	// totp or email
+	Method string `json:"method"`
+	// sha256 of the emailed code, empty for totp
+	HashedCode string `json:"hashed_code"`
+	// idempotency key of the transfer, used once it is confirmed
+	IdempotencyKey sql.NullString `json:"idempotency_key"`
+	FailedCount    int32          `json:"failed_count"`
+	IsUsed         bool           `json:"is_used"`
+	CreatedAt      time.Time      `json:"created_at"`
+	ExpiredAt      time.Time      `json:"expired_at"`
 }
 
 type User struct {
@@ -54,6 +260,22 @@ type User struct {
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
 	IsEmailVerified   bool      `json:"is_email_verified"`
+	// depositor, banker or admin
+	Role string `json:"role"`
+	// frozen users cannot log in or renew their tokens
+	IsFrozen bool `json:"is_frozen"`
+	// new email waiting to be verified before it replaces email
+	PendingEmail sql.NullString `json:"pending_email"`
+}
+
+type UserTotp struct {
+	Username string `json:"username"`
+	Secret   string `json:"secret"`
+	// two-factor authentication is enabled once the first code has been confirmed
+	ConfirmedAt sql.NullTime `json:"confirmed_at"`
+	// time step of the last accepted code, a code is never accepted twice
+	LastUsedStep int64     `json:"last_used_step"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 type VerifyEmail struct {
@@ -65,3 +287,9 @@ type VerifyEmail struct {
 	CreatedAt  time.Time `json:"created_at"`
 	ExpiredAt  time.Time `json:"expired_at"`
 }
+
+type VerifyEmailRequest struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
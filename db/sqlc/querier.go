@@ -12,25 +12,107 @@ import (
 
 type Querier interface {
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	AddAccountHeldAmount(ctx context.Context, arg AddAccountHeldAmountParams) (Account, error)
+	AddTransferReversedAmount(ctx context.Context, arg AddTransferReversedAmountParams) (Transfer, error)
+	ApplyUserPendingEmail(ctx context.Context, arg ApplyUserPendingEmailParams) (User, error)
+	BlockSession(ctx context.Context, arg BlockSessionParams) (Session, error)
+	BlockSessionFamily(ctx context.Context, familyID uuid.UUID) (int64, error)
+	BlockUserSessions(ctx context.Context, username string) (int64, error)
+	ConfirmUserTotp(ctx context.Context, arg ConfirmUserTotpParams) (UserTotp, error)
+	CountPasswordResetRequestsByClientIp(ctx context.Context, arg CountPasswordResetRequestsByClientIpParams) (int64, error)
+	CountPasswordResetRequestsByEmail(ctx context.Context, arg CountPasswordResetRequestsByEmailParams) (int64, error)
+	CountVerifyEmailRequests(ctx context.Context, arg CountVerifyEmailRequestsParams) (int64, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateExchangeQuote(ctx context.Context, arg CreateExchangeQuoteParams) (ExchangeQuote, error)
+	CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error)
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error)
+	CreateLedgerAdjustment(ctx context.Context, arg CreateLedgerAdjustmentParams) (LedgerAdjustment, error)
+	CreateLoginChallenge(ctx context.Context, arg CreateLoginChallengeParams) (LoginChallenge, error)
+	CreateOutboxMessage(ctx context.Context, arg CreateOutboxMessageParams) (Outbox, error)
+	CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (PasswordReset, error)
+	CreatePasswordResetRequest(ctx context.Context, arg CreatePasswordResetRequestParams) (PasswordResetRequest, error)
+	CreateReconciliationDiscrepancy(ctx context.Context, arg CreateReconciliationDiscrepancyParams) (ReconciliationDiscrepancy, error)
+	CreateReconciliationRun(ctx context.Context, arg CreateReconciliationRunParams) (ReconciliationRun, error)
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) (RecoveryCode, error)
+	CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfer, error)
+	CreateScheduledTransferRun(ctx context.Context, arg CreateScheduledTransferRunParams) (ScheduledTransferRun, error)
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
+	CreateTransferChallenge(ctx context.Context, arg CreateTransferChallengeParams) (TransferChallenge, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error)
-	DeleteAccount(ctx context.Context, id int64) error
+	CreateVerifyEmailRequest(ctx context.Context, username string) (VerifyEmailRequest, error)
+	DeleteLoginAttempt(ctx context.Context, arg DeleteLoginAttemptParams) error
+	DeleteRecoveryCodes(ctx context.Context, username string) error
+	DeleteScheduledTransfer(ctx context.Context, id int64) error
+	DeleteUserTotp(ctx context.Context, username string) error
 	GetAccount(ctx context.Context, id int64) (Account, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetExchangeQuote(ctx context.Context, id int64) (ExchangeQuote, error)
+	GetExchangeQuoteForUpdate(ctx context.Context, id int64) (ExchangeQuote, error)
+	GetExchangeRate(ctx context.Context, arg GetExchangeRateParams) (ExchangeRate, error)
+	GetHold(ctx context.Context, id int64) (Hold, error)
+	GetHoldForUpdate(ctx context.Context, id int64) (Hold, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	GetLoginAttempt(ctx context.Context, arg GetLoginAttemptParams) (LoginAttempt, error)
+	GetLoginChallenge(ctx context.Context, id uuid.UUID) (LoginChallenge, error)
+	GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error)
+	GetScheduledTransferRun(ctx context.Context, arg GetScheduledTransferRunParams) (ScheduledTransferRun, error)
 	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
+	GetSessionForUpdate(ctx context.Context, id uuid.UUID) (Session, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTransferChallenge(ctx context.Context, id uuid.UUID) (TransferChallenge, error)
+	GetTransferChallengeByHold(ctx context.Context, holdID int64) (TransferChallenge, error)
+	GetTransferChallengeByIdempotencyKey(ctx context.Context, arg GetTransferChallengeByIdempotencyKeyParams) (TransferChallenge, error)
+	GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetUserTotp(ctx context.Context, username string) (UserTotp, error)
+	ListAccountBalanceMismatches(ctx context.Context) ([]ListAccountBalanceMismatchesRow, error)
+	ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]Entry, error)
+	ListAccountEntriesInPeriod(ctx context.Context, arg ListAccountEntriesInPeriodParams) ([]Entry, error)
+	ListAccountTransfers(ctx context.Context, arg ListAccountTransfersParams) ([]Transfer, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
-	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
-	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	ListActiveAccounts(ctx context.Context, arg ListActiveAccountsParams) ([]Account, error)
+	ListDueScheduledTransfers(ctx context.Context, limit int32) ([]ScheduledTransfer, error)
+	ListExchangeRates(ctx context.Context) ([]ExchangeRate, error)
+	ListExpiredHolds(ctx context.Context, limit int32) ([]int64, error)
+	ListLedgerAdjustments(ctx context.Context, arg ListLedgerAdjustmentsParams) ([]LedgerAdjustment, error)
+	ListReconciliationDiscrepancies(ctx context.Context, runID int64) ([]ReconciliationDiscrepancy, error)
+	ListReconciliationRuns(ctx context.Context, arg ListReconciliationRunsParams) ([]ReconciliationRun, error)
+	ListScheduledTransferRuns(ctx context.Context, arg ListScheduledTransferRunsParams) ([]ScheduledTransferRun, error)
+	ListScheduledTransfers(ctx context.Context, arg ListScheduledTransfersParams) ([]ScheduledTransfer, error)
+	ListSessions(ctx context.Context, username string) ([]Session, error)
+	ListTransferEntryMismatches(ctx context.Context) ([]ListTransferEntryMismatchesRow, error)
+	ListUnpublishedOutboxMessages(ctx context.Context, limit int32) ([]Outbox, error)
+	LockLoginAttempt(ctx context.Context, arg LockLoginAttemptParams) (LoginAttempt, error)
+	MarkOutboxMessagePublished(ctx context.Context, id int64) (Outbox, error)
+	RecordFailedLoginAttempt(ctx context.Context, arg RecordFailedLoginAttemptParams) (LoginAttempt, error)
+	RecordLoginChallengeFailure(ctx context.Context, arg RecordLoginChallengeFailureParams) (LoginChallenge, error)
+	RecordTransferChallengeFailure(ctx context.Context, arg RecordTransferChallengeFailureParams) (TransferChallenge, error)
+	RotateSession(ctx context.Context, id uuid.UUID) (Session, error)
+	SearchUsers(ctx context.Context, arg SearchUsersParams) ([]User, error)
+	SetUserPendingEmail(ctx context.Context, arg SetUserPendingEmailParams) (User, error)
+	SumAccountEntriesBefore(ctx context.Context, arg SumAccountEntriesBeforeParams) (int64, error)
+	UnlockLoginAttempt(ctx context.Context, arg UnlockLoginAttemptParams) (LoginAttempt, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error)
+	UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error)
+	UpdateExchangeQuoteTransfer(ctx context.Context, arg UpdateExchangeQuoteTransferParams) (ExchangeQuote, error)
+	UpdateHold(ctx context.Context, arg UpdateHoldParams) (Hold, error)
+	UpdateIdempotencyKeyResponse(ctx context.Context, arg UpdateIdempotencyKeyResponseParams) (IdempotencyKey, error)
+	UpdatePasswordReset(ctx context.Context, arg UpdatePasswordResetParams) (PasswordReset, error)
+	UpdateScheduledTransfer(ctx context.Context, arg UpdateScheduledTransferParams) (ScheduledTransfer, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
 	UpdateVerifyEmail(ctx context.Context, arg UpdateVerifyEmailParams) (VerifyEmail, error)
+	UpsertExchangeRate(ctx context.Context, arg UpsertExchangeRateParams) (ExchangeRate, error)
+	UpsertUserTotp(ctx context.Context, arg UpsertUserTotpParams) (UserTotp, error)
+	UseLoginChallenge(ctx context.Context, id uuid.UUID) (LoginChallenge, error)
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (RecoveryCode, error)
+	UseTransferChallenge(ctx context.Context, id uuid.UUID) (TransferChallenge, error)
+	UseUserTotpStep(ctx context.Context, arg UseUserTotpStepParams) (UserTotp, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomScheduledTransfer(t *testing.T, nextRunAt time.Time) ScheduledTransfer {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	arg := CreateScheduledTransferParams{
+		Owner:         account1.Owner,
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		Schedule:      "@every 24h",
+		NextRunAt:     nextRunAt,
+	}
+
+	scheduledTransfer, err := testQueries.CreateScheduledTransfer(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, scheduledTransfer.ID)
+	require.Equal(t, arg.Owner, scheduledTransfer.Owner)
+	require.Equal(t, arg.FromAccountID, scheduledTransfer.FromAccountID)
+	require.Equal(t, arg.ToAccountID, scheduledTransfer.ToAccountID)
+	require.Equal(t, arg.Amount, scheduledTransfer.Amount)
+	require.Equal(t, arg.Schedule, scheduledTransfer.Schedule)
+	require.WithinDuration(t, arg.NextRunAt, scheduledTransfer.NextRunAt, time.Second)
+	require.True(t, scheduledTransfer.IsActive)
+
+	return scheduledTransfer
+}
+
+func TestListDueScheduledTransfers(t *testing.T) {
+	due := createRandomScheduledTransfer(t, time.Now().Add(-time.Minute))
+	notDue := createRandomScheduledTransfer(t, time.Now().Add(time.Hour))
+	paused := createRandomScheduledTransfer(t, time.Now().Add(-time.Minute))
+
+	_, err := testQueries.UpdateScheduledTransfer(context.Background(), UpdateScheduledTransferParams{
+		ID:       paused.ID,
+		IsActive: sql.NullBool{Bool: false, Valid: true},
+	})
+	require.NoError(t, err)
+
+	scheduledTransfers, err := testQueries.ListDueScheduledTransfers(context.Background(), 1000)
+	require.NoError(t, err)
+
+	ids := make(map[int64]bool)
+	for _, scheduledTransfer := range scheduledTransfers {
+		ids[scheduledTransfer.ID] = true
+	}
+	require.True(t, ids[due.ID])
+	require.False(t, ids[notDue.ID])
+	require.False(t, ids[paused.ID])
+}
+
+func TestUpdateScheduledTransfer(t *testing.T) {
+	scheduledTransfer := createRandomScheduledTransfer(t, time.Now())
+
+	updated, err := testQueries.UpdateScheduledTransfer(context.Background(), UpdateScheduledTransferParams{
+		ID:     scheduledTransfer.ID,
+		Amount: sql.NullInt64{Int64: 20, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(20), updated.Amount)
+	require.Equal(t, scheduledTransfer.Schedule, updated.Schedule)
+	require.Equal(t, scheduledTransfer.NextRunAt, updated.NextRunAt)
+	require.Equal(t, scheduledTransfer.IsActive, updated.IsActive)
+}
+
+func TestCreateScheduledTransferRun(t *testing.T) {
+	scheduledTransfer := createRandomScheduledTransfer(t, time.Now())
+	runAt := time.Now().Truncate(time.Second)
+
+	run, err := testQueries.CreateScheduledTransferRun(context.Background(), CreateScheduledTransferRunParams{
+		ScheduledTransferID: scheduledTransfer.ID,
+		RunAt:               runAt,
+		Status:              "failed",
+		Error:               ErrInsufficientFunds.Error(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "failed", run.Status)
+
+	// a retried run overwrites the result recorded for the same time
+	transfer := createRandomTransfer(t)
+	run2, err := testQueries.CreateScheduledTransferRun(context.Background(), CreateScheduledTransferRunParams{
+		ScheduledTransferID: scheduledTransfer.ID,
+		RunAt:               runAt,
+		Status:              "succeeded",
+		TransferID:          sql.NullInt64{Int64: transfer.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, run.ID, run2.ID)
+	require.Equal(t, "succeeded", run2.Status)
+	require.Empty(t, run2.Error)
+
+	runs, err := testQueries.ListScheduledTransferRuns(context.Background(), ListScheduledTransferRunsParams{
+		ScheduledTransferID: scheduledTransfer.ID,
+		Limit:               5,
+		Offset:              0,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+
+	// deleting the scheduled transfer removes its runs
+	err = testQueries.DeleteScheduledTransfer(context.Background(), scheduledTransfer.ID)
+	require.NoError(t, err)
+
+	_, err = testQueries.GetScheduledTransfer(context.Background(), scheduledTransfer.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,142 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomSession(t *testing.T, username string, expiredAt time.Time) Session {
+	id := uuid.New()
+	arg := CreateSessionParams{
+		ID:           id,
+		Username:     username,
+		RefreshToken: util.RandomString(32),
+		UserAgent:    util.RandomString(10),
+		ClientIp:     "127.0.0.1",
+		ExpiredAt:    expiredAt,
+		FamilyID:     id,
+	}
+
+	session, err := testQueries.CreateSession(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, session.ID)
+	require.False(t, session.IsBlocked)
+
+	return session
+}
+
+func TestListSessions(t *testing.T) {
+	user := createRandomUser(t)
+	active := createRandomSession(t, user.Username, time.Now().Add(time.Hour))
+	createRandomSession(t, user.Username, time.Now().Add(-time.Minute))
+	blocked := createRandomSession(t, user.Username, time.Now().Add(time.Hour))
+
+	_, err := testQueries.BlockSession(context.Background(), BlockSessionParams{
+		ID:       blocked.ID,
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+
+	sessions, err := testQueries.ListSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, active.ID, sessions[0].ID)
+}
+
+func TestBlockSession(t *testing.T) {
+	user1 := createRandomUser(t)
+	user2 := createRandomUser(t)
+	session := createRandomSession(t, user1.Username, time.Now().Add(time.Hour))
+
+	// only the owner can block a session
+	_, err := testQueries.BlockSession(context.Background(), BlockSessionParams{
+		ID:       session.ID,
+		Username: user2.Username,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	blocked, err := testQueries.BlockSession(context.Background(), BlockSessionParams{
+		ID:       session.ID,
+		Username: user1.Username,
+	})
+	require.NoError(t, err)
+	require.True(t, blocked.IsBlocked)
+}
+
+func TestBlockUserSessions(t *testing.T) {
+	user := createRandomUser(t)
+	n := 3
+	for i := 0; i < n; i++ {
+		createRandomSession(t, user.Username, time.Now().Add(time.Hour))
+	}
+
+	count, err := testQueries.BlockUserSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int64(n), count)
+
+	sessions, err := testQueries.ListSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+
+	count, err = testQueries.BlockUserSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
+
+func TestRotateSessionTx(t *testing.T) {
+	store := NewSQLStore(testDB)
+	user := createRandomUser(t)
+	session := createRandomSession(t, user.Username, time.Now().Add(time.Hour))
+
+	newSession := func() CreateSessionParams {
+		return CreateSessionParams{
+			ID:           uuid.New(),
+			Username:     user.Username,
+			RefreshToken: util.RandomString(32),
+			UserAgent:    util.RandomString(10),
+			ClientIp:     "127.0.0.1",
+			ExpiredAt:    time.Now().Add(time.Hour),
+		}
+	}
+
+	result, err := store.RotateSessionTx(context.Background(), RotateSessionTxParams{
+		SessionID:  session.ID,
+		NewSession: newSession(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, session.FamilyID, result.Session.FamilyID)
+	require.False(t, result.Session.RotatedAt.Valid)
+
+	rotated, err := store.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, rotated.RotatedAt.Valid)
+	require.False(t, rotated.IsBlocked)
+
+	// presenting the rotated refresh token again blocks the whole family
+	message := randomOutboxMessage()
+	result2, err := store.RotateSessionTx(context.Background(), RotateSessionTxParams{
+		SessionID:           session.ID,
+		NewSession:          newSession(),
+		ReuseOutboxMessages: []CreateOutboxMessageParams{message},
+	})
+	require.ErrorIs(t, err, ErrRefreshTokenReused)
+	require.Equal(t, int64(2), result2.BlockedCount)
+
+	current, err := store.GetSession(context.Background(), result.Session.ID)
+	require.NoError(t, err)
+	require.True(t, current.IsBlocked)
+
+	require.True(t, relayOutboxTaskTypes(t, store)[message.TaskType])
+
+	_, err = store.RotateSessionTx(context.Background(), RotateSessionTxParams{
+		SessionID:  current.ID,
+		NewSession: newSession(),
+	})
+	require.ErrorIs(t, err, ErrSessionBlocked)
+}
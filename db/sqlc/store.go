@@ -3,14 +3,44 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"expvar"
 	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
 )
 
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	BatchTransferTx(ctx context.Context, arg BatchTransferTxParams) (BatchTransferTxResult, error)
 	CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error)
 	VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error)
+	UpdateAccountStatusTx(ctx context.Context, arg UpdateAccountStatusTxParams) (UpdateAccountStatusTxResult, error)
+	AuthorizeHoldTx(ctx context.Context, arg AuthorizeHoldTxParams) (AuthorizeHoldTxResult, error)
+	CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (CaptureHoldTxResult, error)
+	VoidHoldTx(ctx context.Context, arg VoidHoldTxParams) (VoidHoldTxResult, error)
+	ExpireHoldTx(ctx context.Context, holdID int64) (Hold, error)
+	ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (ReverseTransferTxResult, error)
+	ExchangeTransferTx(ctx context.Context, arg ExchangeTransferTxParams) (ExchangeTransferTxResult, error)
+	UpsertExchangeRatesTx(ctx context.Context, arg UpsertExchangeRatesTxParams) (UpsertExchangeRatesTxResult, error)
+	ReconcileTx(ctx context.Context) (ReconcileTxResult, error)
+	RelayOutboxTx(ctx context.Context, arg RelayOutboxTxParams) (RelayOutboxTxResult, error)
+	RotateSessionTx(ctx context.Context, arg RotateSessionTxParams) (RotateSessionTxResult, error)
+	RecordLoginFailureTx(ctx context.Context, arg RecordLoginFailureTxParams) (RecordLoginFailureTxResult, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error)
+	UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error)
+	ConfirmTotpTx(ctx context.Context, arg ConfirmTotpTxParams) (ConfirmTotpTxResult, error)
+	DisableTotpTx(ctx context.Context, arg DisableTotpTxParams) error
+	CompleteLoginChallengeTx(ctx context.Context, arg CompleteLoginChallengeTxParams) (CompleteLoginChallengeTxResult, error)
+	CreatePendingTransferTx(ctx context.Context, arg CreatePendingTransferTxParams) (CreatePendingTransferTxResult, error)
+	ConfirmTransferTx(ctx context.Context, arg ConfirmTransferTxParams) (ConfirmTransferTxResult, error)
+	FreezeUserTx(ctx context.Context, arg FreezeUserTxParams) (FreezeUserTxResult, error)
+	AdjustBalanceTx(ctx context.Context, arg AdjustBalanceTxParams) (AdjustBalanceTxResult, error)
+	CreateScheduledTransferRunTx(ctx context.Context, arg CreateScheduledTransferRunTxParams) (CreateScheduledTransferRunTxResult, error)
 }
 
 type SQLStore struct {
@@ -27,14 +57,68 @@ func NewSQLStore(db *sql.DB) Store {
 }
 
 func (s *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
+	return s.runTx(ctx, nil, fn)
+}
+
+// txOptions configures a transaction run by execTxWithRetry
+type txOptions struct {
+	// name identifies the transaction in logs and metrics
+	name      string
+	isolation sql.IsolationLevel
+	// maxRetries is how many more times the transaction is run after
+	// a serialization failure or a deadlock
+	maxRetries int
+}
+
+const (
+	txRetryBaseDelay = 10 * time.Millisecond
+	txRetryMaxDelay  = 500 * time.Millisecond
+)
+
+// retried transactions and the ones still failing after their last retry,
+// counted by transaction name and published on /debug/vars
+var (
+	txRetries          = expvar.NewMap("db_tx_retries")
+	txRetriesExhausted = expvar.NewMap("db_tx_retries_exhausted")
+)
+
+// execTxWithRetry runs fn in a transaction with the isolation level of the options,
+// and runs it again with a jittered backoff when Postgres aborts it with a serialization
+// failure or a deadlock. fn must not have side effects outside the transaction
+func (s *SQLStore) execTxWithRetry(ctx context.Context, opts txOptions, fn func(*Queries) error) error {
+	for attempt := 0; ; attempt++ {
+		err := s.runTx(ctx, &sql.TxOptions{Isolation: opts.isolation}, fn)
+		if err == nil || !isRetryableError(err) {
+			return err
+		}
+
+		if attempt >= opts.maxRetries {
+			txRetriesExhausted.Add(opts.name, 1)
+			log.Error().Err(err).Str("tx", opts.name).Int("retries", attempt).Msg("transaction failed after retries")
+			return err
+		}
+
+		txRetries.Add(opts.name, 1)
+		delay := txRetryDelay(attempt)
+		log.Warn().Err(err).Str("tx", opts.name).Int("retry", attempt+1).Dur("delay", delay).Msg("retrying transaction")
 
-	tx, err := s.db.BeginTx(ctx, nil)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (s *SQLStore) runTx(ctx context.Context, opts *sql.TxOptions, fn func(*Queries) error) error {
+
+	tx, err := s.db.BeginTx(ctx, opts)
 	if err != nil {
 		return err
 	}
 
 	queries := New(tx)
-	err = fn(queries)
+	err = translateError(fn(queries))
 	if err != nil {
 		if rbErr := tx.Rollback(); rbErr != nil {
 			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
@@ -44,3 +128,30 @@ func (s *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
 
 	return tx.Commit()
 }
+
+// isRetryableError reports whether Postgres aborted the transaction because of
+// a conflict with a concurrent one, so running it again may succeed
+func isRetryableError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	switch pqErr.Code.Name() {
+	case "serialization_failure", "deadlock_detected":
+		return true
+	}
+	return false
+}
+
+// txRetryDelay doubles the delay on every retry up to txRetryMaxDelay,
+// and picks a random delay below it so conflicting transactions don't retry in lockstep
+func txRetryDelay(attempt int) time.Duration {
+	delay := txRetryMaxDelay
+	if attempt < 10 {
+		delay = txRetryBaseDelay << attempt
+		if delay > txRetryMaxDelay {
+			delay = txRetryMaxDelay
+		}
+	}
+	return time.Duration(rand.Int63n(int64(delay))) + 1
+}
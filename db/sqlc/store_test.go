@@ -2,17 +2,23 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 )
 
 func TestTransferTx(t *testing.T) {
 	store := NewSQLStore(testDB)
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 1000)
 
 	n := 10
 	errChan := make(chan error)
@@ -109,8 +115,8 @@ func TestTransferTx(t *testing.T) {
 func TestTransferTxDeadlock(t *testing.T) {
 	store := NewSQLStore(testDB)
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 1000)
 
 	n := 10
 	errChan := make(chan error)
@@ -159,3 +165,892 @@ func TestTransferTxDeadlock(t *testing.T) {
 	wg.Wait()
 
 }
+
+func TestTransferTxIdempotencyKey(t *testing.T) {
+	store := NewSQLStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 1000)
+
+	arg := TransferTxParams{
+		FromAccountID:  account1.ID,
+		ToAccountID:    account2.ID,
+		Amount:         10,
+		IdempotencyKey: util.RandomString(32),
+		Username:       account1.Owner,
+	}
+
+	result1, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	// replaying the same request returns the original transfer
+	result2, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, result1.Transfer.ID, result2.Transfer.ID)
+	require.Equal(t, result1.FromEntry.ID, result2.FromEntry.ID)
+	require.Equal(t, result1.ToEntry.ID, result2.ToEntry.ID)
+	require.Equal(t, result1.FromAccount.Balance, result2.FromAccount.Balance)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-arg.Amount, updatedAccount1.Balance)
+
+	// reusing the key for a different request is rejected
+	arg.Amount = 20
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrIdempotencyKeyConflict)
+
+	// another user can use the same key for their own transfer
+	result3, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID:  account2.ID,
+		ToAccountID:    account1.ID,
+		Amount:         10,
+		IdempotencyKey: arg.IdempotencyKey,
+		Username:       account2.Owner,
+	})
+	require.NoError(t, err)
+	require.NotEqual(t, result1.Transfer.ID, result3.Transfer.ID)
+}
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	store := NewSQLStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 100)
+	account2 := createRandomAccountWithBalance(t, 100)
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        account1.Balance + 1,
+	}
+
+	_, err := store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// the overdraft limit allows the balance to go below zero
+	account1, err = store.UpdateAccountOverdraftLimit(context.Background(), UpdateAccountOverdraftLimitParams{
+		ID:             account1.ID,
+		OverdraftLimit: 1,
+	})
+	require.NoError(t, err)
+
+	result, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(-1), result.FromAccount.Balance)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// the check constraint guards the balance outside of TransferTx too
+	_, err = store.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account1.ID,
+		Amount: -1,
+	})
+	require.Error(t, err)
+}
+
+func TestTransferTxAccountNotActive(t *testing.T) {
+	store := NewSQLStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 100)
+	account2 := createRandomAccountWithBalance(t, 100)
+
+	_, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account2.ID,
+		Status:    AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	}
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrAccountNotActive)
+
+	arg.FromAccountID, arg.ToAccountID = account2.ID, account1.ID
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrAccountNotActive)
+}
+
+func TestUpdateAccountStatusTx(t *testing.T) {
+	store := NewSQLStore(testDB)
+
+	account := createRandomAccountWithBalance(t, 100)
+
+	_, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    AccountStatusClosed,
+	})
+	require.ErrorIs(t, err, ErrAccountBalanceNotZero)
+
+	_, err = store.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:      account.ID,
+		Balance: 0,
+	})
+	require.NoError(t, err)
+
+	result, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    AccountStatusClosed,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusClosed, result.Account.Status)
+
+	// a closed account is kept and can't be reopened
+	_, err = store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+
+	_, err = store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    AccountStatusActive,
+	})
+	require.ErrorIs(t, err, ErrInvalidAccountStatusTransition)
+}
+
+func TestUpdateAccountStatusTxByAdmin(t *testing.T) {
+	store := NewSQLStore(testDB)
+	account := createRandomAccount(t)
+
+	updateStatus := func(status string, byAdmin bool) (Account, error) {
+		result, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+			AccountID: account.ID,
+			Status:    status,
+			ByAdmin:   byAdmin,
+		})
+		return result.Account, err
+	}
+
+	// an admin takes over the freeze of the owner
+	_, err := updateStatus(AccountStatusFrozen, false)
+	require.NoError(t, err)
+	frozen, err := updateStatus(AccountStatusFrozen, true)
+	require.NoError(t, err)
+	require.True(t, frozen.FrozenByAdmin)
+
+	// the owner can't lift the freeze of an admin
+	_, err = updateStatus(AccountStatusActive, false)
+	require.ErrorIs(t, err, ErrAccountFrozenByAdmin)
+	_, err = updateStatus(AccountStatusClosed, false)
+	require.ErrorIs(t, err, ErrAccountFrozenByAdmin)
+
+	active, err := updateStatus(AccountStatusActive, true)
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusActive, active.Status)
+	require.False(t, active.FrozenByAdmin)
+}
+
+func TestCaptureHoldTx(t *testing.T) {
+	store := NewSQLStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 100)
+	account2 := createRandomAccount(t)
+
+	authorized, err := store.AuthorizeHoldTx(context.Background(), AuthorizeHoldTxParams{
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      60,
+		ExpiredAt:   time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusAuthorized, authorized.Hold.Status)
+	require.Equal(t, int64(60), authorized.Account.HeldAmount)
+	require.Equal(t, int64(40), authorized.Account.AvailableBalance)
+	require.Equal(t, int64(100), authorized.Account.Balance)
+
+	// the held funds can't be spent by a transfer
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        50,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{
+		HoldID: authorized.Hold.ID,
+		Amount: 61,
+	})
+	require.ErrorIs(t, err, ErrHoldAmountExceeded)
+
+	// a partial capture releases the rest of the hold
+	captured, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{
+		HoldID: authorized.Hold.ID,
+		Amount: 50,
+	})
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusCaptured, captured.Hold.Status)
+	require.Equal(t, int64(50), captured.Hold.CapturedAmount)
+	require.True(t, captured.Hold.TransferID.Valid)
+	require.Equal(t, captured.Transfer.Transfer.ID, captured.Hold.TransferID.Int64)
+	require.Equal(t, int64(50), captured.Transfer.FromAccount.Balance)
+	require.Equal(t, int64(0), captured.Transfer.FromAccount.HeldAmount)
+	require.Equal(t, account2.Balance+50, captured.Transfer.ToAccount.Balance)
+
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{
+		HoldID: authorized.Hold.ID,
+	})
+	require.ErrorIs(t, err, ErrHoldNotAuthorized)
+}
+
+func TestVoidHoldTx(t *testing.T) {
+	store := NewSQLStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 100)
+	account2 := createRandomAccount(t)
+
+	_, err := store.AuthorizeHoldTx(context.Background(), AuthorizeHoldTxParams{
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      101,
+		ExpiredAt:   time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	authorized, err := store.AuthorizeHoldTx(context.Background(), AuthorizeHoldTxParams{
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      100,
+		ExpiredAt:   time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	// the balance can't drop below the held amount
+	_, err = store.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:      account1.ID,
+		Balance: 0,
+	})
+	require.Error(t, err)
+
+	voided, err := store.VoidHoldTx(context.Background(), VoidHoldTxParams{
+		HoldID: authorized.Hold.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusVoided, voided.Hold.Status)
+	require.Equal(t, int64(0), voided.Account.HeldAmount)
+	require.Equal(t, int64(100), voided.Account.AvailableBalance)
+
+	_, err = store.VoidHoldTx(context.Background(), VoidHoldTxParams{
+		HoldID: authorized.Hold.ID,
+	})
+	require.ErrorIs(t, err, ErrHoldNotAuthorized)
+}
+
+func TestExpireHoldTx(t *testing.T) {
+	store := NewSQLStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 100)
+	account2 := createRandomAccount(t)
+
+	authorized, err := store.AuthorizeHoldTx(context.Background(), AuthorizeHoldTxParams{
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      10,
+		ExpiredAt:   time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{
+		HoldID: authorized.Hold.ID,
+	})
+	require.ErrorIs(t, err, ErrHoldExpired)
+
+	hold, err := store.ExpireHoldTx(context.Background(), authorized.Hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusExpired, hold.Status)
+
+	account, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), account.HeldAmount)
+}
+
+func TestReverseTransferTx(t *testing.T) {
+	store := NewSQLStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 100)
+	account2 := createRandomAccountWithBalance(t, 0)
+
+	transferred, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        30,
+	})
+	require.NoError(t, err)
+	require.Equal(t, transferred.Transfer.ID, transferred.FromEntry.TransferID.Int64)
+	require.Equal(t, transferred.Transfer.ID, transferred.ToEntry.TransferID.Int64)
+
+	// a partial refund
+	result, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transferred.Transfer.ID,
+		Amount:     10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(10), result.Transfer.ReversedAmount)
+
+	reversal := result.Reversal
+	require.Equal(t, account2.ID, reversal.Transfer.FromAccountID)
+	require.Equal(t, account1.ID, reversal.Transfer.ToAccountID)
+	require.Equal(t, int64(10), reversal.Transfer.Amount)
+	require.Equal(t, transferred.Transfer.ID, reversal.Transfer.ReversedTransferID.Int64)
+	require.Equal(t, reversal.Transfer.ID, reversal.FromEntry.TransferID.Int64)
+	require.Equal(t, int64(-10), reversal.FromEntry.Amount)
+	require.Equal(t, int64(80), reversal.ToAccount.Balance)
+	require.Equal(t, int64(20), reversal.FromAccount.Balance)
+
+	// a reversal can't be reversed itself
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: reversal.Transfer.ID,
+	})
+	require.ErrorIs(t, err, ErrTransferNotReversible)
+
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transferred.Transfer.ID,
+		Amount:     21,
+	})
+	require.ErrorIs(t, err, ErrReversalAmountExceeded)
+
+	// zero refunds the rest of the transfer
+	result, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transferred.Transfer.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(20), result.Reversal.Transfer.Amount)
+	require.Equal(t, int64(30), result.Transfer.ReversedAmount)
+	require.Equal(t, int64(100), result.Reversal.ToAccount.Balance)
+	require.Equal(t, int64(0), result.Reversal.FromAccount.Balance)
+
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transferred.Transfer.ID,
+	})
+	require.ErrorIs(t, err, ErrTransferFullyReversed)
+}
+
+func TestReverseTransferTxConcurrent(t *testing.T) {
+	store := NewSQLStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 100)
+	account2 := createRandomAccountWithBalance(t, 0)
+
+	transferred, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        50,
+	})
+	require.NoError(t, err)
+
+	// only one of the concurrent full reversals can succeed
+	n := 5
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+				TransferID: transferred.Transfer.ID,
+			})
+			errs <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, ErrTransferFullyReversed)
+	}
+	require.Equal(t, 1, succeeded)
+
+	account1, err = store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), account1.Balance)
+}
+
+func TestExchangeTransferTx(t *testing.T) {
+	store := NewSQLStore(testDB)
+
+	_, err := store.UpsertExchangeRatesTx(context.Background(), UpsertExchangeRatesTxParams{
+		Rates: []UpsertExchangeRateParams{
+			{FromCurrency: util.USD, ToCurrency: util.TWD, Rate: "30.5"},
+		},
+	})
+	require.NoError(t, err)
+
+	rate, err := store.GetExchangeRate(context.Background(), GetExchangeRateParams{
+		FromCurrency: util.USD,
+		ToCurrency:   util.TWD,
+	})
+	require.NoError(t, err)
+
+	account1 := createRandomAccountWithBalance(t, 100)
+	account2 := createRandomAccountWithBalance(t, 0)
+
+	quote, err := store.CreateExchangeQuote(context.Background(), CreateExchangeQuoteParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		FromAmount:    10,
+		ToAmount:      305,
+		Rate:          rate.Rate,
+		ExpiredAt:     time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	result, err := store.ExchangeTransferTx(context.Background(), ExchangeTransferTxParams{
+		QuoteID: quote.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, result.Transfer.Transfer.ID, result.Quote.TransferID.Int64)
+
+	transfer := result.Transfer.Transfer
+	require.Equal(t, int64(10), transfer.Amount)
+	require.Equal(t, int64(305), transfer.ToAmount)
+	require.Equal(t, rate.Rate, transfer.ExchangeRate.String)
+	require.Equal(t, int64(-10), result.Transfer.FromEntry.Amount)
+	require.Equal(t, int64(305), result.Transfer.ToEntry.Amount)
+	require.Equal(t, int64(90), result.Transfer.FromAccount.Balance)
+	require.Equal(t, int64(305), result.Transfer.ToAccount.Balance)
+
+	// a quote can only be executed once
+	_, err = store.ExchangeTransferTx(context.Background(), ExchangeTransferTxParams{
+		QuoteID: quote.ID,
+	})
+	require.ErrorIs(t, err, ErrExchangeQuoteUsed)
+
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transfer.ID,
+	})
+	require.ErrorIs(t, err, ErrCrossCurrencyReversal)
+
+	expiredQuote, err := store.CreateExchangeQuote(context.Background(), CreateExchangeQuoteParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		FromAmount:    10,
+		ToAmount:      305,
+		Rate:          rate.Rate,
+		ExpiredAt:     time.Now().Add(-time.Second),
+	})
+	require.NoError(t, err)
+
+	_, err = store.ExchangeTransferTx(context.Background(), ExchangeTransferTxParams{
+		QuoteID: expiredQuote.ID,
+	})
+	require.ErrorIs(t, err, ErrExchangeQuoteExpired)
+}
+
+// createRandomAccountWithBalance creates an account that can afford the transfers of a test
+func createRandomAccountWithBalance(t *testing.T, balance int64) Account {
+	account := createRandomAccount(t)
+
+	account, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:      account.ID,
+		Balance: balance,
+	})
+	require.NoError(t, err)
+
+	return account
+}
+
+func TestReconcileTx(t *testing.T) {
+	store := NewSQLStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 0)
+	account2 := createRandomAccountWithBalance(t, 0)
+	_, err := store.CreateEntry(context.Background(), CreateEntryParams{
+		AccountID: account1.ID,
+		Amount:    100,
+	})
+	require.NoError(t, err)
+	_, err = store.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:      account1.ID,
+		Balance: 100,
+	})
+	require.NoError(t, err)
+
+	transferResult, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	// a balance changed without an entry, and a transfer without entries
+	brokenAccount := createRandomAccountWithBalance(t, 50)
+	brokenTransfer, err := store.CreateTransfer(context.Background(), CreateTransferParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		ToAmount:      10,
+	})
+	require.NoError(t, err)
+
+	result, err := store.ReconcileTx(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ReconciliationStatusMismatch, result.Run.Status)
+	require.Equal(t, int32(len(result.Discrepancies)), result.Run.DiscrepancyCount)
+
+	var foundAccount, foundTransfer bool
+	for _, discrepancy := range result.Discrepancies {
+		require.Equal(t, result.Run.ID, discrepancy.RunID)
+
+		switch discrepancy.Kind {
+		case DiscrepancyKindAccountBalance:
+			require.NotEqual(t, account1.ID, discrepancy.AccountID.Int64)
+			require.NotEqual(t, account2.ID, discrepancy.AccountID.Int64)
+			if discrepancy.AccountID.Int64 == brokenAccount.ID {
+				foundAccount = true
+				require.Equal(t, int64(0), discrepancy.Expected)
+				require.Equal(t, int64(50), discrepancy.Actual)
+			}
+		case DiscrepancyKindTransferEntries:
+			require.NotEqual(t, transferResult.Transfer.ID, discrepancy.TransferID.Int64)
+			if discrepancy.TransferID.Int64 == brokenTransfer.ID {
+				foundTransfer = true
+				require.Equal(t, int64(2), discrepancy.Expected)
+				require.Equal(t, int64(0), discrepancy.Actual)
+			}
+		}
+	}
+	require.True(t, foundAccount)
+	require.True(t, foundTransfer)
+
+	discrepancies, err := store.ListReconciliationDiscrepancies(context.Background(), result.Run.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.Discrepancies, discrepancies)
+}
+
+func createBatchTestAccount(t *testing.T, currency string, balance int64) Account {
+	user := createRandomUser(t)
+
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  balance,
+		Currency: currency,
+	})
+	require.NoError(t, err)
+
+	return account
+}
+
+func TestBatchTransferTx(t *testing.T) {
+	store := NewSQLStore(testDB)
+
+	from := createBatchTestAccount(t, util.USD, 100)
+	to1 := createBatchTestAccount(t, util.USD, 0)
+	to2 := createBatchTestAccount(t, util.USD, 0)
+
+	result, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		FromAccountID: from.ID,
+		Legs: []BatchTransferLeg{
+			{ToAccountID: to1.ID, Amount: 30},
+			{ToAccountID: to2.ID, Amount: 20},
+			{ToAccountID: to1.ID, Amount: 10},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, result.SucceededCount)
+	require.Zero(t, result.FailedCount)
+	require.Equal(t, int64(40), result.FromAccount.Balance)
+	for _, leg := range result.Legs {
+		require.NotNil(t, leg.Transfer)
+		require.Empty(t, leg.Error)
+		require.Equal(t, from.ID, leg.Transfer.FromAccountID)
+		require.Equal(t, leg.ToAccountID, leg.Transfer.ToAccountID)
+		require.Equal(t, leg.Amount, leg.Transfer.Amount)
+	}
+
+	to1, err = store.GetAccount(context.Background(), to1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(40), to1.Balance)
+}
+
+func TestBatchTransferTxAllOrNothing(t *testing.T) {
+	store := NewSQLStore(testDB)
+
+	from := createBatchTestAccount(t, util.USD, 50)
+	to1 := createBatchTestAccount(t, util.USD, 0)
+	to2 := createBatchTestAccount(t, util.USD, 0)
+
+	_, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		FromAccountID: from.ID,
+		Legs: []BatchTransferLeg{
+			{ToAccountID: to1.ID, Amount: 30},
+			{ToAccountID: to2.ID, Amount: 30},
+		},
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+	require.ErrorContains(t, err, "leg 1")
+
+	// the first leg has been rolled back too
+	from, err = store.GetAccount(context.Background(), from.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(50), from.Balance)
+	to1, err = store.GetAccount(context.Background(), to1.ID)
+	require.NoError(t, err)
+	require.Zero(t, to1.Balance)
+}
+
+func TestBatchTransferTxBestEffort(t *testing.T) {
+	store := NewSQLStore(testDB)
+
+	from := createBatchTestAccount(t, util.USD, 50)
+	to1 := createBatchTestAccount(t, util.USD, 0)
+	to2 := createBatchTestAccount(t, util.EUR, 0)
+
+	result, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		FromAccountID: from.ID,
+		BestEffort:    true,
+		Legs: []BatchTransferLeg{
+			{ToAccountID: to1.ID, Amount: 30},
+			{ToAccountID: to2.ID, Amount: 10},
+			{ToAccountID: to1.ID, Amount: 30},
+			{ToAccountID: 1 << 62, Amount: 10},
+			{ToAccountID: to1.ID, Amount: 20},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, result.SucceededCount)
+	require.Equal(t, 3, result.FailedCount)
+	require.Len(t, result.Legs, 5)
+	require.NotNil(t, result.Legs[0].Transfer)
+	require.Equal(t, ErrCurrencyMismatch.Error(), result.Legs[1].Error)
+	require.Equal(t, ErrInsufficientFunds.Error(), result.Legs[2].Error)
+	require.Equal(t, ErrAccountNotFound.Error(), result.Legs[3].Error)
+	require.NotNil(t, result.Legs[4].Transfer)
+	require.Zero(t, result.FromAccount.Balance)
+
+	to1, err = store.GetAccount(context.Background(), to1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(50), to1.Balance)
+}
+
+func TestBatchTransferTxDeadlock(t *testing.T) {
+	store := NewSQLStore(testDB)
+
+	account1 := createBatchTestAccount(t, util.USD, 100)
+	account2 := createBatchTestAccount(t, util.USD, 100)
+	account3 := createBatchTestAccount(t, util.USD, 100)
+
+	// batches going around the three accounts in opposite directions
+	n := 10
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		arg := BatchTransferTxParams{
+			FromAccountID: account1.ID,
+			Legs: []BatchTransferLeg{
+				{ToAccountID: account3.ID, Amount: 1},
+				{ToAccountID: account2.ID, Amount: 1},
+			},
+		}
+		if i%2 == 1 {
+			arg = BatchTransferTxParams{
+				FromAccountID: account3.ID,
+				Legs: []BatchTransferLeg{
+					{ToAccountID: account1.ID, Amount: 1},
+					{ToAccountID: account2.ID, Amount: 1},
+				},
+			}
+		}
+
+		go func() {
+			_, err := store.BatchTransferTx(context.Background(), arg)
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	account2, err := store.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(100+n), account2.Balance)
+}
+
+func randomOutboxMessage() CreateOutboxMessageParams {
+	return CreateOutboxMessageParams{
+		TaskType: "task:" + util.RandomString(8),
+		Payload:  json.RawMessage(`{}`),
+		Queue:    "default",
+		MaxRetry: 1,
+	}
+}
+
+// relayOutboxTaskTypes publishes every pending message and returns their task types
+func relayOutboxTaskTypes(t *testing.T, store Store) map[string]bool {
+	taskTypes := make(map[string]bool)
+	_, err := store.RelayOutboxTx(context.Background(), RelayOutboxTxParams{
+		Limit: 1000,
+		Publish: func(message Outbox) error {
+			taskTypes[message.TaskType] = true
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	return taskTypes
+}
+
+func TestCreateUserTxOutbox(t *testing.T) {
+	store := NewSQLStore(testDB)
+
+	arg := CreateUserTxParams{
+		CreateUserParams: CreateUserParams{
+			Username:       util.RandomOwner(),
+			HashedPassword: util.RandomString(32),
+			FullName:       util.RandomOwner(),
+			Email:          util.RandomEmail(),
+		},
+		OutboxMessages: []CreateOutboxMessageParams{randomOutboxMessage()},
+	}
+	result, err := store.CreateUserTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.CreateUserParams.Username, result.User.Username)
+
+	// the same username again rolls back, so its message is never written
+	duplicate := arg
+	duplicate.OutboxMessages = []CreateOutboxMessageParams{randomOutboxMessage()}
+	_, err = store.CreateUserTx(context.Background(), duplicate)
+	require.Error(t, err)
+
+	taskTypes := relayOutboxTaskTypes(t, store)
+	require.True(t, taskTypes[arg.OutboxMessages[0].TaskType])
+	require.False(t, taskTypes[duplicate.OutboxMessages[0].TaskType])
+}
+
+func TestRelayOutboxTx(t *testing.T) {
+	store := NewSQLStore(testDB)
+
+	message1, err := store.CreateOutboxMessage(context.Background(), randomOutboxMessage())
+	require.NoError(t, err)
+	require.False(t, message1.PublishedAt.Valid)
+	message2, err := store.CreateOutboxMessage(context.Background(), randomOutboxMessage())
+	require.NoError(t, err)
+
+	// the first message is committed as published even though the second one fails
+	publishErr := errors.New("queue unavailable")
+	result, err := store.RelayOutboxTx(context.Background(), RelayOutboxTxParams{
+		Limit: 1000,
+		Publish: func(message Outbox) error {
+			if message.ID == message2.ID {
+				return publishErr
+			}
+			return nil
+		},
+	})
+	require.ErrorIs(t, err, publishErr)
+	require.NotEmpty(t, result.Messages)
+	last := result.Messages[len(result.Messages)-1]
+	require.Equal(t, message1.ID, last.ID)
+	require.True(t, last.PublishedAt.Valid)
+
+	taskTypes := relayOutboxTaskTypes(t, store)
+	require.False(t, taskTypes[message1.TaskType])
+	require.True(t, taskTypes[message2.TaskType])
+
+	require.False(t, relayOutboxTaskTypes(t, store)[message2.TaskType])
+}
+
+func TestExecTxWithRetry(t *testing.T) {
+	store := NewSQLStore(testDB).(*SQLStore)
+	opts := txOptions{
+		name:       "test",
+		isolation:  sql.LevelSerializable,
+		maxRetries: 2,
+	}
+	serializationFailure := &pq.Error{Code: "40001"}
+
+	// succeeds on the last retry
+	attempts := 0
+	err := store.execTxWithRetry(context.Background(), opts, func(q *Queries) error {
+		attempts++
+		if attempts <= opts.maxRetries {
+			return serializationFailure
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, opts.maxRetries+1, attempts)
+
+	// gives up once the retries are exhausted
+	attempts = 0
+	err = store.execTxWithRetry(context.Background(), opts, func(q *Queries) error {
+		attempts++
+		return &pq.Error{Code: "40P01"}
+	})
+	require.True(t, isRetryableError(err))
+	require.Equal(t, opts.maxRetries+1, attempts)
+
+	// other errors are returned straight away
+	attempts = 0
+	err = store.execTxWithRetry(context.Background(), opts, func(q *Queries) error {
+		attempts++
+		return ErrInsufficientFunds
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+	require.Equal(t, 1, attempts)
+}
+
+func TestTxRetryDelay(t *testing.T) {
+	for attempt := 0; attempt < 20; attempt++ {
+		delay := txRetryDelay(attempt)
+		require.Positive(t, delay)
+		require.LessOrEqual(t, delay, txRetryMaxDelay)
+		if attempt == 0 {
+			require.LessOrEqual(t, delay, txRetryBaseDelay)
+		}
+	}
+}
+
+func TestAdjustBalanceTx(t *testing.T) {
+	store := NewSQLStore(testDB)
+	admin := createRandomUser(t)
+	account := createRandomAccountWithBalance(t, 100)
+
+	result, err := store.AdjustBalanceTx(context.Background(), AdjustBalanceTxParams{
+		AccountID: account.ID,
+		Amount:    -30,
+		Reason:    "duplicated deposit",
+		CreatedBy: admin.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(70), result.Account.Balance)
+	require.Equal(t, int64(-30), result.Entry.Amount)
+	require.False(t, result.Entry.TransferID.Valid)
+	require.Equal(t, result.Entry.ID, result.Adjustment.EntryID)
+	require.Equal(t, admin.Username, result.Adjustment.CreatedBy)
+	require.Equal(t, "duplicated deposit", result.Adjustment.Reason)
+
+	adjustments, err := store.ListLedgerAdjustments(context.Background(), ListLedgerAdjustmentsParams{
+		AccountID: account.ID,
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Len(t, adjustments, 1)
+	require.Equal(t, result.Adjustment, adjustments[0])
+
+	// a debit can't go past the overdraft limit
+	_, err = store.AdjustBalanceTx(context.Background(), AdjustBalanceTxParams{
+		AccountID: account.ID,
+		Amount:    -71,
+		Reason:    "too much",
+		CreatedBy: admin.Username,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     account.ID,
+		Status: AccountStatusClosed,
+	})
+	require.NoError(t, err)
+
+	_, err = store.AdjustBalanceTx(context.Background(), AdjustBalanceTxParams{
+		AccountID: account.ID,
+		Amount:    10,
+		Reason:    "closed account",
+		CreatedBy: admin.Username,
+	})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+}
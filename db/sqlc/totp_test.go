@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createConfirmedTotp(t *testing.T, store Store, username string, hashedRecoveryCodes []string) UserTotp {
+	secret, err := util.NewTotpSecret()
+	require.NoError(t, err)
+
+	_, err = store.UpsertUserTotp(context.Background(), UpsertUserTotpParams{
+		Username: username,
+		Secret:   secret,
+	})
+	require.NoError(t, err)
+
+	result, err := store.ConfirmTotpTx(context.Background(), ConfirmTotpTxParams{
+		Username:            username,
+		Step:                util.TotpStep(time.Now()),
+		HashedRecoveryCodes: hashedRecoveryCodes,
+	})
+	require.NoError(t, err)
+	require.True(t, result.UserTotp.ConfirmedAt.Valid)
+	require.Equal(t, secret, result.UserTotp.Secret)
+	return result.UserTotp
+}
+
+func TestConfirmTotpTx(t *testing.T) {
+	store := NewSQLStore(testDB)
+	user := createRandomUser(t)
+	userTotp := createConfirmedTotp(t, store, user.Username, nil)
+
+	// a confirmed secret is neither replaced nor confirmed again
+	_, err := store.UpsertUserTotp(context.Background(), UpsertUserTotpParams{
+		Username: user.Username,
+		Secret:   util.RandomString(32),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = store.ConfirmTotpTx(context.Background(), ConfirmTotpTxParams{
+		Username: user.Username,
+		Step:     userTotp.LastUsedStep + 1,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	err = store.DisableTotpTx(context.Background(), DisableTotpTxParams{
+		Username:     user.Username,
+		SecondFactor: SecondFactor{Step: userTotp.LastUsedStep + 1},
+	})
+	require.NoError(t, err)
+
+	_, err = store.GetUserTotp(context.Background(), user.Username)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestCompleteLoginChallengeTx(t *testing.T) {
+	store := NewSQLStore(testDB)
+	user := createRandomUser(t)
+	recoveryCode, err := util.NewRecoveryCode()
+	require.NoError(t, err)
+	userTotp := createConfirmedTotp(t, store, user.Username, []string{util.HashRecoveryCode(recoveryCode)})
+
+	createChallenge := func() LoginChallenge {
+		challenge, err := store.CreateLoginChallenge(context.Background(), CreateLoginChallengeParams{
+			ID:        uuid.New(),
+			Username:  user.Username,
+			ExpiredAt: time.Now().Add(time.Minute),
+		})
+		require.NoError(t, err)
+		return challenge
+	}
+	complete := func(challenge LoginChallenge, factor SecondFactor) error {
+		_, err := store.CompleteLoginChallengeTx(context.Background(), CompleteLoginChallengeTxParams{
+			ChallengeID:  challenge.ID,
+			Username:     user.Username,
+			SecondFactor: factor,
+		})
+		return err
+	}
+
+	// the step confirming the enrollment can't be replayed
+	challenge := createChallenge()
+	require.ErrorIs(t, complete(challenge, SecondFactor{Step: userTotp.LastUsedStep}), sql.ErrNoRows)
+	require.NoError(t, complete(challenge, SecondFactor{Step: userTotp.LastUsedStep + 1}))
+	require.ErrorIs(t, complete(challenge, SecondFactor{Step: userTotp.LastUsedStep + 2}), sql.ErrNoRows)
+
+	// a recovery code is used once
+	challenge = createChallenge()
+	require.NoError(t, complete(challenge, SecondFactor{HashedRecoveryCode: util.HashRecoveryCode(recoveryCode)}))
+	challenge = createChallenge()
+	require.ErrorIs(t, complete(challenge, SecondFactor{HashedRecoveryCode: util.HashRecoveryCode(recoveryCode)}), sql.ErrNoRows)
+
+	// the challenge is used up by too many failures
+	for i := 1; i <= 3; i++ {
+		failed, err := store.RecordLoginChallengeFailure(context.Background(), RecordLoginChallengeFailureParams{
+			MaxFailures: 3,
+			ID:          challenge.ID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, int32(i), failed.FailedCount)
+		require.Equal(t, i == 3, failed.IsUsed)
+	}
+	require.ErrorIs(t, complete(challenge, SecondFactor{Step: userTotp.LastUsedStep + 3}), sql.ErrNoRows)
+}
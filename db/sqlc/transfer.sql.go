@@ -7,26 +7,69 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
+const addTransferReversedAmount = `-- name: AddTransferReversedAmount :one
+UPDATE transfers
+SET reversed_amount = reversed_amount + $1
+WHERE id = $2
+RETURNING id, from_account_id, to_account_id, amount, created_at, reversed_transfer_id, reversed_amount, to_amount, exchange_rate
+`
+
+type AddTransferReversedAmountParams struct {
+	Amount int64 `json:"amount"`
+	ID     int64 `json:"id"`
+}
+
+func (q *Queries) AddTransferReversedAmount(ctx context.Context, arg AddTransferReversedAmountParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, addTransferReversedAmount, arg.Amount, arg.ID)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ReversedTransferID,
+		&i.ReversedAmount,
+		&i.ToAmount,
+		&i.ExchangeRate,
+	)
+	return i, err
+}
+
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
     from_account_id, 
     to_account_id, 
-    amount
+    amount,
+    reversed_transfer_id,
+    to_amount,
+    exchange_rate
 ) VALUES (
-    $1, $2, $3
-) RETURNING id, from_account_id, to_account_id, amount, created_at
+    $1, $2, $3, $4, $5, $6
+) RETURNING id, from_account_id, to_account_id, amount, created_at, reversed_transfer_id, reversed_amount, to_amount, exchange_rate
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID      int64          `json:"from_account_id"`
+	ToAccountID        int64          `json:"to_account_id"`
+	Amount             int64          `json:"amount"`
+	ReversedTransferID sql.NullInt64  `json:"reversed_transfer_id"`
+	ToAmount           int64          `json:"to_amount"`
+	ExchangeRate       sql.NullString `json:"exchange_rate"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ReversedTransferID,
+		arg.ToAmount,
+		arg.ExchangeRate,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -34,12 +77,16 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ReversedTransferID,
+		&i.ReversedAmount,
+		&i.ToAmount,
+		&i.ExchangeRate,
 	)
 	return i, err
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at 
+SELECT id, from_account_id, to_account_id, amount, created_at, reversed_transfer_id, reversed_amount, to_amount, exchange_rate 
 FROM transfers 
 WHERE id = $1
 LIMIT 1
@@ -54,24 +101,94 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ReversedTransferID,
+		&i.ReversedAmount,
+		&i.ToAmount,
+		&i.ExchangeRate,
+	)
+	return i, err
+}
+
+const getTransferForUpdate = `-- name: GetTransferForUpdate :one
+SELECT id, from_account_id, to_account_id, amount, created_at, reversed_transfer_id, reversed_amount, to_amount, exchange_rate 
+FROM transfers 
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, getTransferForUpdate, id)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ReversedTransferID,
+		&i.ReversedAmount,
+		&i.ToAmount,
+		&i.ExchangeRate,
 	)
 	return i, err
 }
 
-const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at
+const listAccountTransfers = `-- name: ListAccountTransfers :many
+(SELECT id, from_account_id, to_account_id, amount, created_at, reversed_transfer_id, reversed_amount, to_amount, exchange_rate
 FROM transfers
-LIMIT $1
-OFFSET $2
+WHERE from_account_id = $1
+  AND ($2::varchar IS NULL OR $2 = 'out')
+  AND ($3::timestamptz IS NULL OR created_at >= $3)
+  AND ($4::timestamptz IS NULL OR created_at < $4)
+  AND ($5::bigint IS NULL OR amount >= $5)
+  AND ($6::bigint IS NULL OR amount <= $6)
+  AND ($7::timestamptz IS NULL
+    OR (created_at, id) < ($7, $8::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT $9)
+UNION ALL
+(SELECT id, from_account_id, to_account_id, amount, created_at, reversed_transfer_id, reversed_amount, to_amount, exchange_rate
+FROM transfers
+WHERE to_account_id = $1
+  AND ($2 IS NULL OR $2 = 'in')
+  AND ($2 IS NOT NULL OR from_account_id <> $1)
+  AND ($3 IS NULL OR created_at >= $3)
+  AND ($4 IS NULL OR created_at < $4)
+  AND ($5 IS NULL OR amount >= $5)
+  AND ($6 IS NULL OR amount <= $6)
+  AND ($7 IS NULL
+    OR (created_at, id) < ($7, $8))
+ORDER BY created_at DESC, id DESC
+LIMIT $9)
+ORDER BY created_at DESC, id DESC
+LIMIT $9
 `
 
-type ListTransfersParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+type ListAccountTransfersParams struct {
+	AccountID       int64          `json:"account_id"`
+	Direction       sql.NullString `json:"direction"`
+	StartTime       sql.NullTime   `json:"start_time"`
+	EndTime         sql.NullTime   `json:"end_time"`
+	MinAmount       sql.NullInt64  `json:"min_amount"`
+	MaxAmount       sql.NullInt64  `json:"max_amount"`
+	CursorCreatedAt sql.NullTime   `json:"cursor_created_at"`
+	CursorID        sql.NullInt64  `json:"cursor_id"`
+	PageSize        int32          `json:"page_size"`
 }
 
-func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
-	rows, err := q.db.QueryContext(ctx, listTransfers, arg.Limit, arg.Offset)
+func (q *Queries) ListAccountTransfers(ctx context.Context, arg ListAccountTransfersParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountTransfers,
+		arg.AccountID,
+		arg.Direction,
+		arg.StartTime,
+		arg.EndTime,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -85,6 +202,10 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ReversedTransferID,
+			&i.ReversedAmount,
+			&i.ToAmount,
+			&i.ExchangeRate,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,156 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirmTransferTx(t *testing.T) {
+	store := NewSQLStore(testDB)
+	account1 := createRandomAccountWithBalance(t, 100)
+	account2 := createRandomAccount(t)
+	code := "123456"
+
+	arg := CreatePendingTransferTxParams{
+		ChallengeID:    uuid.New(),
+		Username:       account1.Owner,
+		FromAccountID:  account1.ID,
+		ToAccountID:    account2.ID,
+		Amount:         60,
+		IdempotencyKey: util.RandomString(32),
+		Method:         TransferChallengeMethodEmail,
+		HashedCode:     util.HashEmailCode(code),
+		ExpiredAt:      time.Now().Add(time.Minute),
+	}
+	pending, err := store.CreatePendingTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, pending.Confirmed)
+	require.Equal(t, pending.Hold.ID, pending.TransferChallenge.HoldID)
+	require.Equal(t, int64(40), pending.FromAccount.AvailableBalance)
+
+	// the receiver can't capture the hold of a pending transfer
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: pending.Hold.ID})
+	require.ErrorIs(t, err, ErrHoldStepUpRequired)
+
+	// a retry returns the pending transfer, without holding the amount twice
+	retryArg := arg
+	retryArg.ChallengeID = uuid.New()
+	retried, err := store.CreatePendingTransferTx(context.Background(), retryArg)
+	require.NoError(t, err)
+	require.Equal(t, pending.TransferChallenge.ID, retried.TransferChallenge.ID)
+	require.Equal(t, int64(40), retried.FromAccount.AvailableBalance)
+
+	conflictArg := arg
+	conflictArg.Amount = 50
+	_, err = store.CreatePendingTransferTx(context.Background(), conflictArg)
+	require.ErrorIs(t, err, ErrIdempotencyKeyConflict)
+
+	// only the owner of the challenge can confirm it
+	_, err = store.ConfirmTransferTx(context.Background(), ConfirmTransferTxParams{
+		ChallengeID: pending.TransferChallenge.ID,
+		Username:    account2.Owner,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	confirmed, err := store.ConfirmTransferTx(context.Background(), ConfirmTransferTxParams{
+		ChallengeID: pending.TransferChallenge.ID,
+		Username:    account1.Owner,
+	})
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusCaptured, confirmed.Hold.Status)
+	require.Equal(t, int64(60), confirmed.Transfer.Transfer.Amount)
+	require.Equal(t, int64(40), confirmed.Transfer.FromAccount.Balance)
+
+	_, err = store.ConfirmTransferTx(context.Background(), ConfirmTransferTxParams{
+		ChallengeID: pending.TransferChallenge.ID,
+		Username:    account1.Owner,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// once confirmed, a retry returns the transfer made
+	retryArg.ChallengeID = uuid.New()
+	retried, err = store.CreatePendingTransferTx(context.Background(), retryArg)
+	require.NoError(t, err)
+	require.True(t, retried.Confirmed)
+	require.Equal(t, confirmed.Transfer.Transfer.ID, retried.Transfer.Transfer.ID)
+}
+
+func TestConcurrentCreatePendingTransferTx(t *testing.T) {
+	store := NewSQLStore(testDB)
+	account1 := createRandomAccountWithBalance(t, 100)
+	account2 := createRandomAccount(t)
+	idempotencyKey := util.RandomString(32)
+
+	// concurrent requests with the same key all get the one pending transfer
+	n := 5
+	errs := make(chan error)
+	results := make(chan CreatePendingTransferTxResult)
+	for i := 0; i < n; i++ {
+		go func() {
+			result, err := store.CreatePendingTransferTx(context.Background(), CreatePendingTransferTxParams{
+				ChallengeID:    uuid.New(),
+				Username:       account1.Owner,
+				FromAccountID:  account1.ID,
+				ToAccountID:    account2.ID,
+				Amount:         10,
+				IdempotencyKey: idempotencyKey,
+				Method:         TransferChallengeMethodTotp,
+				ExpiredAt:      time.Now().Add(time.Minute),
+			})
+			errs <- err
+			results <- result
+		}()
+	}
+
+	challengeIDs := make(map[uuid.UUID]bool)
+	for i := 0; i < n; i++ {
+		err := <-errs
+		result := <-results
+		require.NoError(t, err)
+		challengeIDs[result.TransferChallenge.ID] = true
+	}
+	require.Len(t, challengeIDs, 1)
+
+	account1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(90), account1.AvailableBalance)
+}
+
+func TestRecordTransferChallengeFailure(t *testing.T) {
+	store := NewSQLStore(testDB)
+	account1 := createRandomAccountWithBalance(t, 100)
+	account2 := createRandomAccount(t)
+
+	pending, err := store.CreatePendingTransferTx(context.Background(), CreatePendingTransferTxParams{
+		ChallengeID:   uuid.New(),
+		Username:      account1.Owner,
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        60,
+		Method:        TransferChallengeMethodTotp,
+		ExpiredAt:     time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	for i := 1; i <= 3; i++ {
+		challenge, err := store.RecordTransferChallengeFailure(context.Background(), RecordTransferChallengeFailureParams{
+			MaxFailures: 3,
+			ID:          pending.TransferChallenge.ID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, int32(i), challenge.FailedCount)
+		require.Equal(t, i == 3, challenge.IsUsed)
+	}
+
+	_, err = store.ConfirmTransferTx(context.Background(), ConfirmTransferTxParams{
+		ChallengeID: pending.TransferChallenge.ID,
+		Username:    account1.Owner,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
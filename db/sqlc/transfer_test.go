@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -49,21 +50,78 @@ func TestGetTransfer(t *testing.T) {
 	require.Equal(t, transfer1.CreatedAt, transfer2.CreatedAt)
 }
 
-func TestListTransfers(t *testing.T) {
+func TestListAccountTransfers(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
 	for i := 0; i < 10; i++ {
-		createRandomTransfer(t)
+		arg := CreateTransferParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        int64(i + 1),
+		}
+		if i%2 == 1 {
+			arg.FromAccountID, arg.ToAccountID = account2.ID, account1.ID
+		}
+		_, err := testQueries.CreateTransfer(context.Background(), arg)
+		require.NoError(t, err)
 	}
 
-	arg := ListTransfersParams{
-		Limit:  5,
-		Offset: 5,
+	arg := ListAccountTransfersParams{
+		AccountID: account1.ID,
+		PageSize:  3,
 	}
 
-	transfers, err := testQueries.ListTransfers(context.Background(), arg)
-	require.NoError(t, err)
-	require.Len(t, transfers, 5)
+	var all []Transfer
+	for {
+		transfers, err := testQueries.ListAccountTransfers(context.Background(), arg)
+		require.NoError(t, err)
+		all = append(all, transfers...)
+		if len(transfers) < int(arg.PageSize) {
+			break
+		}
+
+		last := transfers[len(transfers)-1]
+		arg.CursorCreatedAt = sql.NullTime{Time: last.CreatedAt, Valid: true}
+		arg.CursorID = sql.NullInt64{Int64: last.ID, Valid: true}
+	}
+	require.Len(t, all, 10)
+	for i := 1; i < len(all); i++ {
+		require.False(t, all[i].CreatedAt.After(all[i-1].CreatedAt))
+		require.NotEqual(t, all[i-1].ID, all[i].ID)
+	}
 
+	transfers, err := testQueries.ListAccountTransfers(context.Background(), ListAccountTransfersParams{
+		AccountID: account1.ID,
+		Direction: sql.NullString{String: "in", Valid: true},
+		MaxAmount: sql.NullInt64{Int64: 6, Valid: true},
+		PageSize:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 3)
 	for _, transfer := range transfers {
-		require.NotEmpty(t, transfer)
+		require.Equal(t, account1.ID, transfer.ToAccountID)
+		require.LessOrEqual(t, transfer.Amount, int64(6))
+	}
+}
+
+func TestListAccountTransfersToSameAccount(t *testing.T) {
+	account := createRandomAccount(t)
+	transfer, err := testQueries.CreateTransfer(context.Background(), CreateTransferParams{
+		FromAccountID: account.ID,
+		ToAccountID:   account.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	// the transfer is sent and received by the account, it is listed once in either direction
+	for _, direction := range []sql.NullString{{}, {String: "in", Valid: true}, {String: "out", Valid: true}} {
+		transfers, err := testQueries.ListAccountTransfers(context.Background(), ListAccountTransfersParams{
+			AccountID: account.ID,
+			Direction: direction,
+			PageSize:  10,
+		})
+		require.NoError(t, err)
+		require.Len(t, transfers, 1)
+		require.Equal(t, transfer.ID, transfers[0].ID)
 	}
 }
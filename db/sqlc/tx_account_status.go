@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	AccountStatusActive = "active"
+	AccountStatusFrozen = "frozen"
+	AccountStatusClosed = "closed"
+)
+
+// accountStatusTransitions lists the statuses an account can move to from its current status,
+// a closed account can never be reopened
+var accountStatusTransitions = map[string][]string{
+	AccountStatusActive: {AccountStatusFrozen, AccountStatusClosed},
+	AccountStatusFrozen: {AccountStatusActive, AccountStatusClosed},
+}
+
+type UpdateAccountStatusTxParams struct {
+	AccountID int64  `json:"account_id"`
+	Status    string `json:"status"`
+	// ByAdmin is set when an admin changes the status. Only an admin can change the status
+	// of an account an admin froze, and an admin can take over the freeze of its owner
+	ByAdmin bool `json:"by_admin"`
+}
+
+type UpdateAccountStatusTxResult struct {
+	Account Account `json:"account"`
+}
+
+func (s *SQLStore) UpdateAccountStatusTx(ctx context.Context, arg UpdateAccountStatusTxParams) (UpdateAccountStatusTxResult, error) {
+	var result UpdateAccountStatusTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		if account.FrozenByAdmin && !arg.ByAdmin {
+			return fmt.Errorf("%w: account %d", ErrAccountFrozenByAdmin, account.ID)
+		}
+
+		takeOverFreeze := arg.ByAdmin && arg.Status == AccountStatusFrozen &&
+			account.Status == AccountStatusFrozen && !account.FrozenByAdmin
+		if !takeOverFreeze && !canTransitAccountStatus(account.Status, arg.Status) {
+			return fmt.Errorf("%w: from %s to %s", ErrInvalidAccountStatusTransition, account.Status, arg.Status)
+		}
+
+		if arg.Status == AccountStatusClosed && (account.Balance != 0 || account.HeldAmount != 0) {
+			return ErrAccountBalanceNotZero
+		}
+
+		result.Account, err = q.UpdateAccountStatus(ctx, UpdateAccountStatusParams{
+			ID:            arg.AccountID,
+			Status:        arg.Status,
+			FrozenByAdmin: arg.ByAdmin && arg.Status == AccountStatusFrozen,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+func canTransitAccountStatus(from string, to string) bool {
+	for _, status := range accountStatusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAccountActive fails with ErrAccountNotActive for frozen or closed accounts
+func checkAccountActive(account Account) error {
+	if account.Status != AccountStatusActive {
+		return fmt.Errorf("%w: account %d is %s", ErrAccountNotActive, account.ID, account.Status)
+	}
+	return nil
+}
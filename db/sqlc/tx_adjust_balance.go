@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+type AdjustBalanceTxParams struct {
+	AccountID int64 `json:"account_id"`
+	// Amount is credited when positive and debited when negative
+	Amount    int64  `json:"amount"`
+	Reason    string `json:"reason"`
+	CreatedBy string `json:"created_by"`
+}
+
+type AdjustBalanceTxResult struct {
+	Account    Account          `json:"account"`
+	Entry      Entry            `json:"entry"`
+	Adjustment LedgerAdjustment `json:"adjustment"`
+}
+
+// AdjustBalanceTx corrects the balance of the account with a journal entry that belongs to no transfer,
+// recording who made it and why. Frozen accounts can be adjusted, closed ones can't,
+// and a debit may not take the balance past the overdraft limit
+func (s *SQLStore) AdjustBalanceTx(ctx context.Context, arg AdjustBalanceTxParams) (AdjustBalanceTxResult, error) {
+	var result AdjustBalanceTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		if account.Status == AccountStatusClosed {
+			return fmt.Errorf("%w: account %d is %s", ErrAccountNotActive, account.ID, account.Status)
+		}
+
+		result.Entry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID: arg.AccountID,
+			Amount:    arg.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Account, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			ID:     arg.AccountID,
+			Amount: arg.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Adjustment, err = q.CreateLedgerAdjustment(ctx, CreateLedgerAdjustmentParams{
+			AccountID: arg.AccountID,
+			EntryID:   result.Entry.ID,
+			Amount:    arg.Amount,
+			Reason:    arg.Reason,
+			CreatedBy: arg.CreatedBy,
+		})
+		return err
+	})
+
+	return result, err
+}
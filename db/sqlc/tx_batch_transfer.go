@@ -0,0 +1,164 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+const batchTransferLegSavepoint = "batch_transfer_leg"
+
+type BatchTransferLeg struct {
+	ToAccountID int64 `json:"to_account_id"`
+	Amount      int64 `json:"amount"`
+}
+
+type BatchTransferTxParams struct {
+	FromAccountID int64              `json:"from_account_id"`
+	Legs          []BatchTransferLeg `json:"legs"`
+	// BestEffort commits the legs which succeed and reports the failed ones,
+	// otherwise the first failing leg rolls the whole batch back
+	BestEffort bool `json:"best_effort"`
+}
+
+type BatchTransferLegResult struct {
+	ToAccountID int64     `json:"to_account_id"`
+	Amount      int64     `json:"amount"`
+	Transfer    *Transfer `json:"transfer,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+type BatchTransferTxResult struct {
+	FromAccount    Account                  `json:"from_account"`
+	Legs           []BatchTransferLegResult `json:"legs"`
+	SucceededCount int                      `json:"succeeded_count"`
+	FailedCount    int                      `json:"failed_count"`
+}
+
+// BatchTransferTx moves money from one account to many in a single transaction.
+// All the accounts are locked up front by ascending id, so batches sharing
+// accounts can't deadlock each other or a single transfer
+func (s *SQLStore) BatchTransferTx(ctx context.Context, arg BatchTransferTxParams) (BatchTransferTxResult, error) {
+	var result BatchTransferTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		result = BatchTransferTxResult{
+			Legs: make([]BatchTransferLegResult, 0, len(arg.Legs)),
+		}
+
+		accounts, err := lockBatchAccounts(ctx, q, arg)
+		if err != nil {
+			return err
+		}
+		fromAccount, ok := accounts[arg.FromAccountID]
+		if !ok {
+			return sql.ErrNoRows
+		}
+
+		for i, leg := range arg.Legs {
+			legResult := BatchTransferLegResult{
+				ToAccountID: leg.ToAccountID,
+				Amount:      leg.Amount,
+			}
+
+			transfer, err := transferBatchLeg(ctx, q, fromAccount, accounts, leg, arg.BestEffort)
+			if err != nil {
+				if !arg.BestEffort || !isBatchLegError(err) {
+					return fmt.Errorf("leg %d: %w", i, err)
+				}
+				legResult.Error = err.Error()
+				result.FailedCount++
+			} else {
+				legResult.Transfer = &transfer
+				result.SucceededCount++
+			}
+			result.Legs = append(result.Legs, legResult)
+		}
+
+		result.FromAccount, err = q.GetAccount(ctx, arg.FromAccountID)
+		return err
+	})
+
+	return result, err
+}
+
+// lockBatchAccounts locks every account of the batch once, by ascending id.
+// Destinations which don't exist are left out, their legs fail with ErrAccountNotFound
+func lockBatchAccounts(ctx context.Context, q *Queries, arg BatchTransferTxParams) (map[int64]Account, error) {
+	ids := []int64{arg.FromAccountID}
+	for _, leg := range arg.Legs {
+		ids = append(ids, leg.ToAccountID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	accounts := make(map[int64]Account, len(ids))
+	for i, id := range ids {
+		if i > 0 && ids[i-1] == id {
+			continue
+		}
+
+		account, err := q.GetAccountForUpdate(ctx, id)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		accounts[id] = account
+	}
+
+	return accounts, nil
+}
+
+// transferBatchLeg executes one leg, in best-effort mode it runs inside a savepoint
+// so a failing leg is undone without aborting the transaction
+func transferBatchLeg(ctx context.Context, q *Queries, fromAccount Account, accounts map[int64]Account, leg BatchTransferLeg, bestEffort bool) (Transfer, error) {
+	toAccount, ok := accounts[leg.ToAccountID]
+	if !ok {
+		return Transfer{}, ErrAccountNotFound
+	}
+	if toAccount.Currency != fromAccount.Currency {
+		return Transfer{}, ErrCurrencyMismatch
+	}
+
+	if !bestEffort {
+		result, err := transferMoney(ctx, q, CreateTransferParams{
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   leg.ToAccountID,
+			Amount:        leg.Amount,
+		})
+		return result.Transfer, translateError(err)
+	}
+
+	_, err := q.db.ExecContext(ctx, "SAVEPOINT "+batchTransferLegSavepoint)
+	if err != nil {
+		return Transfer{}, err
+	}
+
+	result, err := transferMoney(ctx, q, CreateTransferParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   leg.ToAccountID,
+		Amount:        leg.Amount,
+	})
+	if err != nil {
+		_, rbErr := q.db.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+batchTransferLegSavepoint)
+		if rbErr != nil {
+			return Transfer{}, fmt.Errorf("leg err: %v, rb err: %w", err, rbErr)
+		}
+		return Transfer{}, translateError(err)
+	}
+
+	_, err = q.db.ExecContext(ctx, "RELEASE SAVEPOINT "+batchTransferLegSavepoint)
+	return result.Transfer, err
+}
+
+// isBatchLegError reports whether the error only concerns one leg,
+// any other error aborts the batch even in best-effort mode
+func isBatchLegError(err error) bool {
+	return errors.Is(err, ErrAccountNotFound) ||
+		errors.Is(err, ErrCurrencyMismatch) ||
+		errors.Is(err, ErrInsufficientFunds) ||
+		errors.Is(err, ErrAccountNotActive)
+}
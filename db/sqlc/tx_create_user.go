@@ -1,10 +1,20 @@
 package db
 
-import "context"
+import (
+	"context"
+	"database/sql"
+)
 
 type CreateUserTxParams struct {
 	CreateUserParams CreateUserParams
-	AfterUserCreated func(User) error
+	// OutboxMessages are the tasks to publish once the user has been created
+	OutboxMessages []CreateOutboxMessageParams
+}
+
+var createUserTxOptions = txOptions{
+	name:       "create_user",
+	isolation:  sql.LevelReadCommitted,
+	maxRetries: 3,
 }
 
 type CreateUserTxResult struct {
@@ -14,13 +24,13 @@ type CreateUserTxResult struct {
 func (s *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error) {
 	var result CreateUserTxResult
 
-	err := s.execTx(ctx, func(q *Queries) error {
+	err := s.execTxWithRetry(ctx, createUserTxOptions, func(q *Queries) error {
 		newUser, err := q.CreateUser(ctx, arg.CreateUserParams)
 		if err != nil {
 			return err
 		}
 
-		if err := arg.AfterUserCreated(newUser); err != nil {
+		if err := createOutboxMessages(ctx, q, arg.OutboxMessages); err != nil {
 			return err
 		}
 
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type ExchangeTransferTxParams struct {
+	QuoteID int64 `json:"quote_id"`
+}
+
+type ExchangeTransferTxResult struct {
+	Quote    ExchangeQuote    `json:"quote"`
+	Transfer TransferTxResult `json:"transfer"`
+}
+
+// ExchangeTransferTx executes a cross-currency transfer at the rate locked by the quote,
+// each account gets an entry in its own currency
+func (s *SQLStore) ExchangeTransferTx(ctx context.Context, arg ExchangeTransferTxParams) (ExchangeTransferTxResult, error) {
+	var result ExchangeTransferTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		// lock the quote, so it can only be executed once
+		quote, err := q.GetExchangeQuoteForUpdate(ctx, arg.QuoteID)
+		if err != nil {
+			return err
+		}
+
+		if quote.TransferID.Valid {
+			return ErrExchangeQuoteUsed
+		}
+		if time.Now().After(quote.ExpiredAt) {
+			return ErrExchangeQuoteExpired
+		}
+
+		result.Transfer, err = transferMoney(ctx, q, CreateTransferParams{
+			FromAccountID: quote.FromAccountID,
+			ToAccountID:   quote.ToAccountID,
+			Amount:        quote.FromAmount,
+			ToAmount:      quote.ToAmount,
+			ExchangeRate: sql.NullString{
+				String: quote.Rate,
+				Valid:  true,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Quote, err = q.UpdateExchangeQuoteTransfer(ctx, UpdateExchangeQuoteTransferParams{
+			ID: quote.ID,
+			TransferID: sql.NullInt64{
+				Int64: result.Transfer.Transfer.ID,
+				Valid: true,
+			},
+		})
+		return err
+	})
+
+	return result, err
+}
+
+type UpsertExchangeRatesTxParams struct {
+	Rates []UpsertExchangeRateParams `json:"rates"`
+}
+
+type UpsertExchangeRatesTxResult struct {
+	Rates []ExchangeRate `json:"rates"`
+}
+
+// UpsertExchangeRatesTx replaces the given rates all at once, the quotes issued before keep their rate
+func (s *SQLStore) UpsertExchangeRatesTx(ctx context.Context, arg UpsertExchangeRatesTxParams) (UpsertExchangeRatesTxResult, error) {
+	var result UpsertExchangeRatesTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		for _, rateArg := range arg.Rates {
+			rate, err := q.UpsertExchangeRate(ctx, rateArg)
+			if err != nil {
+				return err
+			}
+			result.Rates = append(result.Rates, rate)
+		}
+		return nil
+	})
+
+	return result, err
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+type FreezeUserTxParams struct {
+	Username string `json:"username"`
+	Frozen   bool   `json:"frozen"`
+}
+
+type FreezeUserTxResult struct {
+	User User `json:"user"`
+	// BlockedSessions is the number of sessions blocked when the user was frozen
+	BlockedSessions int64 `json:"blocked_sessions"`
+}
+
+// FreezeUserTx freezes or unfreezes the user. Freezing also blocks all the sessions of the user,
+// so no refresh token can be renewed, and they are not restored on unfreeze
+func (s *SQLStore) FreezeUserTx(ctx context.Context, arg FreezeUserTxParams) (FreezeUserTxResult, error) {
+	var result FreezeUserTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.User, err = q.UpdateUser(ctx, UpdateUserParams{
+			Username: arg.Username,
+			IsFrozen: sql.NullBool{Bool: arg.Frozen, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		if arg.Frozen {
+			result.BlockedSessions, err = q.BlockUserSessions(ctx, arg.Username)
+		}
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,239 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	HoldStatusAuthorized = "authorized"
+	HoldStatusCaptured   = "captured"
+	HoldStatusVoided     = "voided"
+	HoldStatusExpired    = "expired"
+)
+
+type AuthorizeHoldTxParams struct {
+	AccountID   int64     `json:"account_id"`
+	ToAccountID int64     `json:"to_account_id"`
+	Amount      int64     `json:"amount"`
+	ExpiredAt   time.Time `json:"expired_at"`
+}
+
+type AuthorizeHoldTxResult struct {
+	Hold    Hold    `json:"hold"`
+	Account Account `json:"account"`
+}
+
+// AuthorizeHoldTx reserves the amount on the account without moving it,
+// the reserved funds are no longer part of the available balance
+func (s *SQLStore) AuthorizeHoldTx(ctx context.Context, arg AuthorizeHoldTxParams) (AuthorizeHoldTxResult, error) {
+	var result AuthorizeHoldTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+		result, err = authorizeHold(ctx, q, arg)
+		return err
+	})
+
+	return result, err
+}
+
+func authorizeHold(ctx context.Context, q *Queries, arg AuthorizeHoldTxParams) (AuthorizeHoldTxResult, error) {
+	var result AuthorizeHoldTxResult
+
+	account, toAccount, err := lockAccounts(ctx, q, arg.AccountID, arg.ToAccountID)
+	if err != nil {
+		return result, err
+	}
+
+	if err := checkAccountActive(account); err != nil {
+		return result, err
+	}
+	if err := checkAccountActive(toAccount); err != nil {
+		return result, err
+	}
+
+	if account.AvailableBalance+account.OverdraftLimit < arg.Amount {
+		return result, ErrInsufficientFunds
+	}
+
+	result.Account, err = q.AddAccountHeldAmount(ctx, AddAccountHeldAmountParams{
+		ID:     arg.AccountID,
+		Amount: arg.Amount,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Hold, err = q.CreateHold(ctx, CreateHoldParams{
+		AccountID:   arg.AccountID,
+		ToAccountID: arg.ToAccountID,
+		Amount:      arg.Amount,
+		ExpiredAt:   arg.ExpiredAt,
+	})
+	return result, err
+}
+
+type CaptureHoldTxParams struct {
+	HoldID int64 `json:"hold_id"`
+	// Amount captures part of the hold, zero captures all of it
+	Amount int64 `json:"amount"`
+}
+
+type CaptureHoldTxResult struct {
+	Hold     Hold             `json:"hold"`
+	Transfer TransferTxResult `json:"transfer"`
+}
+
+// CaptureHoldTx turns the hold into a transfer to the hold's destination account,
+// the part of the hold that isn't captured is released
+func (s *SQLStore) CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (CaptureHoldTxResult, error) {
+	var result CaptureHoldTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		// the hold of a pending high-value transfer is only captured once the sender confirms it
+		_, err := q.GetTransferChallengeByHold(ctx, arg.HoldID)
+		if err == nil {
+			return ErrHoldStepUpRequired
+		}
+		if err != sql.ErrNoRows {
+			return err
+		}
+
+		result, err = captureHold(ctx, q, arg)
+		return err
+	})
+
+	return result, err
+}
+
+func captureHold(ctx context.Context, q *Queries, arg CaptureHoldTxParams) (CaptureHoldTxResult, error) {
+	var result CaptureHoldTxResult
+
+	hold, err := lockAuthorizedHold(ctx, q, arg.HoldID)
+	if err != nil {
+		return result, err
+	}
+
+	if time.Now().After(hold.ExpiredAt) {
+		return result, ErrHoldExpired
+	}
+
+	amount := arg.Amount
+	if amount == 0 {
+		amount = hold.Amount
+	}
+	if amount > hold.Amount {
+		return result, fmt.Errorf("%w: capture %d, hold %d", ErrHoldAmountExceeded, amount, hold.Amount)
+	}
+
+	// lock the accounts before releasing the hold, in the same order as any other transfer
+	_, _, err = lockAccounts(ctx, q, hold.AccountID, hold.ToAccountID)
+	if err != nil {
+		return result, err
+	}
+
+	_, err = q.AddAccountHeldAmount(ctx, AddAccountHeldAmountParams{
+		ID:     hold.AccountID,
+		Amount: -hold.Amount,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Transfer, err = transferMoney(ctx, q, CreateTransferParams{
+		FromAccountID: hold.AccountID,
+		ToAccountID:   hold.ToAccountID,
+		Amount:        amount,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Hold, err = q.UpdateHold(ctx, UpdateHoldParams{
+		ID:             hold.ID,
+		Status:         HoldStatusCaptured,
+		CapturedAmount: amount,
+		TransferID: sql.NullInt64{
+			Int64: result.Transfer.Transfer.ID,
+			Valid: true,
+		},
+	})
+	return result, err
+}
+
+type VoidHoldTxParams struct {
+	HoldID int64 `json:"hold_id"`
+}
+
+type VoidHoldTxResult struct {
+	Hold    Hold    `json:"hold"`
+	Account Account `json:"account"`
+}
+
+// VoidHoldTx releases the hold, giving the funds back to the available balance
+func (s *SQLStore) VoidHoldTx(ctx context.Context, arg VoidHoldTxParams) (VoidHoldTxResult, error) {
+	var result VoidHoldTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.Hold, result.Account, err = releaseHold(ctx, q, arg.HoldID, HoldStatusVoided)
+		return err
+	})
+
+	return result, err
+}
+
+// ExpireHoldTx releases a hold that has passed its expiration time
+func (s *SQLStore) ExpireHoldTx(ctx context.Context, holdID int64) (Hold, error) {
+	var hold Hold
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+		hold, _, err = releaseHold(ctx, q, holdID, HoldStatusExpired)
+		return err
+	})
+
+	return hold, err
+}
+
+func releaseHold(ctx context.Context, q *Queries, holdID int64, status string) (Hold, Account, error) {
+	hold, err := lockAuthorizedHold(ctx, q, holdID)
+	if err != nil {
+		return hold, Account{}, err
+	}
+
+	if status == HoldStatusExpired && time.Now().Before(hold.ExpiredAt) {
+		return hold, Account{}, fmt.Errorf("hold %d has not expired yet", hold.ID)
+	}
+
+	account, err := q.AddAccountHeldAmount(ctx, AddAccountHeldAmountParams{
+		ID:     hold.AccountID,
+		Amount: -hold.Amount,
+	})
+	if err != nil {
+		return hold, account, err
+	}
+
+	hold, err = q.UpdateHold(ctx, UpdateHoldParams{
+		ID:     hold.ID,
+		Status: status,
+	})
+	return hold, account, err
+}
+
+// lockAuthorizedHold locks the hold, which must not be captured, voided or expired yet
+func lockAuthorizedHold(ctx context.Context, q *Queries, holdID int64) (Hold, error) {
+	hold, err := q.GetHoldForUpdate(ctx, holdID)
+	if err != nil {
+		return hold, err
+	}
+
+	if hold.Status != HoldStatusAuthorized {
+		return hold, fmt.Errorf("%w: hold %d is %s", ErrHoldNotAuthorized, hold.ID, hold.Status)
+	}
+
+	return hold, nil
+}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// scopes the failed logins are counted by
+const (
+	LoginScopeUsername = "username"
+	LoginScopeClientIp = "client_ip"
+)
+
+type RecordLoginFailureTxParams struct {
+	Username string `json:"username"`
+	// ClientIp is not tracked when empty
+	ClientIp string `json:"client_ip"`
+	// WindowStart forgets the failures recorded before it
+	WindowStart time.Time `json:"window_start"`
+	// MaxUsernameFailures and MaxClientIpFailures are the failures after which the scope is locked
+	MaxUsernameFailures int32     `json:"max_username_failures"`
+	MaxClientIpFailures int32     `json:"max_client_ip_failures"`
+	LockedUntil         time.Time `json:"locked_until"`
+	// UnlockCode lifts the lock of the username early
+	UnlockCode string `json:"-"`
+	// LockOutboxMessages are the tasks to publish when this failure locks the username
+	LockOutboxMessages []CreateOutboxMessageParams `json:"-"`
+}
+
+type RecordLoginFailureTxResult struct {
+	UsernameAttempt LoginAttempt `json:"username_attempt"`
+	ClientIpAttempt LoginAttempt `json:"client_ip_attempt"`
+	// UsernameLocked is set when this failure locked the username
+	UsernameLocked bool `json:"username_locked"`
+}
+
+// RecordLoginFailureTx counts a failed login for the username and the client ip,
+// and locks each of them once it reaches its maximum. A scope already locked keeps
+// its lock, so concurrent failures send a single unlock email
+func (s *SQLStore) RecordLoginFailureTx(ctx context.Context, arg RecordLoginFailureTxParams) (RecordLoginFailureTxResult, error) {
+	var result RecordLoginFailureTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+		result = RecordLoginFailureTxResult{}
+
+		result.UsernameAttempt, result.UsernameLocked, err = recordLoginFailure(ctx, q, LoginScopeUsername, arg.Username,
+			arg.WindowStart, arg.MaxUsernameFailures, arg.LockedUntil, arg.UnlockCode)
+		if err != nil {
+			return err
+		}
+		if result.UsernameLocked {
+			if err = createOutboxMessages(ctx, q, arg.LockOutboxMessages); err != nil {
+				return err
+			}
+		}
+
+		if arg.ClientIp == "" {
+			return nil
+		}
+		result.ClientIpAttempt, _, err = recordLoginFailure(ctx, q, LoginScopeClientIp, arg.ClientIp,
+			arg.WindowStart, arg.MaxClientIpFailures, arg.LockedUntil, "")
+		return err
+	})
+
+	return result, err
+}
+
+// recordLoginFailure counts the failure and locks the scope when it reaches maxFailures and isn't locked yet,
+// locked reports whether this failure locked it
+func recordLoginFailure(ctx context.Context, q *Queries, scope, identifier string,
+	windowStart time.Time, maxFailures int32, lockedUntil time.Time, unlockCode string) (attempt LoginAttempt, locked bool, err error) {
+	attempt, err = q.RecordFailedLoginAttempt(ctx, RecordFailedLoginAttemptParams{
+		Scope:       scope,
+		Identifier:  identifier,
+		WindowStart: windowStart,
+	})
+	if err != nil {
+		return attempt, false, err
+	}
+
+	isLocked := attempt.LockedUntil.Valid && attempt.LockedUntil.Time.After(time.Now())
+	if attempt.FailedCount < maxFailures || isLocked {
+		return attempt, false, nil
+	}
+
+	attempt, err = q.LockLoginAttempt(ctx, LockLoginAttemptParams{
+		Scope:       scope,
+		Identifier:  identifier,
+		LockedUntil: sql.NullTime{Time: lockedUntil, Valid: true},
+		UnlockCode:  sql.NullString{String: unlockCode, Valid: unlockCode != ""},
+	})
+	return attempt, err == nil, err
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+type RelayOutboxTxParams struct {
+	Limit int32 `json:"limit"`
+	// Publish hands a message over to the task queue, it must be safe to call
+	// again for the same message in case the transaction fails to commit
+	Publish func(Outbox) error
+}
+
+type RelayOutboxTxResult struct {
+	Messages []Outbox `json:"messages"`
+}
+
+// RelayOutboxTx publishes the oldest unpublished outbox messages and marks them published.
+// Rows locked by another relay are skipped. If a message fails to publish, the ones
+// published before it are still committed and the error is returned with the result
+func (s *SQLStore) RelayOutboxTx(ctx context.Context, arg RelayOutboxTxParams) (RelayOutboxTxResult, error) {
+	var result RelayOutboxTxResult
+	var publishErr error
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		result = RelayOutboxTxResult{}
+
+		messages, err := q.ListUnpublishedOutboxMessages(ctx, arg.Limit)
+		if err != nil {
+			return err
+		}
+
+		for _, message := range messages {
+			if err := arg.Publish(message); err != nil {
+				publishErr = fmt.Errorf("failed to publish outbox message %d: %w", message.ID, err)
+				break
+			}
+
+			message, err = q.MarkOutboxMessagePublished(ctx, message.ID)
+			if err != nil {
+				return err
+			}
+			result.Messages = append(result.Messages, message)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, publishErr
+}
+
+// createOutboxMessages writes the messages in the caller's transaction,
+// so they are only published if it commits
+func createOutboxMessages(ctx context.Context, q *Queries, messages []CreateOutboxMessageParams) error {
+	for _, message := range messages {
+		if _, err := q.CreateOutboxMessage(ctx, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+const (
+	ReconciliationStatusBalanced = "balanced"
+	ReconciliationStatusMismatch = "mismatch"
+
+	DiscrepancyKindAccountBalance  = "account_balance"
+	DiscrepancyKindTransferEntries = "transfer_entries"
+)
+
+type ReconcileTxResult struct {
+	Run           ReconciliationRun           `json:"run"`
+	Discrepancies []ReconciliationDiscrepancy `json:"discrepancies"`
+}
+
+// ReconcileTx checks the ledger and records the run with what it found:
+// the balance of every account must equal the sum of its entries, and every
+// transfer must have exactly one debit entry on the from account and one
+// credit entry on the to account
+func (s *SQLStore) ReconcileTx(ctx context.Context) (ReconcileTxResult, error) {
+	var result ReconcileTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		accounts, err := q.ListAccountBalanceMismatches(ctx)
+		if err != nil {
+			return err
+		}
+
+		transfers, err := q.ListTransferEntryMismatches(ctx)
+		if err != nil {
+			return err
+		}
+
+		status := ReconciliationStatusBalanced
+		if len(accounts)+len(transfers) > 0 {
+			status = ReconciliationStatusMismatch
+		}
+		result.Run, err = q.CreateReconciliationRun(ctx, CreateReconciliationRunParams{
+			Status:           status,
+			DiscrepancyCount: int32(len(accounts) + len(transfers)),
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Discrepancies = []ReconciliationDiscrepancy{}
+		for _, account := range accounts {
+			discrepancy, err := q.CreateReconciliationDiscrepancy(ctx, CreateReconciliationDiscrepancyParams{
+				RunID:     result.Run.ID,
+				Kind:      DiscrepancyKindAccountBalance,
+				AccountID: sql.NullInt64{Int64: account.AccountID, Valid: true},
+				Expected:  account.EntriesTotal,
+				Actual:    account.Balance,
+				Details: fmt.Sprintf("balance %d differs from the sum of the entries %d by %d",
+					account.Balance, account.EntriesTotal, account.Balance-account.EntriesTotal),
+			})
+			if err != nil {
+				return err
+			}
+			result.Discrepancies = append(result.Discrepancies, discrepancy)
+		}
+
+		for _, transfer := range transfers {
+			discrepancy, err := q.CreateReconciliationDiscrepancy(ctx, CreateReconciliationDiscrepancyParams{
+				RunID:      result.Run.ID,
+				Kind:       DiscrepancyKindTransferEntries,
+				TransferID: sql.NullInt64{Int64: transfer.TransferID, Valid: true},
+				Expected:   2,
+				Actual:     transfer.FromEntryCount + transfer.ToEntryCount,
+				Details: fmt.Sprintf("%d entries linked, %d matching the debit and %d matching the credit",
+					transfer.EntryCount, transfer.FromEntryCount, transfer.ToEntryCount),
+			})
+			if err != nil {
+				return err
+			}
+			result.Discrepancies = append(result.Discrepancies, discrepancy)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type ResetPasswordTxParams struct {
+	ResetID        int64  `json:"reset_id"`
+	SecretCode     string `json:"secret_code"`
+	HashedPassword string `json:"-"`
+}
+
+// like verify emails, a reset code must never be used twice
+var resetPasswordTxOptions = txOptions{
+	name:       "reset_password",
+	isolation:  sql.LevelSerializable,
+	maxRetries: 3,
+}
+
+type ResetPasswordTxResult struct {
+	User          User          `json:"user"`
+	PasswordReset PasswordReset `json:"password_reset"`
+	// RevokedSessions is the number of sessions blocked with the old password
+	RevokedSessions int64 `json:"revoked_sessions"`
+}
+
+// ResetPasswordTx uses the reset code to set the new password, then blocks all the sessions
+// of the user and lifts the lock of too many failed logins, the email proves the user owns the account
+func (s *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error) {
+	var result ResetPasswordTxResult
+
+	err := s.execTxWithRetry(ctx, resetPasswordTxOptions, func(q *Queries) error {
+		var err error
+
+		result.PasswordReset, err = q.UpdatePasswordReset(ctx, UpdatePasswordResetParams{
+			IsUsed: sql.NullBool{
+				Bool:  true,
+				Valid: true,
+			},
+			ResetID:    arg.ResetID,
+			SecretCode: arg.SecretCode,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.UpdateUser(ctx, UpdateUserParams{
+			Username: result.PasswordReset.Username,
+			HashedPassword: sql.NullString{
+				String: arg.HashedPassword,
+				Valid:  true,
+			},
+			PasswordChangedAt: sql.NullTime{
+				Time:  time.Now(),
+				Valid: true,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		result.RevokedSessions, err = q.BlockUserSessions(ctx, result.User.Username)
+		if err != nil {
+			return err
+		}
+
+		return q.DeleteLoginAttempt(ctx, DeleteLoginAttemptParams{
+			Scope:      LoginScopeUsername,
+			Identifier: result.User.Username,
+		})
+	})
+
+	return result, err
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+type ReverseTransferTxParams struct {
+	TransferID int64 `json:"transfer_id"`
+	// Amount refunds part of the transfer, zero refunds all that is not reversed yet
+	Amount int64 `json:"amount"`
+}
+
+type ReverseTransferTxResult struct {
+	// Transfer is the original transfer with its updated reversed amount
+	Transfer Transfer         `json:"transfer"`
+	Reversal TransferTxResult `json:"reversal"`
+}
+
+// ReverseTransferTx sends the amount back from the receiving account of the transfer,
+// the transfer may be refunded several times until its whole amount has been reversed
+func (s *SQLStore) ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (ReverseTransferTxResult, error) {
+	var result ReverseTransferTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		// lock the transfer first, so concurrent reversals of it run one after another
+		transfer, err := q.GetTransferForUpdate(ctx, arg.TransferID)
+		if err != nil {
+			return err
+		}
+
+		if transfer.ReversedTransferID.Valid {
+			return ErrTransferNotReversible
+		}
+		// the rate has moved since, refunding either amount would leave one side short
+		if transfer.ExchangeRate.Valid {
+			return ErrCrossCurrencyReversal
+		}
+
+		remaining := transfer.Amount - transfer.ReversedAmount
+		if remaining == 0 {
+			return ErrTransferFullyReversed
+		}
+
+		amount := arg.Amount
+		if amount == 0 {
+			amount = remaining
+		}
+		if amount > remaining {
+			return ErrReversalAmountExceeded
+		}
+
+		result.Reversal, err = transferMoney(ctx, q, CreateTransferParams{
+			FromAccountID: transfer.ToAccountID,
+			ToAccountID:   transfer.FromAccountID,
+			Amount:        amount,
+			ReversedTransferID: sql.NullInt64{
+				Int64: transfer.ID,
+				Valid: true,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Transfer, err = q.AddTransferReversedAmount(ctx, AddTransferReversedAmountParams{
+			ID:     transfer.ID,
+			Amount: amount,
+		})
+		return err
+	})
+
+	return result, err
+}
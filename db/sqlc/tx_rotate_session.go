@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type RotateSessionTxParams struct {
+	// SessionID is the session of the refresh token being exchanged
+	SessionID uuid.UUID `json:"session_id"`
+	// NewSession is the session of the new refresh token, it joins the family of the old one
+	NewSession CreateSessionParams `json:"new_session"`
+	// ReuseOutboxMessages are the tasks to publish when the refresh token
+	// turns out to have been exchanged already
+	ReuseOutboxMessages []CreateOutboxMessageParams `json:"-"`
+}
+
+type RotateSessionTxResult struct {
+	Session Session `json:"session"`
+	// BlockedCount is the number of sessions blocked when a reuse was detected
+	BlockedCount int64 `json:"blocked_count"`
+}
+
+// RotateSessionTx replaces the session with a new one of the same family.
+// A session rotated already means its refresh token was stolen or replayed,
+// then the whole family is blocked, the transaction is committed so the block
+// sticks, and ErrRefreshTokenReused is returned
+func (s *SQLStore) RotateSessionTx(ctx context.Context, arg RotateSessionTxParams) (RotateSessionTxResult, error) {
+	var result RotateSessionTxResult
+	var reused bool
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		result = RotateSessionTxResult{}
+		reused = false
+
+		session, err := q.GetSessionForUpdate(ctx, arg.SessionID)
+		if err != nil {
+			return err
+		}
+		if session.IsBlocked {
+			return ErrSessionBlocked
+		}
+
+		if session.RotatedAt.Valid {
+			reused = true
+			result.BlockedCount, err = q.BlockSessionFamily(ctx, session.FamilyID)
+			if err != nil {
+				return err
+			}
+			return createOutboxMessages(ctx, q, arg.ReuseOutboxMessages)
+		}
+
+		_, err = q.RotateSession(ctx, session.ID)
+		if err != nil {
+			return err
+		}
+
+		newSession := arg.NewSession
+		newSession.FamilyID = session.FamilyID
+		result.Session, err = q.CreateSession(ctx, newSession)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if reused {
+		return result, ErrRefreshTokenReused
+	}
+	return result, nil
+}
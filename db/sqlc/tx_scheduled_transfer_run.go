@@ -0,0 +1,34 @@
+package db
+
+import (
+	"context"
+)
+
+type CreateScheduledTransferRunTxParams struct {
+	CreateScheduledTransferRunParams CreateScheduledTransferRunParams
+	// OutboxMessages are published once the run is recorded, like the email telling the owner it failed
+	OutboxMessages []CreateOutboxMessageParams
+}
+
+type CreateScheduledTransferRunTxResult struct {
+	ScheduledTransferRun ScheduledTransferRun `json:"scheduled_transfer_run"`
+}
+
+// CreateScheduledTransferRunTx records the result of a run along with the tasks it triggers,
+// so a run is never recorded without them nor are they sent for a run which wasn't recorded
+func (s *SQLStore) CreateScheduledTransferRunTx(ctx context.Context, arg CreateScheduledTransferRunTxParams) (CreateScheduledTransferRunTxResult, error) {
+	var result CreateScheduledTransferRunTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.ScheduledTransferRun, err = q.CreateScheduledTransferRun(ctx, arg.CreateScheduledTransferRunParams)
+		if err != nil {
+			return err
+		}
+
+		return createOutboxMessages(ctx, q, arg.OutboxMessages)
+	})
+
+	return result, err
+}
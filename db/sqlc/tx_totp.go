@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type ConfirmTotpTxParams struct {
+	Username string `json:"username"`
+	// Step is the time step of the code confirming the enrollment
+	Step int64 `json:"step"`
+	// HashedRecoveryCodes replace the recovery codes of the user
+	HashedRecoveryCodes []string `json:"-"`
+}
+
+type ConfirmTotpTxResult struct {
+	UserTotp UserTotp `json:"user_totp"`
+}
+
+// ConfirmTotpTx enables two-factor authentication for the user, with a new set of recovery codes
+func (s *SQLStore) ConfirmTotpTx(ctx context.Context, arg ConfirmTotpTxParams) (ConfirmTotpTxResult, error) {
+	var result ConfirmTotpTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.UserTotp, err = q.ConfirmUserTotp(ctx, ConfirmUserTotpParams{
+			Username:     arg.Username,
+			LastUsedStep: arg.Step,
+		})
+		if err != nil {
+			return err
+		}
+
+		return replaceRecoveryCodes(ctx, q, arg.Username, arg.HashedRecoveryCodes)
+	})
+
+	return result, err
+}
+
+type DisableTotpTxParams struct {
+	Username     string       `json:"username"`
+	SecondFactor SecondFactor `json:"second_factor"`
+}
+
+// DisableTotpTx turns two-factor authentication off with a code of the user,
+// the recovery codes are deleted with the secret
+func (s *SQLStore) DisableTotpTx(ctx context.Context, arg DisableTotpTxParams) error {
+	return s.execTx(ctx, func(q *Queries) error {
+		if err := useSecondFactor(ctx, q, arg.Username, arg.SecondFactor); err != nil {
+			return err
+		}
+		if err := q.DeleteUserTotp(ctx, arg.Username); err != nil {
+			return err
+		}
+		return q.DeleteRecoveryCodes(ctx, arg.Username)
+	})
+}
+
+// SecondFactor is a code of the user checked against its totp secret or recovery codes
+type SecondFactor struct {
+	// Step is the time step a totp code matched, it must be after the last step used
+	Step int64 `json:"step"`
+	// HashedRecoveryCode is set instead of Step for a recovery code
+	HashedRecoveryCode string `json:"-"`
+}
+
+// useSecondFactor marks the code as used, so it can't be replayed.
+// sql.ErrNoRows is returned when the code was used already or isn't a recovery code of the user
+func useSecondFactor(ctx context.Context, q *Queries, username string, factor SecondFactor) error {
+	if factor.HashedRecoveryCode != "" {
+		_, err := q.UseRecoveryCode(ctx, UseRecoveryCodeParams{
+			Username:   username,
+			HashedCode: factor.HashedRecoveryCode,
+		})
+		return err
+	}
+
+	_, err := q.UseUserTotpStep(ctx, UseUserTotpStepParams{
+		Username:     username,
+		LastUsedStep: factor.Step,
+	})
+	return err
+}
+
+func replaceRecoveryCodes(ctx context.Context, q *Queries, username string, hashedCodes []string) error {
+	if err := q.DeleteRecoveryCodes(ctx, username); err != nil {
+		return err
+	}
+	for _, hashedCode := range hashedCodes {
+		_, err := q.CreateRecoveryCode(ctx, CreateRecoveryCodeParams{
+			Username:   username,
+			HashedCode: hashedCode,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type CompleteLoginChallengeTxParams struct {
+	ChallengeID  uuid.UUID    `json:"challenge_id"`
+	Username     string       `json:"username"`
+	SecondFactor SecondFactor `json:"second_factor"`
+}
+
+type CompleteLoginChallengeTxResult struct {
+	LoginChallenge LoginChallenge `json:"login_challenge"`
+}
+
+// CompleteLoginChallengeTx uses up the challenge and the second factor answering it.
+// sql.ErrNoRows is returned when the challenge is used or expired, or the code has been used already
+func (s *SQLStore) CompleteLoginChallengeTx(ctx context.Context, arg CompleteLoginChallengeTxParams) (CompleteLoginChallengeTxResult, error) {
+	var result CompleteLoginChallengeTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.LoginChallenge, err = q.UseLoginChallenge(ctx, arg.ChallengeID)
+		if err != nil {
+			return err
+		}
+
+		return useSecondFactor(ctx, q, arg.Username, arg.SecondFactor)
+	})
+
+	return result, err
+}
@@ -1,11 +1,26 @@
 package db
 
-import "context"
+import (
+	"context"
+	"database/sql"
+)
 
 type TransferTxParams struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	Amount        int64 `json:"amount"`
+	// IdempotencyKey is optional, a retried transfer with the same key returns the original result.
+	// Keys are scoped to Username, the user making the transfer
+	IdempotencyKey string `json:"idempotency_key"`
+	Username       string `json:"username"`
+}
+
+// transfers lock their rows explicitly, a deadlock with another transaction
+// locking the same rows in a different order is retried
+var transferTxOptions = txOptions{
+	name:       "transfer",
+	isolation:  sql.LevelReadCommitted,
+	maxRetries: 3,
 }
 
 type TransferTxResult struct {
@@ -18,16 +33,28 @@ type TransferTxResult struct {
 
 func (s *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
-
-	err := s.execTx(ctx, func(q *Queries) error {
-
-		if arg.FromAccountID > arg.ToAccountID {
-			arg.FromAccountID, arg.ToAccountID = arg.ToAccountID, arg.FromAccountID
-			arg.Amount = -arg.Amount
+	requestHash := arg.requestHash()
+
+	err := s.execTxWithRetry(ctx, transferTxOptions, func(q *Queries) error {
+
+		if arg.IdempotencyKey != "" {
+			// the insert waits for a concurrent transaction holding the same key,
+			// and returns no row once that one has committed
+			_, err := q.CreateIdempotencyKey(ctx, CreateIdempotencyKeyParams{
+				Username:    arg.Username,
+				Key:         arg.IdempotencyKey,
+				RequestHash: requestHash,
+			})
+			if err == sql.ErrNoRows {
+				return replayTransfer(ctx, q, arg.Username, arg.IdempotencyKey, requestHash, &result)
+			}
+			if err != nil {
+				return err
+			}
 		}
 
 		var err error
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		result, err = transferMoney(ctx, q, CreateTransferParams{
 			FromAccountID: arg.FromAccountID,
 			ToAccountID:   arg.ToAccountID,
 			Amount:        arg.Amount,
@@ -36,71 +63,105 @@ func (s *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (Transf
 			return err
 		}
 
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		})
-		if err != nil {
-			return err
+		if arg.IdempotencyKey != "" {
+			return saveTransferResult(ctx, q, arg.Username, arg.IdempotencyKey, result)
 		}
 
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
+		return nil
+	})
 
-		// version 1
-		// result.FromAccount, err = q.GetAccountForUpdate(ctx, arg.FromAccountID)
-		// if err != nil {
-		// 	return err
-		// }
-
-		// result.ToAccount, err = q.GetAccountForUpdate(ctx, arg.ToAccountID)
-		// if err != nil {
-		// 	return err
-		// }
-
-		// result.FromAccount.Balance -= arg.Amount
-		// result.ToAccount.Balance += arg.Amount
-
-		// result.FromAccount, err = q.UpdateAccount(ctx, UpdateAccountParams{
-		// 	ID:      result.FromAccount.ID,
-		// 	Balance: result.FromAccount.Balance,
-		// })
-		// if err != nil {
-		// 	return err
-		// }
-
-		// result.ToAccount, err = q.UpdateAccount(ctx, UpdateAccountParams{
-		// 	ID:      result.ToAccount.ID,
-		// 	Balance: result.ToAccount.Balance,
-		// })
-		// if err != nil {
-		// 	return err
-		// }
-
-		// version 2
-		result.FromAccount, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-			ID:     arg.FromAccountID,
-			Amount: -arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
+	return result, err
+}
 
-		result.ToAccount, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-			ID:     arg.ToAccountID,
-			Amount: arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
+// transferMoney moves the amount between two active accounts, writing the transfer record,
+// an entry for each account linked to it and the new balances, the to account is credited
+// with ToAmount when the accounts have different currencies
+func transferMoney(ctx context.Context, q *Queries, arg CreateTransferParams) (TransferTxResult, error) {
+	var result TransferTxResult
 
-		return nil
+	// a same-currency transfer credits the amount it debits
+	if arg.ToAmount == 0 {
+		arg.ToAmount = arg.Amount
+	}
+	fromAccountID, toAccountID, amount := arg.FromAccountID, arg.ToAccountID, arg.Amount
+
+	// lock both accounts in a consistent order to avoid deadlock
+	fromAccount, toAccount, err := lockAccounts(ctx, q, fromAccountID, toAccountID)
+	if err != nil {
+		return result, err
+	}
+
+	// frozen and closed accounts can neither send nor receive
+	if err := checkAccountActive(fromAccount); err != nil {
+		return result, err
+	}
+	if err := checkAccountActive(toAccount); err != nil {
+		return result, err
+	}
+
+	if fromAccount.AvailableBalance+fromAccount.OverdraftLimit < amount {
+		return result, ErrInsufficientFunds
+	}
+
+	result.Transfer, err = q.CreateTransfer(ctx, arg)
+	if err != nil {
+		return result, err
+	}
+	transferID := sql.NullInt64{Int64: result.Transfer.ID, Valid: true}
+
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:  fromAccountID,
+		Amount:     -amount,
+		TransferID: transferID,
 	})
+	if err != nil {
+		return result, err
+	}
+
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:  toAccountID,
+		Amount:     arg.ToAmount,
+		TransferID: transferID,
+	})
+	if err != nil {
+		return result, err
+	}
 
-	return result, err
+	result.FromAccount, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+		ID:     fromAccountID,
+		Amount: -amount,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.ToAccount, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+		ID:     toAccountID,
+		Amount: arg.ToAmount,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// lockAccounts locks the two accounts by ascending id, so concurrent transfers
+// in opposite directions always acquire the locks in the same order
+func lockAccounts(ctx context.Context, q *Queries, accountID1 int64, accountID2 int64) (account1 Account, account2 Account, err error) {
+	if accountID1 < accountID2 {
+		account1, err = q.GetAccountForUpdate(ctx, accountID1)
+		if err != nil {
+			return
+		}
+		account2, err = q.GetAccountForUpdate(ctx, accountID2)
+		return
+	}
+
+	account2, err = q.GetAccountForUpdate(ctx, accountID2)
+	if err != nil {
+		return
+	}
+	account1, err = q.GetAccountForUpdate(ctx, accountID1)
+	return
 }
@@ -0,0 +1,220 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TransferChallengeMethodTotp  = "totp"
+	TransferChallengeMethodEmail = "email"
+)
+
+type CreatePendingTransferTxParams struct {
+	ChallengeID   uuid.UUID `json:"challenge_id"`
+	Username      string    `json:"username"`
+	FromAccountID int64     `json:"from_account_id"`
+	ToAccountID   int64     `json:"to_account_id"`
+	Amount        int64     `json:"amount"`
+	// IdempotencyKey is optional, a retry of the user with the same key returns the pending transfer,
+	// or the transfer once it has been confirmed
+	IdempotencyKey string `json:"idempotency_key"`
+	Method         string `json:"method"`
+	// HashedCode is the hash of the code emailed for the email method
+	HashedCode string    `json:"-"`
+	ExpiredAt  time.Time `json:"expired_at"`
+	// OutboxMessages are published with the new pending transfer, not when a retry returns it again
+	OutboxMessages []CreateOutboxMessageParams `json:"-"`
+}
+
+type CreatePendingTransferTxResult struct {
+	TransferChallenge TransferChallenge `json:"transfer_challenge"`
+	Hold              Hold              `json:"hold"`
+	FromAccount       Account           `json:"from_account"`
+	// Confirmed is set when the idempotency key belongs to a pending transfer confirmed already,
+	// Transfer is then its result and no challenge is returned
+	Confirmed bool             `json:"confirmed"`
+	Transfer  TransferTxResult `json:"transfer"`
+}
+
+// errPendingTransferKeyTaken rolls back a pending transfer whose idempotency key has been taken
+// by a concurrent request, the transaction is then run again to replay the pending transfer of that request
+var errPendingTransferKeyTaken = errors.New("idempotency key taken by a concurrent pending transfer")
+
+// CreatePendingTransferTx holds the amount of a transfer which waits for the sender to confirm it
+// with a second factor. The hold expires with the challenge, releasing the funds
+func (s *SQLStore) CreatePendingTransferTx(ctx context.Context, arg CreatePendingTransferTxParams) (CreatePendingTransferTxResult, error) {
+	var result CreatePendingTransferTxResult
+	transferArg := TransferTxParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		Username:      arg.Username,
+	}
+	idempotencyKey := sql.NullString{String: arg.IdempotencyKey, Valid: arg.IdempotencyKey != ""}
+
+	createPendingTransfer := func(q *Queries) error {
+		result = CreatePendingTransferTxResult{}
+
+		if idempotencyKey.Valid {
+			replayed, err := replayPendingTransfer(ctx, q, idempotencyKey, transferArg, &result)
+			if replayed || err != nil {
+				return err
+			}
+		}
+
+		hold, err := authorizeHold(ctx, q, AuthorizeHoldTxParams{
+			AccountID:   arg.FromAccountID,
+			ToAccountID: arg.ToAccountID,
+			Amount:      arg.Amount,
+			ExpiredAt:   arg.ExpiredAt,
+		})
+		if err != nil {
+			return err
+		}
+		result.Hold = hold.Hold
+		result.FromAccount = hold.Account
+
+		result.TransferChallenge, err = q.CreateTransferChallenge(ctx, CreateTransferChallengeParams{
+			ID:             arg.ChallengeID,
+			HoldID:         hold.Hold.ID,
+			Username:       arg.Username,
+			Method:         arg.Method,
+			HashedCode:     arg.HashedCode,
+			IdempotencyKey: idempotencyKey,
+			ExpiredAt:      arg.ExpiredAt,
+		})
+		if err == sql.ErrNoRows {
+			// the insert waits for a concurrent transaction holding the same key,
+			// and returns no row once that one has committed
+			return errPendingTransferKeyTaken
+		}
+		if err != nil {
+			return err
+		}
+
+		return createOutboxMessages(ctx, q, arg.OutboxMessages)
+	}
+
+	err := s.execTxWithRetry(ctx, transferTxOptions, createPendingTransfer)
+	if err == errPendingTransferKeyTaken {
+		err = s.execTxWithRetry(ctx, transferTxOptions, createPendingTransfer)
+	}
+
+	return result, err
+}
+
+// replayPendingTransfer loads what a used idempotency key stands for: the transfer once it has
+// been confirmed, or else the pending transfer. It fails with ErrIdempotencyKeyConflict if the key
+// was used for another request, and replays nothing when the key is new
+func replayPendingTransfer(ctx context.Context, q *Queries, key sql.NullString, arg TransferTxParams, result *CreatePendingTransferTxResult) (bool, error) {
+	requestHash := arg.requestHash()
+
+	err := replayTransfer(ctx, q, arg.Username, key.String, requestHash, &result.Transfer)
+	if err == nil {
+		result.Confirmed = true
+		return true, nil
+	}
+	if err != sql.ErrNoRows {
+		return false, err
+	}
+
+	result.TransferChallenge, err = q.GetTransferChallengeByIdempotencyKey(ctx, GetTransferChallengeByIdempotencyKeyParams{
+		Username:       arg.Username,
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	result.Hold, err = q.GetHold(ctx, result.TransferChallenge.HoldID)
+	if err != nil {
+		return false, err
+	}
+	pendingTransfer := TransferTxParams{
+		FromAccountID: result.Hold.AccountID,
+		ToAccountID:   result.Hold.ToAccountID,
+		Amount:        result.Hold.Amount,
+	}
+	if pendingTransfer.requestHash() != requestHash {
+		return false, ErrIdempotencyKeyConflict
+	}
+
+	result.FromAccount, err = q.GetAccount(ctx, result.Hold.AccountID)
+	return true, err
+}
+
+type ConfirmTransferTxParams struct {
+	ChallengeID uuid.UUID `json:"challenge_id"`
+	Username    string    `json:"username"`
+	// SecondFactor is the totp code confirming the transfer, emailed codes are checked by the caller
+	SecondFactor SecondFactor `json:"second_factor"`
+}
+
+type ConfirmTransferTxResult struct {
+	TransferChallenge TransferChallenge `json:"transfer_challenge"`
+	Hold              Hold              `json:"hold"`
+	Transfer          TransferTxResult  `json:"transfer"`
+}
+
+// ConfirmTransferTx uses up the challenge and captures the hold of the pending transfer.
+// sql.ErrNoRows is returned when the challenge is used or expired, or the totp code has been used already
+func (s *SQLStore) ConfirmTransferTx(ctx context.Context, arg ConfirmTransferTxParams) (ConfirmTransferTxResult, error) {
+	var result ConfirmTransferTxResult
+
+	err := s.execTxWithRetry(ctx, transferTxOptions, func(q *Queries) error {
+		var err error
+
+		result.TransferChallenge, err = q.UseTransferChallenge(ctx, arg.ChallengeID)
+		if err != nil {
+			return err
+		}
+		if result.TransferChallenge.Username != arg.Username {
+			return sql.ErrNoRows
+		}
+
+		if result.TransferChallenge.Method == TransferChallengeMethodTotp {
+			if err := useSecondFactor(ctx, q, arg.Username, arg.SecondFactor); err != nil {
+				return err
+			}
+		}
+
+		captured, err := captureHold(ctx, q, CaptureHoldTxParams{HoldID: result.TransferChallenge.HoldID})
+		if err != nil {
+			return err
+		}
+		result.Hold = captured.Hold
+		result.Transfer = captured.Transfer
+
+		// the key was saved with the pending transfer, it now returns the transfer made
+		key := result.TransferChallenge.IdempotencyKey
+		if !key.Valid {
+			return nil
+		}
+		_, err = q.CreateIdempotencyKey(ctx, CreateIdempotencyKeyParams{
+			Username: arg.Username,
+			Key:      key.String,
+			RequestHash: TransferTxParams{
+				FromAccountID: result.Hold.AccountID,
+				ToAccountID:   result.Hold.ToAccountID,
+				Amount:        result.Hold.Amount,
+			}.requestHash(),
+		})
+		if err == sql.ErrNoRows {
+			return ErrIdempotencyKeyConflict
+		}
+		if err != nil {
+			return err
+		}
+		return saveTransferResult(ctx, q, arg.Username, key.String, result.Transfer)
+	})
+
+	return result, err
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+type UpdateUserTxParams struct {
+	UpdateUserParams UpdateUserParams `json:"update_user_params"`
+	// ChangeEmail replaces the pending email of the user with PendingEmail,
+	// a null one cancels the pending change
+	ChangeEmail  bool           `json:"change_email"`
+	PendingEmail sql.NullString `json:"pending_email"`
+	// OutboxMessages are the tasks to publish once the user has been updated
+	OutboxMessages []CreateOutboxMessageParams `json:"-"`
+}
+
+type UpdateUserTxResult struct {
+	User User `json:"user"`
+}
+
+// UpdateUserTx updates the user. A new email is only stored as pending,
+// VerifyEmailTx applies it once the user has confirmed it
+func (s *SQLStore) UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error) {
+	var result UpdateUserTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.User, err = q.UpdateUser(ctx, arg.UpdateUserParams)
+		if err != nil {
+			return err
+		}
+
+		if arg.ChangeEmail {
+			result.User, err = q.SetUserPendingEmail(ctx, SetUserPendingEmailParams{
+				Username:     arg.UpdateUserParams.Username,
+				PendingEmail: arg.PendingEmail,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return createOutboxMessages(ctx, q, arg.OutboxMessages)
+	})
+
+	return result, err
+}
@@ -10,15 +10,25 @@ type VerifyEmailTxParams struct {
 	SecretCode string
 }
 
+// a secret code must never be used twice, so concurrent verifications are
+// serialized and the ones losing the race retry and find the code used
+var verifyEmailTxOptions = txOptions{
+	name:       "verify_email",
+	isolation:  sql.LevelSerializable,
+	maxRetries: 3,
+}
+
 type VerifyEmailTxResult struct {
 	User        User
 	VerifyEmail VerifyEmail
 }
 
+// VerifyEmailTx marks the email of the user verified, or applies the pending email change
+// the code was sent for. A code sent to an address the user no longer has or asks for is outdated
 func (s *SQLStore) VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error) {
 	var result VerifyEmailTxResult
 
-	err := s.execTx(ctx, func(q *Queries) error {
+	err := s.execTxWithRetry(ctx, verifyEmailTxOptions, func(q *Queries) error {
 		var err error
 
 		result.VerifyEmail, err = q.UpdateVerifyEmail(ctx, UpdateVerifyEmailParams{
@@ -33,23 +43,31 @@ func (s *SQLStore) VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (
 			return err
 		}
 
-		result.User, err = q.UpdateUser(ctx, UpdateUserParams{
-			Username: result.VerifyEmail.Username,
-			IsEmailVerified: sql.NullBool{
-				Bool:  true,
-				Valid: true,
-			},
-		})
+		user, err := q.GetUser(ctx, result.VerifyEmail.Username)
 		if err != nil {
 			return err
 		}
 
-		return nil
+		switch {
+		case result.VerifyEmail.Email == user.Email:
+			result.User, err = q.UpdateUser(ctx, UpdateUserParams{
+				Username: user.Username,
+				IsEmailVerified: sql.NullBool{
+					Bool:  true,
+					Valid: true,
+				},
+			})
+		case user.PendingEmail.Valid && result.VerifyEmail.Email == user.PendingEmail.String:
+			// the new address is confirmed, it replaces the email of the user
+			result.User, err = q.ApplyUserPendingEmail(ctx, ApplyUserPendingEmailParams{
+				Username:     user.Username,
+				PendingEmail: user.PendingEmail,
+			})
+		default:
+			return ErrVerifyEmailOutdated
+		}
+		return err
 	})
 
-	if err != nil {
-		return result, err
-	}
-
 	return result, err
 }
@@ -10,6 +10,40 @@ import (
 	"database/sql"
 )
 
+const applyUserPendingEmail = `-- name: ApplyUserPendingEmail :one
+UPDATE users
+SET
+  email = pending_email,
+  pending_email = NULL,
+  is_email_verified = TRUE
+WHERE username = $1
+  AND pending_email = $2
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, is_frozen, pending_email
+`
+
+type ApplyUserPendingEmailParams struct {
+	Username     string         `json:"username"`
+	PendingEmail sql.NullString `json:"pending_email"`
+}
+
+func (q *Queries) ApplyUserPendingEmail(ctx context.Context, arg ApplyUserPendingEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, applyUserPendingEmail, arg.Username, arg.PendingEmail)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.IsFrozen,
+		&i.PendingEmail,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
   username, 
@@ -19,7 +53,7 @@ INSERT INTO users (
 ) VALUES (
   $1, $2, $3, $4
 )
-RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, is_frozen, pending_email
 `
 
 type CreateUserParams struct {
@@ -45,12 +79,15 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.IsEmailVerified,
+		&i.Role,
+		&i.IsFrozen,
+		&i.PendingEmail,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified FROM users
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, is_frozen, pending_email FROM users
 WHERE username = $1 LIMIT 1
 `
 
@@ -65,6 +102,112 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.IsEmailVerified,
+		&i.Role,
+		&i.IsFrozen,
+		&i.PendingEmail,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, is_frozen, pending_email FROM users
+WHERE email = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.IsFrozen,
+		&i.PendingEmail,
+	)
+	return i, err
+}
+
+const searchUsers = `-- name: SearchUsers :many
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, is_frozen, pending_email FROM users
+WHERE username ILIKE $1
+  OR email ILIKE $1
+  OR full_name ILIKE $1
+ORDER BY username
+LIMIT $2
+OFFSET $3
+`
+
+type SearchUsersParams struct {
+	Pattern string `json:"pattern"`
+	Limit   int32  `json:"limit"`
+	Offset  int32  `json:"offset"`
+}
+
+func (q *Queries) SearchUsers(ctx context.Context, arg SearchUsersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, searchUsers, arg.Pattern, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.Username,
+			&i.HashedPassword,
+			&i.FullName,
+			&i.Email,
+			&i.PasswordChangedAt,
+			&i.CreatedAt,
+			&i.IsEmailVerified,
+			&i.Role,
+			&i.IsFrozen,
+			&i.PendingEmail,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setUserPendingEmail = `-- name: SetUserPendingEmail :one
+UPDATE users
+SET pending_email = $2
+WHERE username = $1
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, is_frozen, pending_email
+`
+
+type SetUserPendingEmailParams struct {
+	Username     string         `json:"username"`
+	PendingEmail sql.NullString `json:"pending_email"`
+}
+
+func (q *Queries) SetUserPendingEmail(ctx context.Context, arg SetUserPendingEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserPendingEmail, arg.Username, arg.PendingEmail)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.IsFrozen,
+		&i.PendingEmail,
 	)
 	return i, err
 }
@@ -76,10 +219,12 @@ SET
   full_name = COALESCE($2, full_name),
   email = COALESCE($3, email),
   password_changed_at = COALESCE($4, password_changed_at),
-  is_email_verified = COALESCE($5, is_email_verified)
+  is_email_verified = COALESCE($5, is_email_verified),
+  role = COALESCE($6, role),
+  is_frozen = COALESCE($7, is_frozen)
 WHERE 
-  username = $6
-RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified
+  username = $8
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, is_frozen, pending_email
 `
 
 type UpdateUserParams struct {
@@ -88,6 +233,8 @@ type UpdateUserParams struct {
 	Email             sql.NullString `json:"email"`
 	PasswordChangedAt sql.NullTime   `json:"password_changed_at"`
 	IsEmailVerified   sql.NullBool   `json:"is_email_verified"`
+	Role              sql.NullString `json:"role"`
+	IsFrozen          sql.NullBool   `json:"is_frozen"`
 	Username          string         `json:"username"`
 }
 
@@ -98,6 +245,8 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		arg.Email,
 		arg.PasswordChangedAt,
 		arg.IsEmailVerified,
+		arg.Role,
+		arg.IsFrozen,
 		arg.Username,
 	)
 	var i User
@@ -109,6 +258,9 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.IsEmailVerified,
+		&i.Role,
+		&i.IsFrozen,
+		&i.PendingEmail,
 	)
 	return i, err
 }
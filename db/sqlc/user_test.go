@@ -32,6 +32,8 @@ func createRandomUser(t *testing.T) User {
 
 	require.True(t, user.PasswordChangedAt.IsZero())
 	require.NotZero(t, user.CreatedAt)
+	require.Equal(t, util.DepositorRole, user.Role)
+	require.False(t, user.IsFrozen)
 
 	return user
 }
@@ -161,3 +163,138 @@ func TestUpdateAllField(t *testing.T) {
 	require.Equal(t, newEmail, updatedUser.Email)
 	require.Equal(t, newFullName, updatedUser.FullName)
 }
+
+func TestFreezeUserTx(t *testing.T) {
+	store := NewSQLStore(testDB)
+	user := createRandomUser(t)
+	session := createRandomSession(t, user.Username, time.Now().Add(time.Hour))
+
+	result, err := store.FreezeUserTx(context.Background(), FreezeUserTxParams{
+		Username: user.Username,
+		Frozen:   true,
+	})
+	require.NoError(t, err)
+	require.True(t, result.User.IsFrozen)
+	require.Equal(t, int64(1), result.BlockedSessions)
+
+	blocked, err := store.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, blocked.IsBlocked)
+
+	result, err = store.FreezeUserTx(context.Background(), FreezeUserTxParams{
+		Username: user.Username,
+		Frozen:   false,
+	})
+	require.NoError(t, err)
+	require.False(t, result.User.IsFrozen)
+	require.Zero(t, result.BlockedSessions)
+
+	_, err = store.FreezeUserTx(context.Background(), FreezeUserTxParams{
+		Username: util.RandomOwner(),
+		Frozen:   true,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestResetPasswordTx(t *testing.T) {
+	store := NewSQLStore(testDB)
+	user := createRandomUser(t)
+	session := createRandomSession(t, user.Username, time.Now().Add(time.Hour))
+
+	passwordReset, err := store.CreatePasswordReset(context.Background(), CreatePasswordResetParams{
+		Username:   user.Username,
+		SecretCode: util.RandomString(32),
+	})
+	require.NoError(t, err)
+	require.False(t, passwordReset.IsUsed)
+
+	hashedPassword, err := util.HashPassword(util.RandomString(8))
+	require.NoError(t, err)
+
+	arg := ResetPasswordTxParams{
+		ResetID:        passwordReset.ID,
+		SecretCode:     passwordReset.SecretCode,
+		HashedPassword: hashedPassword,
+	}
+	result, err := store.ResetPasswordTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.PasswordReset.IsUsed)
+	require.Equal(t, hashedPassword, result.User.HashedPassword)
+	require.True(t, result.User.PasswordChangedAt.After(user.PasswordChangedAt))
+	require.Equal(t, int64(1), result.RevokedSessions)
+
+	blocked, err := store.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, blocked.IsBlocked)
+
+	// the code can only be used once
+	_, err = store.ResetPasswordTx(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestChangeEmail(t *testing.T) {
+	store := NewSQLStore(testDB)
+	user := createRandomUser(t)
+	newEmail := util.RandomEmail()
+
+	oldVerifyEmail, err := store.CreateVerifyEmail(context.Background(), CreateVerifyEmailParams{
+		Username:   user.Username,
+		Email:      user.Email,
+		SecretCode: util.RandomString(32),
+	})
+	require.NoError(t, err)
+
+	message := randomOutboxMessage()
+	result, err := store.UpdateUserTx(context.Background(), UpdateUserTxParams{
+		UpdateUserParams: UpdateUserParams{Username: user.Username},
+		ChangeEmail:      true,
+		PendingEmail:     sql.NullString{String: newEmail, Valid: true},
+		OutboxMessages:   []CreateOutboxMessageParams{message},
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Email, result.User.Email)
+	require.Equal(t, newEmail, result.User.PendingEmail.String)
+	require.True(t, relayOutboxTaskTypes(t, store)[message.TaskType])
+
+	verifyEmail, err := store.CreateVerifyEmail(context.Background(), CreateVerifyEmailParams{
+		Username:   user.Username,
+		Email:      newEmail,
+		SecretCode: util.RandomString(32),
+	})
+	require.NoError(t, err)
+
+	verified, err := store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:    verifyEmail.ID,
+		SecretCode: verifyEmail.SecretCode,
+	})
+	require.NoError(t, err)
+	require.Equal(t, newEmail, verified.User.Email)
+	require.False(t, verified.User.PendingEmail.Valid)
+	require.True(t, verified.User.IsEmailVerified)
+
+	// the code sent to the old address can't verify it anymore
+	_, err = store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:    oldVerifyEmail.ID,
+		SecretCode: oldVerifyEmail.SecretCode,
+	})
+	require.ErrorIs(t, err, ErrVerifyEmailOutdated)
+}
+
+func TestSearchUsers(t *testing.T) {
+	user := createRandomUser(t)
+
+	users, err := testQueries.SearchUsers(context.Background(), SearchUsersParams{
+		Pattern: "%" + user.Email[:len(user.Email)-1] + "%",
+		Limit:   5,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, users)
+
+	found := false
+	for _, u := range users {
+		if u.Username == user.Username {
+			found = true
+		}
+	}
+	require.True(t, found)
+}
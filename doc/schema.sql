@@ -9,7 +9,10 @@ CREATE TABLE "users" (
   "email" varchar UNIQUE NOT NULL,
   "is_email_verified" boolean NOT NULL DEFAULT false,
   "password_changed_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
-  "created_at" timestamptz NOT NULL DEFAULT (now())
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "role" varchar NOT NULL DEFAULT 'depositor',
+  "is_frozen" boolean NOT NULL DEFAULT false,
+  "pending_email" varchar
 );
 
 CREATE TABLE "verify_emails" (
@@ -22,11 +25,35 @@ CREATE TABLE "verify_emails" (
   "expired_at" timestamptz NOT NULL DEFAULT (now() + interval '15 minutes')
 );
 
+CREATE TABLE "verify_email_requests" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "sessions" (
+  "id" uuid PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "refresh_token" varchar NOT NULL,
+  "user_agent" varchar NOT NULL,
+  "client_ip" varchar NOT NULL,
+  "is_blocked" boolean NOT NULL DEFAULT false,
+  "expired_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "family_id" uuid NOT NULL,
+  "rotated_at" timestamptz
+);
+
 CREATE TABLE "accounts" (
   "id" bigserial PRIMARY KEY,
   "owner" varchar NOT NULL,
   "balance" bigint NOT NULL,
   "currency" varchar NOT NULL,
+  "overdraft_limit" bigint NOT NULL DEFAULT 0,
+  "status" varchar NOT NULL DEFAULT 'active',
+  "held_amount" bigint NOT NULL DEFAULT 0,
+  "available_balance" bigint NOT NULL GENERATED ALWAYS AS ("balance" - "held_amount") STORED,
+  "frozen_by_admin" boolean NOT NULL DEFAULT false,
   "created_at" timestamptz NOT NULL DEFAULT (now())
 );
 
@@ -34,6 +61,7 @@ CREATE TABLE "entries" (
   "id" bigserial PRIMARY KEY,
   "account_id" bigint,
   "amount" bigint NOT NULL,
+  "transfer_id" bigint,
   "created_at" timestamptz NOT NULL DEFAULT (now())
 );
 
@@ -42,29 +70,330 @@ CREATE TABLE "transfers" (
   "from_account_id" bigint,
   "to_account_id" bigint,
   "amount" bigint NOT NULL,
+  "reversed_transfer_id" bigint,
+  "reversed_amount" bigint NOT NULL DEFAULT 0,
+  "to_amount" bigint NOT NULL,
+  "exchange_rate" numeric(20,10),
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "idempotency_keys" (
+  "username" varchar NOT NULL,
+  "key" varchar NOT NULL,
+  "request_hash" varchar NOT NULL,
+  "response" jsonb NOT NULL DEFAULT '{}',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("username", "key")
+);
+
+CREATE TABLE "holds" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "captured_amount" bigint NOT NULL DEFAULT 0,
+  "status" varchar NOT NULL DEFAULT 'authorized',
+  "transfer_id" bigint,
+  "expired_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "exchange_rates" (
+  "from_currency" varchar NOT NULL,
+  "to_currency" varchar NOT NULL,
+  "rate" numeric(20,10) NOT NULL,
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("from_currency", "to_currency")
+);
+
+CREATE TABLE "exchange_quotes" (
+  "id" bigserial PRIMARY KEY,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "from_amount" bigint NOT NULL,
+  "to_amount" bigint NOT NULL,
+  "rate" numeric(20,10) NOT NULL,
+  "transfer_id" bigint,
+  "expired_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "scheduled_transfers" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "schedule" varchar NOT NULL,
+  "next_run_at" timestamptz NOT NULL,
+  "is_active" bool NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "scheduled_transfer_runs" (
+  "id" bigserial PRIMARY KEY,
+  "scheduled_transfer_id" bigint NOT NULL,
+  "run_at" timestamptz NOT NULL,
+  "status" varchar NOT NULL,
+  "transfer_id" bigint,
+  "error" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "reconciliation_runs" (
+  "id" bigserial PRIMARY KEY,
+  "status" varchar NOT NULL,
+  "discrepancy_count" int NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "reconciliation_discrepancies" (
+  "id" bigserial PRIMARY KEY,
+  "run_id" bigint NOT NULL,
+  "kind" varchar NOT NULL,
+  "account_id" bigint,
+  "transfer_id" bigint,
+  "expected" bigint NOT NULL,
+  "actual" bigint NOT NULL,
+  "details" varchar NOT NULL DEFAULT ''
+);
+
+CREATE TABLE "outbox" (
+  "id" bigserial PRIMARY KEY,
+  "task_type" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "queue" varchar NOT NULL DEFAULT 'default',
+  "max_retry" int NOT NULL DEFAULT 25,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "published_at" timestamptz
+);
+
+CREATE TABLE "ledger_adjustments" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "entry_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "reason" varchar NOT NULL,
+  "created_by" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "login_attempts" (
+  "scope" varchar NOT NULL,
+  "identifier" varchar NOT NULL,
+  "failed_count" int NOT NULL DEFAULT 0,
+  "last_failed_at" timestamptz NOT NULL DEFAULT (now()),
+  "locked_until" timestamptz,
+  "unlock_code" varchar,
+  PRIMARY KEY ("scope", "identifier")
+);
+
+CREATE TABLE "password_resets" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "secret_code" varchar NOT NULL,
+  "is_used" bool NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "expired_at" timestamptz NOT NULL DEFAULT (now() + interval '15 minutes')
+);
+
+CREATE TABLE "password_reset_requests" (
+  "id" bigserial PRIMARY KEY,
+  "email" varchar NOT NULL,
+  "client_ip" varchar NOT NULL DEFAULT '',
   "created_at" timestamptz NOT NULL DEFAULT (now())
 );
 
+CREATE TABLE "user_totps" (
+  "username" varchar PRIMARY KEY,
+  "secret" varchar NOT NULL,
+  "confirmed_at" timestamptz,
+  "last_used_step" bigint NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "recovery_codes" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "hashed_code" varchar NOT NULL,
+  "used_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "login_challenges" (
+  "id" uuid PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "failed_count" int NOT NULL DEFAULT 0,
+  "is_used" bool NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "expired_at" timestamptz NOT NULL
+);
+
+CREATE TABLE "transfer_challenges" (
+  "id" uuid PRIMARY KEY,
+  "hold_id" bigint UNIQUE NOT NULL,
+  "username" varchar NOT NULL,
+  "method" varchar NOT NULL,
+  "hashed_code" varchar NOT NULL DEFAULT '',
+  "idempotency_key" varchar,
+  "failed_count" int NOT NULL DEFAULT 0,
+  "is_used" bool NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "expired_at" timestamptz NOT NULL
+);
+
 CREATE INDEX ON "users" ("username");
 
+CREATE INDEX ON "verify_email_requests" ("username", "created_at");
+
+CREATE INDEX ON "sessions" ("family_id");
+
+CREATE INDEX ON "sessions" ("username");
+
 CREATE INDEX ON "accounts" ("owner");
 
-CREATE UNIQUE INDEX ON "accounts" ("owner", "currency");
+CREATE UNIQUE INDEX ON "accounts" ("owner", "currency") WHERE "status" <> 'closed';
 
-CREATE INDEX ON "entries" ("account_id");
+CREATE INDEX ON "entries" ("account_id", "created_at", "id");
 
-CREATE INDEX ON "transfers" ("from_account_id");
+CREATE INDEX ON "entries" ("transfer_id");
 
-CREATE INDEX ON "transfers" ("to_account_id");
+CREATE INDEX ON "transfers" ("from_account_id", "created_at", "id");
+
+CREATE INDEX ON "transfers" ("to_account_id", "created_at", "id");
 
 CREATE INDEX ON "transfers" ("from_account_id", "to_account_id");
 
+CREATE INDEX ON "transfers" ("reversed_transfer_id");
+
+CREATE INDEX ON "holds" ("account_id");
+
+CREATE INDEX ON "holds" ("status", "expired_at");
+
+CREATE INDEX ON "exchange_quotes" ("from_account_id");
+
+CREATE INDEX ON "scheduled_transfers" ("owner");
+
+CREATE INDEX ON "scheduled_transfers" ("next_run_at") WHERE "is_active";
+
+CREATE UNIQUE INDEX ON "scheduled_transfer_runs" ("scheduled_transfer_id", "run_at");
+
+CREATE INDEX ON "reconciliation_discrepancies" ("run_id");
+
+CREATE INDEX ON "outbox" ("id") WHERE "published_at" IS NULL;
+
+CREATE INDEX ON "ledger_adjustments" ("account_id");
+
+CREATE UNIQUE INDEX ON "ledger_adjustments" ("entry_id");
+
+CREATE INDEX ON "password_reset_requests" ("email", "created_at");
+
+CREATE INDEX ON "password_reset_requests" ("client_ip", "created_at");
+
+CREATE UNIQUE INDEX ON "recovery_codes" ("username", "hashed_code");
+
+CREATE UNIQUE INDEX ON "transfer_challenges" ("username", "idempotency_key");
+
+COMMENT ON COLUMN "users"."role" IS 'depositor, banker or admin';
+
+COMMENT ON COLUMN "users"."is_frozen" IS 'frozen users cannot log in or renew their tokens';
+
+COMMENT ON COLUMN "users"."pending_email" IS 'new email waiting to be verified before it replaces email';
+
+COMMENT ON COLUMN "sessions"."family_id" IS 'id of the session created at login';
+
+COMMENT ON COLUMN "sessions"."rotated_at" IS 'set once the refresh token has been exchanged for a new one';
+
+COMMENT ON COLUMN "accounts"."overdraft_limit" IS 'how far the balance may go below zero';
+
+COMMENT ON COLUMN "accounts"."status" IS 'active, frozen or closed';
+
+COMMENT ON COLUMN "accounts"."held_amount" IS 'sum of the authorized holds';
+
+COMMENT ON COLUMN "accounts"."available_balance" IS 'balance minus the authorized holds';
+
+COMMENT ON COLUMN "accounts"."frozen_by_admin" IS 'set while the account is frozen by an admin, its owner can then neither unfreeze nor close it';
+
 COMMENT ON COLUMN "entries"."amount" IS 'can be negative or positive';
 
 COMMENT ON COLUMN "transfers"."amount" IS 'must be positive';
 
+COMMENT ON COLUMN "transfers"."reversed_transfer_id" IS 'the original transfer when this one is a reversal';
+
+COMMENT ON COLUMN "transfers"."reversed_amount" IS 'sum of the reversals of this transfer';
+
+COMMENT ON COLUMN "transfers"."to_amount" IS 'amount credited in the currency of the to account';
+
+COMMENT ON COLUMN "transfers"."exchange_rate" IS 'the rate used by a cross-currency transfer';
+
+COMMENT ON COLUMN "idempotency_keys"."username" IS 'the user who sent the key, different users can use the same key';
+
+COMMENT ON COLUMN "holds"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "holds"."status" IS 'authorized, captured, voided or expired';
+
+COMMENT ON COLUMN "exchange_rates"."rate" IS 'amount of to_currency for one unit of from_currency';
+
+COMMENT ON COLUMN "exchange_quotes"."transfer_id" IS 'set once the quote has been executed';
+
+COMMENT ON COLUMN "scheduled_transfers"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "scheduled_transfers"."schedule" IS 'cron expression like 0 9 1 * *, or @every 24h';
+
+COMMENT ON COLUMN "scheduled_transfer_runs"."run_at" IS 'the time the run was scheduled for';
+
+COMMENT ON COLUMN "scheduled_transfer_runs"."status" IS 'succeeded or failed';
+
+COMMENT ON COLUMN "reconciliation_runs"."status" IS 'balanced or mismatch';
+
+COMMENT ON COLUMN "reconciliation_discrepancies"."kind" IS 'account_balance or transfer_entries';
+
+COMMENT ON COLUMN "reconciliation_discrepancies"."expected" IS 'sum of the entries, or the number of entries a transfer must have';
+
+COMMENT ON COLUMN "reconciliation_discrepancies"."actual" IS 'balance of the account, or the number of matching entries found';
+
+COMMENT ON COLUMN "outbox"."task_type" IS 'the asynq task type the row is published as';
+
+COMMENT ON COLUMN "outbox"."published_at" IS 'set once the task has been enqueued';
+
+COMMENT ON COLUMN "ledger_adjustments"."amount" IS 'credited when positive, debited when negative';
+
+COMMENT ON COLUMN "ledger_adjustments"."reason" IS 'why the balance was adjusted, kept for audit';
+
+COMMENT ON COLUMN "ledger_adjustments"."created_by" IS 'the admin who made the adjustment';
+
+COMMENT ON COLUMN "login_attempts"."scope" IS 'username or client_ip';
+
+COMMENT ON COLUMN "login_attempts"."identifier" IS 'the username or the client ip the failures are counted for, the username may not exist';
+
+COMMENT ON COLUMN "login_attempts"."locked_until" IS 'logins are rejected until then';
+
+COMMENT ON COLUMN "login_attempts"."unlock_code" IS 'sent to the user to lift the lock early';
+
+COMMENT ON COLUMN "password_reset_requests"."email" IS 'the email asked for, requests for unknown emails are counted too';
+
+COMMENT ON COLUMN "user_totps"."confirmed_at" IS 'two-factor authentication is enabled once the first code has been confirmed';
+
+COMMENT ON COLUMN "user_totps"."last_used_step" IS 'time step of the last accepted code, a code is never accepted twice';
+
+COMMENT ON COLUMN "recovery_codes"."hashed_code" IS 'sha256 of the code, shown once when generated';
+
+COMMENT ON COLUMN "login_challenges"."id" IS 'the challenge token returned by the login of a user with two-factor authentication';
+
+COMMENT ON COLUMN "transfer_challenges"."hold_id" IS 'the hold reserving the funds of the pending transfer';
+
+COMMENT ON COLUMN "transfer_challenges"."method" IS 'totp or email';
+
+COMMENT ON COLUMN "transfer_challenges"."hashed_code" IS 'sha256 of the emailed code, empty for totp';
+
+COMMENT ON COLUMN "transfer_challenges"."idempotency_key" IS 'idempotency key of the transfer, used once it is confirmed';
+
 ALTER TABLE "verify_emails" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
 
+ALTER TABLE "verify_email_requests" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "sessions" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
 ALTER TABLE "accounts" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
 
 ALTER TABLE "entries" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
@@ -72,3 +401,55 @@ ALTER TABLE "entries" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id"
 ALTER TABLE "transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
 
 ALTER TABLE "transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("reversed_transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "idempotency_keys" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "exchange_quotes" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "exchange_quotes" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "exchange_quotes" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfer_runs" ADD FOREIGN KEY ("scheduled_transfer_id") REFERENCES "scheduled_transfers" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "scheduled_transfer_runs" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "reconciliation_discrepancies" ADD FOREIGN KEY ("run_id") REFERENCES "reconciliation_runs" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "reconciliation_discrepancies" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "reconciliation_discrepancies" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "ledger_adjustments" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "ledger_adjustments" ADD FOREIGN KEY ("entry_id") REFERENCES "entries" ("id");
+
+ALTER TABLE "ledger_adjustments" ADD FOREIGN KEY ("created_by") REFERENCES "users" ("username");
+
+ALTER TABLE "password_resets" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "user_totps" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "recovery_codes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "login_challenges" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_challenges" ADD FOREIGN KEY ("hold_id") REFERENCES "holds" ("id");
+
+ALTER TABLE "transfer_challenges" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
@@ -0,0 +1,68 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/token"
+	"github.com/chensheep/simple-bank-backend/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// getOwnAccount loads the account and checks it belongs to the authenticated user
+func (server *Server) getOwnAccount(ctx context.Context, authPayload *token.Payload, accountID int64) (db.Account, error) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return account, status.Error(codes.NotFound, "account not found")
+		}
+		return account, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+
+	if authPayload.Username != account.Owner {
+		return account, status.Error(codes.PermissionDenied, "cannot access other user's account")
+	}
+
+	return account, nil
+}
+
+// getViewableAccount loads the account for reading, bankers can read the accounts of any customer
+func (server *Server) getViewableAccount(ctx context.Context, authPayload *token.Payload, accountID int64) (db.Account, error) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return account, status.Error(codes.NotFound, "account not found")
+		}
+		return account, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+
+	if !canViewAccount(authPayload, account) {
+		return account, status.Error(codes.PermissionDenied, "cannot access other user's account")
+	}
+
+	return account, nil
+}
+
+func canViewAccount(authPayload *token.Payload, account db.Account) bool {
+	return authPayload.Username == account.Owner || authPayload.Role == util.BankerRole
+}
+
+// validAccount checks the account exists and has the given currency. Its status is checked
+// by the transfer transactions, which fail with db.ErrAccountNotActive
+func (server *Server) validAccount(ctx context.Context, accountID int64, currency string) (db.Account, error) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return account, status.Errorf(codes.NotFound, "account [%d] not found", accountID)
+		}
+		return account, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+
+	if account.Currency != currency {
+		return account, status.Errorf(codes.InvalidArgument, "account [%d] currency mismatch: %s vs %s", accountID, account.Currency, currency)
+	}
+
+	return account, nil
+}
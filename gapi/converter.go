@@ -13,5 +13,137 @@ func convertUser(user db.User) *pb.User {
 		Email:             user.Email,
 		PasswordChangedAt: timestamppb.New(user.PasswordChangedAt),
 		CreatedAt:         timestamppb.New(user.CreatedAt),
+		Role:              user.Role,
+		IsFrozen:          user.IsFrozen,
+		PendingEmail:      user.PendingEmail.String,
 	}
 }
+
+func convertAccount(account db.Account) *pb.Account {
+	return &pb.Account{
+		Id:               account.ID,
+		Owner:            account.Owner,
+		Balance:          account.Balance,
+		Currency:         account.Currency,
+		CreatedAt:        timestamppb.New(account.CreatedAt),
+		OverdraftLimit:   account.OverdraftLimit,
+		Status:           account.Status,
+		HeldAmount:       account.HeldAmount,
+		AvailableBalance: account.AvailableBalance,
+	}
+}
+
+func convertEntry(entry db.Entry) *pb.Entry {
+	return &pb.Entry{
+		Id:         entry.ID,
+		AccountId:  entry.AccountID,
+		Amount:     entry.Amount,
+		TransferId: entry.TransferID.Int64,
+		CreatedAt:  timestamppb.New(entry.CreatedAt),
+	}
+}
+
+func convertTransfer(transfer db.Transfer) *pb.Transfer {
+	return &pb.Transfer{
+		Id:                 transfer.ID,
+		FromAccountId:      transfer.FromAccountID,
+		ToAccountId:        transfer.ToAccountID,
+		Amount:             transfer.Amount,
+		ReversedTransferId: transfer.ReversedTransferID.Int64,
+		ReversedAmount:     transfer.ReversedAmount,
+		CreatedAt:          timestamppb.New(transfer.CreatedAt),
+		ToAmount:           transfer.ToAmount,
+		ExchangeRate:       transfer.ExchangeRate.String,
+	}
+}
+
+func convertTransferTxResult(result db.TransferTxResult) *pb.CreateTransferResponse {
+	return &pb.CreateTransferResponse{
+		Transfer:    convertTransfer(result.Transfer),
+		FromAccount: convertAccount(result.FromAccount),
+		ToAccount:   convertAccount(result.ToAccount),
+		FromEntry:   convertEntry(result.FromEntry),
+		ToEntry:     convertEntry(result.ToEntry),
+	}
+}
+
+func convertLedgerAdjustment(adjustment db.LedgerAdjustment) *pb.LedgerAdjustment {
+	return &pb.LedgerAdjustment{
+		Id:        adjustment.ID,
+		AccountId: adjustment.AccountID,
+		EntryId:   adjustment.EntryID,
+		Amount:    adjustment.Amount,
+		Reason:    adjustment.Reason,
+		CreatedBy: adjustment.CreatedBy,
+		CreatedAt: timestamppb.New(adjustment.CreatedAt),
+	}
+}
+
+func convertExchangeRate(rate db.ExchangeRate) *pb.ExchangeRate {
+	return &pb.ExchangeRate{
+		FromCurrency: rate.FromCurrency,
+		ToCurrency:   rate.ToCurrency,
+		Rate:         rate.Rate,
+		UpdatedAt:    timestamppb.New(rate.UpdatedAt),
+	}
+}
+
+func convertExchangeQuote(quote db.ExchangeQuote) *pb.ExchangeQuote {
+	return &pb.ExchangeQuote{
+		Id:            quote.ID,
+		FromAccountId: quote.FromAccountID,
+		ToAccountId:   quote.ToAccountID,
+		FromAmount:    quote.FromAmount,
+		ToAmount:      quote.ToAmount,
+		Rate:          quote.Rate,
+		TransferId:    quote.TransferID.Int64,
+		ExpiredAt:     timestamppb.New(quote.ExpiredAt),
+		CreatedAt:     timestamppb.New(quote.CreatedAt),
+	}
+}
+
+func convertScheduledTransfer(scheduledTransfer db.ScheduledTransfer) *pb.ScheduledTransfer {
+	return &pb.ScheduledTransfer{
+		Id:            scheduledTransfer.ID,
+		Owner:         scheduledTransfer.Owner,
+		FromAccountId: scheduledTransfer.FromAccountID,
+		ToAccountId:   scheduledTransfer.ToAccountID,
+		Amount:        scheduledTransfer.Amount,
+		Schedule:      scheduledTransfer.Schedule,
+		NextRunAt:     timestamppb.New(scheduledTransfer.NextRunAt),
+		IsActive:      scheduledTransfer.IsActive,
+		CreatedAt:     timestamppb.New(scheduledTransfer.CreatedAt),
+	}
+}
+
+func convertScheduledTransferRun(run db.ScheduledTransferRun) *pb.ScheduledTransferRun {
+	return &pb.ScheduledTransferRun{
+		Id:                  run.ID,
+		ScheduledTransferId: run.ScheduledTransferID,
+		RunAt:               timestamppb.New(run.RunAt),
+		Status:              run.Status,
+		TransferId:          run.TransferID.Int64,
+		Error:               run.Error,
+		CreatedAt:           timestamppb.New(run.CreatedAt),
+	}
+}
+
+func convertBatchTransferTxResult(result db.BatchTransferTxResult) *pb.CreateBatchTransferResponse {
+	rsp := &pb.CreateBatchTransferResponse{
+		FromAccount:    convertAccount(result.FromAccount),
+		SucceededCount: int32(result.SucceededCount),
+		FailedCount:    int32(result.FailedCount),
+	}
+	for _, leg := range result.Legs {
+		legResult := &pb.BatchTransferLegResult{
+			ToAccountId: leg.ToAccountID,
+			Amount:      leg.Amount,
+			Error:       leg.Error,
+		}
+		if leg.Transfer != nil {
+			legResult.Transfer = convertTransfer(*leg.Transfer)
+		}
+		rsp.Legs = append(rsp.Legs, legResult)
+	}
+	return rsp
+}
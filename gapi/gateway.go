@@ -0,0 +1,212 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/chensheep/simple-bank-backend/pb"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+)
+
+// GatewayServer serves the grpc-gateway handlers registered with RegisterSimpleBankServiceHandlerServer
+// and RegisterAdminServiceHandlerServer. Those call the server methods directly and skip the gRPC interceptors,
+// so every method goes through the auth interceptor here. Methods not listed fall back to Unimplemented
+type GatewayServer struct {
+	pb.UnimplementedSimpleBankServiceServer
+	pb.UnimplementedAdminServiceServer
+	server *Server
+}
+
+func NewGatewayServer(server *Server) *GatewayServer {
+	return &GatewayServer{server: server}
+}
+
+// invoke runs the handler behind the auth interceptor as if it was called through the gRPC server
+func invoke[Req any, Res any](ctx context.Context, gateway *GatewayServer, fullMethod string, req Req, handler func(context.Context, Req) (Res, error)) (Res, error) {
+	info := &grpc.UnaryServerInfo{
+		Server:     gateway.server,
+		FullMethod: fullMethod,
+	}
+	rsp, err := gateway.server.AuthUnaryInterceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return handler(ctx, req.(Req))
+	})
+	if err != nil {
+		var zero Res
+		return zero, err
+	}
+	return rsp.(Res), nil
+}
+
+func (gateway *GatewayServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_CreateUser_FullMethodName, req, gateway.server.CreateUser)
+}
+
+func (gateway *GatewayServer) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.LoginUserResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_LoginUser_FullMethodName, req, gateway.server.LoginUser)
+}
+
+func (gateway *GatewayServer) RenewAccessToken(ctx context.Context, req *pb.RenewAccessTokenRequest) (*pb.RenewAccessTokenResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_RenewAccessToken_FullMethodName, req, gateway.server.RenewAccessToken)
+}
+
+func (gateway *GatewayServer) LogoutUser(ctx context.Context, req *pb.LogoutUserRequest) (*pb.LogoutUserResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_LogoutUser_FullMethodName, req, gateway.server.LogoutUser)
+}
+
+func (gateway *GatewayServer) ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_ListSessions_FullMethodName, req, gateway.server.ListSessions)
+}
+
+func (gateway *GatewayServer) RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*pb.RevokeSessionResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_RevokeSession_FullMethodName, req, gateway.server.RevokeSession)
+}
+
+func (gateway *GatewayServer) RevokeAllSessions(ctx context.Context, req *pb.RevokeAllSessionsRequest) (*pb.RevokeAllSessionsResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_RevokeAllSessions_FullMethodName, req, gateway.server.RevokeAllSessions)
+}
+
+func (gateway *GatewayServer) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_UpdateUser_FullMethodName, req, gateway.server.UpdateUser)
+}
+
+func (gateway *GatewayServer) FreezeUser(ctx context.Context, req *pb.FreezeUserRequest) (*pb.FreezeUserResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_FreezeUser_FullMethodName, req, gateway.server.FreezeUser)
+}
+
+func (gateway *GatewayServer) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.CreateAccountResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_CreateAccount_FullMethodName, req, gateway.server.CreateAccount)
+}
+
+func (gateway *GatewayServer) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.GetAccountResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_GetAccount_FullMethodName, req, gateway.server.GetAccount)
+}
+
+func (gateway *GatewayServer) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_ListAccounts_FullMethodName, req, gateway.server.ListAccounts)
+}
+
+func (gateway *GatewayServer) CloseAccount(ctx context.Context, req *pb.CloseAccountRequest) (*pb.CloseAccountResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_CloseAccount_FullMethodName, req, gateway.server.CloseAccount)
+}
+
+func (gateway *GatewayServer) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_CreateTransfer_FullMethodName, req, gateway.server.CreateTransfer)
+}
+
+func (gateway *GatewayServer) ConfirmTransfer(ctx context.Context, req *pb.ConfirmTransferRequest) (*pb.CreateTransferResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_ConfirmTransfer_FullMethodName, req, gateway.server.ConfirmTransfer)
+}
+
+func (gateway *GatewayServer) CreateBatchTransfer(ctx context.Context, req *pb.CreateBatchTransferRequest) (*pb.CreateBatchTransferResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_CreateBatchTransfer_FullMethodName, req, gateway.server.CreateBatchTransfer)
+}
+
+func (gateway *GatewayServer) DownloadStatement(ctx context.Context, req *pb.DownloadStatementRequest) (*httpbody.HttpBody, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_DownloadStatement_FullMethodName, req, gateway.server.DownloadStatement)
+}
+
+func (gateway *GatewayServer) ListAccountEntries(ctx context.Context, req *pb.ListAccountEntriesRequest) (*pb.ListAccountEntriesResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_ListAccountEntries_FullMethodName, req, gateway.server.ListAccountEntries)
+}
+
+func (gateway *GatewayServer) ListAccountTransfers(ctx context.Context, req *pb.ListAccountTransfersRequest) (*pb.ListAccountTransfersResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_ListAccountTransfers_FullMethodName, req, gateway.server.ListAccountTransfers)
+}
+
+func (gateway *GatewayServer) ReverseTransfer(ctx context.Context, req *pb.ReverseTransferRequest) (*pb.ReverseTransferResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_ReverseTransfer_FullMethodName, req, gateway.server.ReverseTransfer)
+}
+
+func (gateway *GatewayServer) CreateScheduledTransfer(ctx context.Context, req *pb.CreateScheduledTransferRequest) (*pb.CreateScheduledTransferResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_CreateScheduledTransfer_FullMethodName, req, gateway.server.CreateScheduledTransfer)
+}
+
+func (gateway *GatewayServer) GetScheduledTransfer(ctx context.Context, req *pb.GetScheduledTransferRequest) (*pb.GetScheduledTransferResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_GetScheduledTransfer_FullMethodName, req, gateway.server.GetScheduledTransfer)
+}
+
+func (gateway *GatewayServer) ListScheduledTransfers(ctx context.Context, req *pb.ListScheduledTransfersRequest) (*pb.ListScheduledTransfersResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_ListScheduledTransfers_FullMethodName, req, gateway.server.ListScheduledTransfers)
+}
+
+func (gateway *GatewayServer) UpdateScheduledTransfer(ctx context.Context, req *pb.UpdateScheduledTransferRequest) (*pb.UpdateScheduledTransferResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_UpdateScheduledTransfer_FullMethodName, req, gateway.server.UpdateScheduledTransfer)
+}
+
+func (gateway *GatewayServer) DeleteScheduledTransfer(ctx context.Context, req *pb.DeleteScheduledTransferRequest) (*pb.DeleteScheduledTransferResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_DeleteScheduledTransfer_FullMethodName, req, gateway.server.DeleteScheduledTransfer)
+}
+
+func (gateway *GatewayServer) ListScheduledTransferRuns(ctx context.Context, req *pb.ListScheduledTransferRunsRequest) (*pb.ListScheduledTransferRunsResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_ListScheduledTransferRuns_FullMethodName, req, gateway.server.ListScheduledTransferRuns)
+}
+
+func (gateway *GatewayServer) CreateExchangeQuote(ctx context.Context, req *pb.CreateExchangeQuoteRequest) (*pb.CreateExchangeQuoteResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_CreateExchangeQuote_FullMethodName, req, gateway.server.CreateExchangeQuote)
+}
+
+func (gateway *GatewayServer) ExecuteExchangeQuote(ctx context.Context, req *pb.ExecuteExchangeQuoteRequest) (*pb.ExecuteExchangeQuoteResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_ExecuteExchangeQuote_FullMethodName, req, gateway.server.ExecuteExchangeQuote)
+}
+
+func (gateway *GatewayServer) VerifyEmail(ctx context.Context, req *pb.VerifyEmailRequest) (*pb.VerifyEmailResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_VerifyEmail_FullMethodName, req, gateway.server.VerifyEmail)
+}
+
+func (gateway *GatewayServer) UnlockUser(ctx context.Context, req *pb.UnlockUserRequest) (*pb.UnlockUserResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_UnlockUser_FullMethodName, req, gateway.server.UnlockUser)
+}
+
+func (gateway *GatewayServer) RequestPasswordReset(ctx context.Context, req *pb.RequestPasswordResetRequest) (*pb.RequestPasswordResetResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_RequestPasswordReset_FullMethodName, req, gateway.server.RequestPasswordReset)
+}
+
+func (gateway *GatewayServer) ResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*pb.ResetPasswordResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_ResetPassword_FullMethodName, req, gateway.server.ResetPassword)
+}
+
+func (gateway *GatewayServer) VerifyLoginTotp(ctx context.Context, req *pb.VerifyLoginTotpRequest) (*pb.LoginUserResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_VerifyLoginTotp_FullMethodName, req, gateway.server.VerifyLoginTotp)
+}
+
+func (gateway *GatewayServer) EnrollTotp(ctx context.Context, req *pb.EnrollTotpRequest) (*pb.EnrollTotpResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_EnrollTotp_FullMethodName, req, gateway.server.EnrollTotp)
+}
+
+func (gateway *GatewayServer) ConfirmTotp(ctx context.Context, req *pb.ConfirmTotpRequest) (*pb.ConfirmTotpResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_ConfirmTotp_FullMethodName, req, gateway.server.ConfirmTotp)
+}
+
+func (gateway *GatewayServer) DisableTotp(ctx context.Context, req *pb.DisableTotpRequest) (*pb.DisableTotpResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_DisableTotp_FullMethodName, req, gateway.server.DisableTotp)
+}
+
+func (gateway *GatewayServer) SearchUsers(ctx context.Context, req *pb.SearchUsersRequest) (*pb.SearchUsersResponse, error) {
+	return invoke(ctx, gateway, pb.AdminService_SearchUsers_FullMethodName, req, gateway.server.SearchUsers)
+}
+
+func (gateway *GatewayServer) RevokeUserSessions(ctx context.Context, req *pb.RevokeUserSessionsRequest) (*pb.RevokeUserSessionsResponse, error) {
+	return invoke(ctx, gateway, pb.AdminService_RevokeUserSessions_FullMethodName, req, gateway.server.RevokeUserSessions)
+}
+
+// ResendVerifyEmail serves the routes of both services, so it runs under the policy of SimpleBankService
+// and the handler only lets admins resend the emails of other users
+func (gateway *GatewayServer) ResendVerifyEmail(ctx context.Context, req *pb.ResendVerifyEmailRequest) (*pb.ResendVerifyEmailResponse, error) {
+	return invoke(ctx, gateway, pb.SimpleBankService_ResendVerifyEmail_FullMethodName, req, gateway.server.ResendVerifyEmail)
+}
+
+func (gateway *GatewayServer) FreezeAccount(ctx context.Context, req *pb.FreezeAccountRequest) (*pb.FreezeAccountResponse, error) {
+	return invoke(ctx, gateway, pb.AdminService_FreezeAccount_FullMethodName, req, gateway.server.FreezeAccount)
+}
+
+func (gateway *GatewayServer) UnfreezeAccount(ctx context.Context, req *pb.UnfreezeAccountRequest) (*pb.UnfreezeAccountResponse, error) {
+	return invoke(ctx, gateway, pb.AdminService_UnfreezeAccount_FullMethodName, req, gateway.server.UnfreezeAccount)
+}
+
+func (gateway *GatewayServer) AdjustBalance(ctx context.Context, req *pb.AdjustBalanceRequest) (*pb.AdjustBalanceResponse, error) {
+	return invoke(ctx, gateway, pb.AdminService_AdjustBalance_FullMethodName, req, gateway.server.AdjustBalance)
+}
+
+func (gateway *GatewayServer) UpsertExchangeRates(ctx context.Context, req *pb.UpsertExchangeRatesRequest) (*pb.UpsertExchangeRatesResponse, error) {
+	return invoke(ctx, gateway, pb.AdminService_UpsertExchangeRates_FullMethodName, req, gateway.server.UpsertExchangeRates)
+}
@@ -0,0 +1,97 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/chensheep/simple-bank-backend/token"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/chensheep/simple-bank-backend/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// accountHistoryRequest is implemented by the requests listing the history of an account
+type accountHistoryRequest interface {
+	GetAccountId() int64
+	GetPageSize() int32
+	GetCursor() string
+	GetStartTime() *timestamppb.Timestamp
+	GetEndTime() *timestamppb.Timestamp
+	GetDirection() string
+	GetMinAmount() int64
+	GetMaxAmount() int64
+}
+
+// accountHistoryFilter holds the validated filters shared by the history RPCs
+type accountHistoryFilter struct {
+	direction       sql.NullString
+	startTime       sql.NullTime
+	endTime         sql.NullTime
+	minAmount       sql.NullInt64
+	maxAmount       sql.NullInt64
+	cursorCreatedAt sql.NullTime
+	cursorID        sql.NullInt64
+}
+
+func parseAccountHistoryRequest(req accountHistoryRequest, hasDirection, hasMinAmount, hasMaxAmount bool) (filter accountHistoryFilter, violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetAccountId()); err != nil {
+		violations = append(violations, fieldViolation("account_id", err.Error()))
+	}
+	if err := val.ValidatePageSize(req.GetPageSize()); err != nil {
+		violations = append(violations, fieldViolation("page_size", err.Error()))
+	}
+	if req.GetCursor() != "" {
+		createdAt, id, err := util.DecodeCursor(req.GetCursor())
+		if err != nil {
+			violations = append(violations, fieldViolation("cursor", err.Error()))
+		}
+		filter.cursorCreatedAt = sql.NullTime{Time: createdAt, Valid: err == nil}
+		filter.cursorID = sql.NullInt64{Int64: id, Valid: err == nil}
+	}
+	if req.GetStartTime() != nil {
+		filter.startTime = sql.NullTime{Time: req.GetStartTime().AsTime(), Valid: true}
+	}
+	if req.GetEndTime() != nil {
+		filter.endTime = sql.NullTime{Time: req.GetEndTime().AsTime(), Valid: true}
+	}
+	if hasDirection {
+		if err := val.ValidateDirection(req.GetDirection()); err != nil {
+			violations = append(violations, fieldViolation("direction", err.Error()))
+		}
+		filter.direction = sql.NullString{String: req.GetDirection(), Valid: true}
+	}
+	if hasMinAmount {
+		if err := val.ValidateAmount(req.GetMinAmount()); err != nil {
+			violations = append(violations, fieldViolation("min_amount", err.Error()))
+		}
+		filter.minAmount = sql.NullInt64{Int64: req.GetMinAmount(), Valid: true}
+	}
+	if hasMaxAmount {
+		if err := val.ValidateAmount(req.GetMaxAmount()); err != nil {
+			violations = append(violations, fieldViolation("max_amount", err.Error()))
+		}
+		filter.maxAmount = sql.NullInt64{Int64: req.GetMaxAmount(), Valid: true}
+	}
+	return filter, violations
+}
+
+// authorizeAccountViewer checks the account exists and can be read by the authenticated user,
+// that is the owner or a banker. Closed accounts pass so their history stays readable
+func (server *Server) authorizeAccountViewer(ctx context.Context, authPayload *token.Payload, accountID int64) error {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return status.Error(codes.NotFound, "account not found")
+		}
+		return status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+
+	if !canViewAccount(authPayload, account) {
+		return status.Error(codes.PermissionDenied, "cannot read the history of other user's account")
+	}
+
+	return nil
+}
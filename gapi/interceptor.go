@@ -0,0 +1,112 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/chensheep/simple-bank-backend/token"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type authPayloadKey struct{}
+
+// AuthUnaryInterceptor enforces the policy of the method and
+// injects the token payload of authenticated calls into the context
+func (server *Server) AuthUnaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	ctx, err := server.authorizeMethod(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// AuthStreamInterceptor is the streaming counterpart of AuthUnaryInterceptor
+func (server *Server) AuthStreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	ctx, err := server.authorizeMethod(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authServerStream{ServerStream: ss, ctx: ctx})
+}
+
+type authServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (server *Server) authorizeMethod(ctx context.Context, fullMethod string) (context.Context, error) {
+	policy, ok := methodPolicies[fullMethod]
+	if !ok {
+		return nil, status.Errorf(codes.PermissionDenied, "no access policy for method %s", fullMethod)
+	}
+	if policy.access == accessPublic {
+		return ctx, nil
+	}
+
+	payload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return nil, unathorizedError(err)
+	}
+
+	if policy.access == accessRole && !hasRole(payload, policy.roles) {
+		return nil, status.Error(codes.PermissionDenied, "permission denied")
+	}
+
+	if err := server.checkUser(ctx, policy, payload.Username); err != nil {
+		return nil, err
+	}
+
+	return context.WithValue(ctx, authPayloadKey{}, payload), nil
+}
+
+// checkUser enforces the parts of the policy which depend on the state of the user,
+// the user is only loaded for the methods which have one
+func (server *Server) checkUser(ctx context.Context, policy methodPolicy, username string) error {
+	requireVerifiedEmail := policy.verifiedEmail && server.config.RequireVerifiedEmail
+	if !requireVerifiedEmail && !policy.activeUser {
+		return nil
+	}
+
+	user, err := server.store.GetUser(ctx, username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return status.Error(codes.NotFound, "user not found")
+		}
+		return status.Errorf(codes.Internal, "failed to get user: %s", err)
+	}
+
+	if policy.activeUser && user.IsFrozen {
+		return status.Error(codes.PermissionDenied, "user is frozen")
+	}
+	if requireVerifiedEmail && !user.IsEmailVerified {
+		return status.Error(codes.PermissionDenied, "email must be verified first")
+	}
+
+	return nil
+}
+
+// authPayloadFromContext returns the token payload injected by the auth interceptors
+func authPayloadFromContext(ctx context.Context) (*token.Payload, error) {
+	payload, ok := ctx.Value(authPayloadKey{}).(*token.Payload)
+	if !ok {
+		return nil, fmt.Errorf("missing access token payload")
+	}
+	return payload, nil
+}
@@ -0,0 +1,46 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/chensheep/simple-bank-backend/login"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errInvalidCredentials is returned for an unknown username and a wrong password alike
+var errInvalidCredentials = status.Error(codes.Unauthenticated, login.ErrInvalidCredentials.Error())
+
+// loginError maps the errors of the authenticator
+func loginError(err error) error {
+	var lockedErr *login.LockedError
+	switch {
+	case errors.Is(err, login.ErrInvalidCredentials):
+		return errInvalidCredentials
+	case errors.Is(err, login.ErrInvalidChallenge):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, login.ErrUserFrozen):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.As(err, &lockedErr):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// checkLoginAttempts rejects the call while the username or the client ip is locked out
+// after too many failed logins, the second factor codes count as logins
+func (server *Server) checkLoginAttempts(ctx context.Context, username string, clientIp string) error {
+	if err := server.authenticator.CheckAttempts(ctx, username, clientIp); err != nil {
+		return loginError(err)
+	}
+	return nil
+}
+
+// recordLoginFailure counts a wrong code of the user as a failed login
+func (server *Server) recordLoginFailure(ctx context.Context, username string, clientIp string, userExists bool) error {
+	if err := server.authenticator.RecordFailure(ctx, username, clientIp, userExists); err != nil {
+		return loginError(err)
+	}
+	return nil
+}
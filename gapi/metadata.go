@@ -2,6 +2,8 @@ package gapi
 
 import (
 	"context"
+	"net"
+	"strings"
 
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
@@ -10,7 +12,7 @@ import (
 const (
 	grpcGatewayUserAgentHeader = "grpcgateway-user-agent"
 	userAgentHeader            = "user-agent"
-	xForwardedHostHeader       = "x-forwarded-host"
+	xForwardedForHeader        = "x-forwarded-for"
 )
 
 type Metadata struct {
@@ -28,13 +30,19 @@ func (server *Server) ExtractMetadata(ctx context.Context) *Metadata {
 		if userAgents := md.Get(userAgentHeader); len(userAgents) > 0 {
 			metdt.UserAgent = userAgents[0]
 		}
-		if clientIps := md.Get(xForwardedHostHeader); len(clientIps) > 0 {
-			metdt.ClientIp = clientIps[0]
+		// the gateway appends the address of the http client to x-forwarded-for,
+		// the addresses before it are sent by the client and can't be trusted
+		if clientIps := md.Get(xForwardedForHeader); len(clientIps) > 0 {
+			addrs := strings.Split(clientIps[0], ",")
+			metdt.ClientIp = strings.TrimSpace(addrs[len(addrs)-1])
 		}
 	}
 
 	if p, ok := peer.FromContext(ctx); ok {
 		metdt.ClientIp = p.Addr.String()
+		if host, _, err := net.SplitHostPort(metdt.ClientIp); err == nil {
+			metdt.ClientIp = host
+		}
 	}
 
 	return metdt
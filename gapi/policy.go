@@ -0,0 +1,119 @@
+package gapi
+
+import (
+	"fmt"
+
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/token"
+	"github.com/chensheep/simple-bank-backend/util"
+	"google.golang.org/grpc"
+)
+
+type accessLevel int
+
+const (
+	// accessPublic methods are served without an access token
+	accessPublic accessLevel = iota
+	// accessAuthenticated methods need a valid access token
+	accessAuthenticated
+	// accessRole methods need a valid access token granting one of the policy roles
+	accessRole
+)
+
+type methodPolicy struct {
+	access accessLevel
+	roles  []string
+	// verifiedEmail methods need the caller to have verified their email,
+	// when the server is configured with RequireVerifiedEmail
+	verifiedEmail bool
+	// activeUser methods are refused to frozen users, whose access tokens stay valid until they expire
+	activeUser bool
+}
+
+var (
+	publicPolicy        = methodPolicy{access: accessPublic}
+	authenticatedPolicy = methodPolicy{access: accessAuthenticated}
+	adminPolicy         = methodPolicy{access: accessRole, roles: []string{util.AdminRole}}
+	// moneyMovementPolicy guards the methods opening accounts or sending money out of them
+	moneyMovementPolicy = methodPolicy{access: accessAuthenticated, verifiedEmail: true, activeUser: true}
+)
+
+// methodPolicies is the access policy of every RPC served, keyed by the full method name.
+// Methods without a policy are rejected by the auth interceptors
+var methodPolicies = map[string]methodPolicy{
+	pb.SimpleBankService_CreateUser_FullMethodName:                publicPolicy,
+	pb.SimpleBankService_LoginUser_FullMethodName:                 publicPolicy,
+	pb.SimpleBankService_RenewAccessToken_FullMethodName:          publicPolicy,
+	pb.SimpleBankService_LogoutUser_FullMethodName:                publicPolicy,
+	pb.SimpleBankService_VerifyEmail_FullMethodName:               publicPolicy,
+	pb.SimpleBankService_UnlockUser_FullMethodName:                publicPolicy,
+	pb.SimpleBankService_RequestPasswordReset_FullMethodName:      publicPolicy,
+	pb.SimpleBankService_ResetPassword_FullMethodName:             publicPolicy,
+	pb.SimpleBankService_VerifyLoginTotp_FullMethodName:           publicPolicy,
+	pb.SimpleBankService_ListSessions_FullMethodName:              authenticatedPolicy,
+	pb.SimpleBankService_RevokeSession_FullMethodName:             authenticatedPolicy,
+	pb.SimpleBankService_RevokeAllSessions_FullMethodName:         authenticatedPolicy,
+	pb.SimpleBankService_UpdateUser_FullMethodName:                authenticatedPolicy,
+	pb.SimpleBankService_ResendVerifyEmail_FullMethodName:         authenticatedPolicy,
+	pb.SimpleBankService_EnrollTotp_FullMethodName:                authenticatedPolicy,
+	pb.SimpleBankService_ConfirmTotp_FullMethodName:               authenticatedPolicy,
+	pb.SimpleBankService_DisableTotp_FullMethodName:               authenticatedPolicy,
+	pb.SimpleBankService_FreezeUser_FullMethodName:                adminPolicy,
+	pb.SimpleBankService_CreateAccount_FullMethodName:             moneyMovementPolicy,
+	pb.SimpleBankService_GetAccount_FullMethodName:                authenticatedPolicy,
+	pb.SimpleBankService_ListAccounts_FullMethodName:              authenticatedPolicy,
+	pb.SimpleBankService_CloseAccount_FullMethodName:              authenticatedPolicy,
+	pb.SimpleBankService_CreateTransfer_FullMethodName:            moneyMovementPolicy,
+	pb.SimpleBankService_ConfirmTransfer_FullMethodName:           moneyMovementPolicy,
+	pb.SimpleBankService_CreateBatchTransfer_FullMethodName:       moneyMovementPolicy,
+	pb.SimpleBankService_DownloadStatement_FullMethodName:         authenticatedPolicy,
+	pb.SimpleBankService_ListAccountEntries_FullMethodName:        authenticatedPolicy,
+	pb.SimpleBankService_ListAccountTransfers_FullMethodName:      authenticatedPolicy,
+	pb.SimpleBankService_ReverseTransfer_FullMethodName:           moneyMovementPolicy,
+	pb.SimpleBankService_CreateExchangeQuote_FullMethodName:       moneyMovementPolicy,
+	pb.SimpleBankService_ExecuteExchangeQuote_FullMethodName:      moneyMovementPolicy,
+	pb.SimpleBankService_CreateScheduledTransfer_FullMethodName:   moneyMovementPolicy,
+	pb.SimpleBankService_GetScheduledTransfer_FullMethodName:      authenticatedPolicy,
+	pb.SimpleBankService_ListScheduledTransfers_FullMethodName:    authenticatedPolicy,
+	pb.SimpleBankService_UpdateScheduledTransfer_FullMethodName:   moneyMovementPolicy,
+	pb.SimpleBankService_DeleteScheduledTransfer_FullMethodName:   authenticatedPolicy,
+	pb.SimpleBankService_ListScheduledTransferRuns_FullMethodName: authenticatedPolicy,
+
+	pb.AdminService_SearchUsers_FullMethodName:         adminPolicy,
+	pb.AdminService_RevokeUserSessions_FullMethodName:  adminPolicy,
+	pb.AdminService_ResendVerifyEmail_FullMethodName:   adminPolicy,
+	pb.AdminService_FreezeAccount_FullMethodName:       adminPolicy,
+	pb.AdminService_UnfreezeAccount_FullMethodName:     adminPolicy,
+	pb.AdminService_AdjustBalance_FullMethodName:       adminPolicy,
+	pb.AdminService_UpsertExchangeRates_FullMethodName: adminPolicy,
+}
+
+// hasRole reports whether the token grants one of the roles
+func hasRole(payload *token.Payload, roles []string) bool {
+	for _, role := range roles {
+		if payload.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// checkMethodPolicies makes sure every method of the services has a policy,
+// so a new RPC fails at startup instead of being served unprotected
+func checkMethodPolicies(services ...grpc.ServiceDesc) error {
+	for _, service := range services {
+		for _, method := range service.Methods {
+			fullMethod := fmt.Sprintf("/%s/%s", service.ServiceName, method.MethodName)
+			if _, ok := methodPolicies[fullMethod]; !ok {
+				return fmt.Errorf("missing access policy for %s", fullMethod)
+			}
+		}
+		for _, stream := range service.Streams {
+			fullMethod := fmt.Sprintf("/%s/%s", service.ServiceName, stream.StreamName)
+			if _, ok := methodPolicies[fullMethod]; !ok {
+				return fmt.Errorf("missing access policy for %s", fullMethod)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AdjustBalance corrects the balance of an account with a journal entry,
+// the admin calling it is recorded with the reason
+func (server *Server) AdjustBalance(ctx context.Context, req *pb.AdjustBalanceRequest) (*pb.AdjustBalanceResponse, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, unathorizedError(err)
+	}
+
+	violations := validateAdjustBalanceRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	result, err := server.store.AdjustBalanceTx(ctx, db.AdjustBalanceTxParams{
+		AccountID: req.GetAccountId(),
+		Amount:    req.GetAmount(),
+		Reason:    req.GetReason(),
+		CreatedBy: authPayload.Username,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Error(codes.NotFound, "account not found")
+		}
+		if errors.Is(err, db.ErrAccountNotActive) || errors.Is(err, db.ErrInsufficientFunds) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "failed to adjust balance: %s", err)
+	}
+
+	rsp := &pb.AdjustBalanceResponse{
+		Adjustment: convertLedgerAdjustment(result.Adjustment),
+		Entry:      convertEntry(result.Entry),
+		Account:    convertAccount(result.Account),
+	}
+
+	return rsp, nil
+}
+
+func validateAdjustBalanceRequest(req *pb.AdjustBalanceRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetAccountId()); err != nil {
+		violations = append(violations, fieldViolation("account_id", err.Error()))
+	}
+	if err := val.ValidateAdjustmentAmount(req.GetAmount()); err != nil {
+		violations = append(violations, fieldViolation("amount", err.Error()))
+	}
+	if err := val.ValidateReason(req.GetReason()); err != nil {
+		violations = append(violations, fieldViolation("reason", err.Error()))
+	}
+	return violations
+}
@@ -0,0 +1,82 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/chensheep/simple-bank-backend/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ConfirmTotp enables two-factor authentication once the user proves the authenticator app
+// generates the right codes, and returns the recovery codes. Only their hashes are kept
+func (server *Server) ConfirmTotp(ctx context.Context, req *pb.ConfirmTotpRequest) (*pb.ConfirmTotpResponse, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, unathorizedError(err)
+	}
+
+	violations := validateConfirmTotpRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	userTotp, err := server.store.GetUserTotp(ctx, authPayload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Error(codes.FailedPrecondition, "two-factor authentication is not enrolled")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user totp: %s", err)
+	}
+	if userTotp.ConfirmedAt.Valid {
+		return nil, status.Error(codes.FailedPrecondition, "two-factor authentication is already enabled")
+	}
+
+	step, ok := util.ValidateTotpCode(userTotp.Secret, req.GetCode(), time.Now())
+	if !ok {
+		return nil, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{
+			fieldViolation("code", "invalid code"),
+		})
+	}
+
+	recoveryCodes := make([]string, recoveryCodeCount)
+	hashedRecoveryCodes := make([]string, recoveryCodeCount)
+	for i := range recoveryCodes {
+		recoveryCodes[i], err = util.NewRecoveryCode()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to generate recovery code: %s", err)
+		}
+		hashedRecoveryCodes[i] = util.HashRecoveryCode(recoveryCodes[i])
+	}
+
+	_, err = server.store.ConfirmTotpTx(ctx, db.ConfirmTotpTxParams{
+		Username:            authPayload.Username,
+		Step:                step,
+		HashedRecoveryCodes: hashedRecoveryCodes,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Error(codes.FailedPrecondition, "two-factor authentication is already enabled")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to confirm totp: %s", err)
+	}
+
+	rsp := &pb.ConfirmTotpResponse{
+		RecoveryCodes: recoveryCodes,
+	}
+
+	return rsp, nil
+}
+
+func validateConfirmTotpRequest(req *pb.ConfirmTotpRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateTotpCode(req.GetCode()); err != nil {
+		violations = append(violations, fieldViolation("code", err.Error()))
+	}
+	return violations
+}
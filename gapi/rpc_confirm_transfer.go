@@ -0,0 +1,129 @@
+package gapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"time"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/login"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/chensheep/simple-bank-backend/val"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ConfirmTransfer makes the pending transfer held by CreateTransfer, once the user answers its challenge
+// with the emailed code, or a totp or recovery code as at login. Wrong codes count as failed logins
+// of the user, and cancel the transfer after a few tries
+func (server *Server) ConfirmTransfer(ctx context.Context, req *pb.ConfirmTransferRequest) (*pb.CreateTransferResponse, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, unathorizedError(err)
+	}
+
+	violations := validateConfirmTransferRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	// challenges of other users are reported as not found, so their ids can't be probed
+	challenge, err := server.store.GetTransferChallenge(ctx, uuid.MustParse(req.GetChallengeId()))
+	if err != nil && err != sql.ErrNoRows {
+		return nil, status.Errorf(codes.Internal, "failed to get transfer challenge: %s", err)
+	}
+	if err == sql.ErrNoRows || challenge.Username != authPayload.Username {
+		return nil, status.Error(codes.NotFound, "transfer challenge not found")
+	}
+	if challenge.IsUsed || time.Now().After(challenge.ExpiredAt) {
+		return nil, status.Error(codes.FailedPrecondition, "transfer challenge is used or expired")
+	}
+
+	md := server.ExtractMetadata(ctx)
+	if err := server.checkLoginAttempts(ctx, authPayload.Username, md.ClientIp); err != nil {
+		return nil, err
+	}
+
+	arg := db.ConfirmTransferTxParams{
+		ChallengeID: challenge.ID,
+		Username:    authPayload.Username,
+	}
+	ok := false
+	switch challenge.Method {
+	case db.TransferChallengeMethodTotp:
+		userTotp, err := server.store.GetUserTotp(ctx, authPayload.Username)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, status.Errorf(codes.Internal, "failed to get user totp: %s", err)
+		}
+		if err == nil && userTotp.ConfirmedAt.Valid {
+			arg.SecondFactor, ok = login.SecondFactor(userTotp, req.GetCode())
+		}
+	case db.TransferChallengeMethodEmail:
+		hashedCode := util.HashEmailCode(req.GetCode())
+		ok = subtle.ConstantTimeCompare([]byte(hashedCode), []byte(challenge.HashedCode)) == 1
+	}
+
+	var result db.ConfirmTransferTxResult
+	if ok {
+		result, err = server.store.ConfirmTransferTx(ctx, arg)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, transferError(err)
+		}
+		ok = err == nil
+	}
+
+	if !ok {
+		if err := server.recordTransferChallengeFailure(ctx, challenge); err != nil {
+			return nil, err
+		}
+		if err := server.recordLoginFailure(ctx, authPayload.Username, md.ClientIp, true); err != nil {
+			return nil, err
+		}
+		return nil, status.Error(codes.Unauthenticated, "invalid code")
+	}
+
+	return convertTransferTxResult(result.Transfer), nil
+}
+
+// recordTransferChallengeFailure counts the wrong code, and releases the funds
+// as soon as the challenge is used up instead of waiting for the hold to expire
+func (server *Server) recordTransferChallengeFailure(ctx context.Context, challenge db.TransferChallenge) error {
+	challenge, err := server.store.RecordTransferChallengeFailure(ctx, db.RecordTransferChallengeFailureParams{
+		MaxFailures: transferChallengeMaxFailures,
+		ID:          challenge.ID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return status.Errorf(codes.Internal, "failed to record transfer challenge failure: %s", err)
+	}
+	if !challenge.IsUsed {
+		return nil
+	}
+
+	_, err = server.store.VoidHoldTx(ctx, db.VoidHoldTxParams{HoldID: challenge.HoldID})
+	if err != nil && !errors.Is(err, db.ErrHoldNotAuthorized) {
+		return status.Errorf(codes.Internal, "failed to release pending transfer: %s", err)
+	}
+
+	log.Warn().Str("username", challenge.Username).Int64("hold_id", challenge.HoldID).
+		Msg("pending transfer cancelled after too many wrong codes")
+	return nil
+}
+
+func validateConfirmTransferRequest(req *pb.ConfirmTransferRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateSessionID(req.GetChallengeId()); err != nil {
+		violations = append(violations, fieldViolation("challenge_id", err.Error()))
+	}
+	if err := val.ValidateSecondFactorCode(req.GetCode()); err != nil {
+		violations = append(violations, fieldViolation("code", err.Error()))
+	}
+	return violations
+}
@@ -0,0 +1,115 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	maxBatchTransferLegs = 1000
+
+	batchTransferModeBestEffort = "best_effort"
+)
+
+// CreateBatchTransfer pays many accounts from one account in a single transaction
+func (server *Server) CreateBatchTransfer(ctx context.Context, req *pb.CreateBatchTransferRequest) (*pb.CreateBatchTransferResponse, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, unathorizedError(err)
+	}
+
+	violations := validateCreateBatchTransferRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	// the legs add up, so a payment over the threshold can't be split into legs under it.
+	// The total can't overflow, the validation rejects the legs adding up to more than an amount can hold
+	var total int64
+	for _, leg := range req.GetLegs() {
+		total += leg.GetAmount()
+	}
+	if err := server.checkStepUp(req.GetCurrency(), total); err != nil {
+		return nil, err
+	}
+
+	fromAccount, err := server.validAccount(ctx, req.GetFromAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+	if authPayload.Username != fromAccount.Owner {
+		return nil, status.Error(codes.PermissionDenied, "cannot send money from other user's account")
+	}
+
+	arg := db.BatchTransferTxParams{
+		FromAccountID: req.GetFromAccountId(),
+		BestEffort:    req.GetMode() == batchTransferModeBestEffort,
+	}
+	for _, leg := range req.GetLegs() {
+		arg.Legs = append(arg.Legs, db.BatchTransferLeg{
+			ToAccountID: leg.GetToAccountId(),
+			Amount:      leg.GetAmount(),
+		})
+	}
+
+	result, err := server.store.BatchTransferTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrInsufficientFunds) ||
+			errors.Is(err, db.ErrAccountNotActive) ||
+			errors.Is(err, db.ErrAccountNotFound) ||
+			errors.Is(err, db.ErrCurrencyMismatch) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "account not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to create batch transfer: %s", err)
+	}
+
+	return convertBatchTransferTxResult(result), nil
+}
+
+func validateCreateBatchTransferRequest(req *pb.CreateBatchTransferRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetFromAccountId()); err != nil {
+		violations = append(violations, fieldViolation("from_account_id", err.Error()))
+	}
+	if err := val.ValidateCurrency(req.GetCurrency()); err != nil {
+		violations = append(violations, fieldViolation("currency", err.Error()))
+	}
+	if err := val.ValidateBatchTransferMode(req.GetMode()); err != nil {
+		violations = append(violations, fieldViolation("mode", err.Error()))
+	}
+
+	legs := req.GetLegs()
+	if len(legs) < 1 || len(legs) > maxBatchTransferLegs {
+		violations = append(violations, fieldViolation("legs", fmt.Sprintf("must have between 1 and %d legs", maxBatchTransferLegs)))
+		return violations
+	}
+
+	var total int64
+	for i, leg := range legs {
+		if err := val.ValidateID(leg.GetToAccountId()); err != nil {
+			violations = append(violations, fieldViolation(fmt.Sprintf("legs[%d].to_account_id", i), err.Error()))
+		}
+		if err := val.ValidateTransferAmount(leg.GetAmount()); err != nil {
+			violations = append(violations, fieldViolation(fmt.Sprintf("legs[%d].amount", i), err.Error()))
+			continue
+		}
+		if leg.GetAmount() > math.MaxInt64-total {
+			violations = append(violations, fieldViolation("legs", "must not add up to more than an amount can hold"))
+			break
+		}
+		total += leg.GetAmount()
+	}
+	return violations
+}
@@ -0,0 +1,146 @@
+package gapi
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	mockdb "github.com/chensheep/simple-bank-backend/db/mock"
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreateBatchTransfer(t *testing.T) {
+	user := randomUser()
+	account1 := randomAccount(user.Username, util.USD)
+	account2 := randomAccount(util.RandomOwner(), util.USD)
+	account2.ID = account1.ID + 1
+	account3 := randomAccount(util.RandomOwner(), util.USD)
+	account3.ID = account1.ID + 2
+
+	testCases := []struct {
+		name          string
+		legs          []*pb.BatchTransferLeg
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.CreateBatchTransferResponse, err error)
+	}{
+		{
+			name: "OK",
+			legs: []*pb.BatchTransferLeg{
+				{ToAccountId: account2.ID, Amount: 10},
+				{ToAccountId: account3.ID, Amount: 20},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+
+				arg := db.BatchTransferTxParams{
+					FromAccountID: account1.ID,
+					Legs: []db.BatchTransferLeg{
+						{ToAccountID: account2.ID, Amount: 10},
+						{ToAccountID: account3.ID, Amount: 20},
+					},
+				}
+				store.EXPECT().
+					BatchTransferTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.BatchTransferTxResult{
+						FromAccount: account1,
+						Legs: []db.BatchTransferLegResult{
+							{ToAccountID: account2.ID, Amount: 10, Transfer: &db.Transfer{ID: 1, Amount: 10}},
+							{ToAccountID: account3.ID, Amount: 20, Transfer: &db.Transfer{ID: 2, Amount: 20}},
+						},
+						SucceededCount: 2,
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateBatchTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.GetLegs(), 2)
+				require.Equal(t, int32(2), res.GetSucceededCount())
+			},
+		},
+		{
+			name: "StepUpRequired",
+			legs: []*pb.BatchTransferLeg{
+				{ToAccountId: account2.ID, Amount: 300},
+				{ToAccountId: account3.ID, Amount: 300},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateBatchTransferResponse, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name: "TotalOverflow",
+			legs: []*pb.BatchTransferLeg{
+				{ToAccountId: account2.ID, Amount: math.MaxInt64},
+				{ToAccountId: account3.ID, Amount: 1},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateBatchTransferResponse, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "NoLegs",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateBatchTransferResponse, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			store := mockdb.NewMockStore(mockCtrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.stepUpThresholds = map[string]int64{util.USD: 500}
+			ctx := newContextWithBearerToken(t, server.tokenMaker, user.Username, user.Role, time.Minute)
+			req := &pb.CreateBatchTransferRequest{
+				FromAccountId: account1.ID,
+				Currency:      util.USD,
+				Legs:          tc.legs,
+			}
+
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBankService_CreateBatchTransfer_FullMethodName}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.CreateBatchTransfer(ctx, req.(*pb.CreateBatchTransferRequest))
+			}
+			res, err := server.AuthUnaryInterceptor(ctx, req, info, handler)
+
+			rsp, _ := res.(*pb.CreateBatchTransferResponse)
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
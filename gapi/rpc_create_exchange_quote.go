@@ -0,0 +1,107 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/chensheep/simple-bank-backend/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CreateExchangeQuote locks the current rate between the currencies of the two accounts,
+// the quote has to be executed before it expires
+func (server *Server) CreateExchangeQuote(ctx context.Context, req *pb.CreateExchangeQuoteRequest) (*pb.CreateExchangeQuoteResponse, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, unathorizedError(err)
+	}
+
+	violations := validateCreateExchangeQuoteRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	fromAccount, err := server.getOwnAccount(ctx, authPayload, req.GetFromAccountId())
+	if err != nil {
+		return nil, err
+	}
+	if fromAccount.Status != db.AccountStatusActive {
+		return nil, status.Errorf(codes.FailedPrecondition, "account [%d] is %s", fromAccount.ID, fromAccount.Status)
+	}
+	if err := server.checkStepUp(fromAccount.Currency, req.GetAmount()); err != nil {
+		return nil, err
+	}
+	if fromAccount.AvailableBalance+fromAccount.OverdraftLimit < req.GetAmount() {
+		return nil, status.Error(codes.FailedPrecondition, db.ErrInsufficientFunds.Error())
+	}
+
+	toAccount, err := server.store.GetAccount(ctx, req.GetToAccountId())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "account [%d] not found", req.GetToAccountId())
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+	if toAccount.Status != db.AccountStatusActive {
+		return nil, status.Errorf(codes.FailedPrecondition, "account [%d] is %s", toAccount.ID, toAccount.Status)
+	}
+	if fromAccount.Currency == toAccount.Currency {
+		return nil, status.Errorf(codes.InvalidArgument, "both accounts use %s, make a transfer instead", fromAccount.Currency)
+	}
+
+	rate, err := server.store.GetExchangeRate(ctx, db.GetExchangeRateParams{
+		FromCurrency: fromAccount.Currency,
+		ToCurrency:   toAccount.Currency,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.FailedPrecondition, "no exchange rate from %s to %s", fromAccount.Currency, toAccount.Currency)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get exchange rate: %s", err)
+	}
+
+	toAmount, err := util.ConvertAmount(req.GetAmount(), rate.Rate)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to convert amount: %s", err)
+	}
+	if toAmount <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "amount is too small to be converted to %s", toAccount.Currency)
+	}
+
+	quote, err := server.store.CreateExchangeQuote(ctx, db.CreateExchangeQuoteParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		FromAmount:    req.GetAmount(),
+		ToAmount:      toAmount,
+		Rate:          rate.Rate,
+		ExpiredAt:     time.Now().Add(server.config.ExchangeQuoteDuration),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create exchange quote: %s", err)
+	}
+
+	rsp := &pb.CreateExchangeQuoteResponse{
+		Quote: convertExchangeQuote(quote),
+	}
+
+	return rsp, nil
+}
+
+func validateCreateExchangeQuoteRequest(req *pb.CreateExchangeQuoteRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetFromAccountId()); err != nil {
+		violations = append(violations, fieldViolation("from_account_id", err.Error()))
+	}
+	if err := val.ValidateID(req.GetToAccountId()); err != nil {
+		violations = append(violations, fieldViolation("to_account_id", err.Error()))
+	}
+	if err := val.ValidateTransferAmount(req.GetAmount()); err != nil {
+		violations = append(violations, fieldViolation("amount", err.Error()))
+	}
+	return violations
+}
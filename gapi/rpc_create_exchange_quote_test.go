@@ -0,0 +1,132 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mockdb "github.com/chensheep/simple-bank-backend/db/mock"
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreateExchangeQuote(t *testing.T) {
+	user := randomUser()
+	account1 := randomAccount(user.Username, util.USD)
+	account1.AvailableBalance = 1000
+	account2 := randomAccount(util.RandomOwner(), util.EUR)
+	account2.ID = account1.ID + 1
+	account3 := randomAccount(util.RandomOwner(), util.USD)
+	account3.ID = account1.ID + 2
+
+	testCases := []struct {
+		name          string
+		toAccountID   int64
+		amount        int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.CreateExchangeQuoteResponse, err error)
+	}{
+		{
+			name:        "OK",
+			toAccountID: account2.ID,
+			amount:      100,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					GetExchangeRate(gomock.Any(), gomock.Eq(db.GetExchangeRateParams{FromCurrency: util.USD, ToCurrency: util.EUR})).
+					Times(1).
+					Return(db.ExchangeRate{FromCurrency: util.USD, ToCurrency: util.EUR, Rate: "0.9"}, nil)
+				store.EXPECT().
+					CreateExchangeQuote(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateExchangeQuoteParams) (db.ExchangeQuote, error) {
+						require.Equal(t, int64(90), arg.ToAmount)
+						return db.ExchangeQuote{
+							ID:            1,
+							FromAccountID: arg.FromAccountID,
+							ToAccountID:   arg.ToAccountID,
+							FromAmount:    arg.FromAmount,
+							ToAmount:      arg.ToAmount,
+							Rate:          arg.Rate,
+							ExpiredAt:     arg.ExpiredAt,
+						}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateExchangeQuoteResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(100), res.GetQuote().GetFromAmount())
+				require.Equal(t, int64(90), res.GetQuote().GetToAmount())
+			},
+		},
+		{
+			name:        "StepUpRequired",
+			toAccountID: account2.ID,
+			amount:      500,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().CreateExchangeQuote(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateExchangeQuoteResponse, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name:        "SameCurrency",
+			toAccountID: account3.ID,
+			amount:      100,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				store.EXPECT().GetExchangeRate(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateExchangeQuote(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateExchangeQuoteResponse, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			store := mockdb.NewMockStore(mockCtrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.stepUpThresholds = map[string]int64{util.USD: 500}
+			ctx := newContextWithBearerToken(t, server.tokenMaker, user.Username, user.Role, time.Minute)
+			req := &pb.CreateExchangeQuoteRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   tc.toAccountID,
+				Amount:        tc.amount,
+			}
+
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBankService_CreateExchangeQuote_FullMethodName}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.CreateExchangeQuote(ctx, req.(*pb.CreateExchangeQuoteRequest))
+			}
+			res, err := server.AuthUnaryInterceptor(ctx, req, info, handler)
+
+			rsp, _ := res.(*pb.CreateExchangeQuoteResponse)
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
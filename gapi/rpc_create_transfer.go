@@ -0,0 +1,89 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, unathorizedError(err)
+	}
+
+	violations := validateCreateTransferRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	// the funds and the status of the accounts are checked by the transaction, after an idempotent retry
+	// has been matched, so a retry gets the original result even if the account has changed since
+	fromAccount, err := server.validAccount(ctx, req.GetFromAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+	if authPayload.Username != fromAccount.Owner {
+		return nil, status.Error(codes.PermissionDenied, "cannot send money from other user's account")
+	}
+
+	_, err = server.validAccount(ctx, req.GetToAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	if server.requiresStepUp(req.GetCurrency(), req.GetAmount()) {
+		return server.createPendingTransfer(ctx, authPayload.Username, req)
+	}
+
+	result, err := server.store.TransferTx(ctx, db.TransferTxParams{
+		FromAccountID:  req.GetFromAccountId(),
+		ToAccountID:    req.GetToAccountId(),
+		Amount:         req.GetAmount(),
+		IdempotencyKey: req.GetIdempotencyKey(),
+		Username:       authPayload.Username,
+	})
+	if err != nil {
+		return nil, transferError(err)
+	}
+
+	return convertTransferTxResult(result), nil
+}
+
+// transferError maps the errors of the transactions moving money out of an account,
+// pending transfers add the errors of their hold
+func transferError(err error) error {
+	if errors.Is(err, db.ErrIdempotencyKeyConflict) {
+		return status.Error(codes.AlreadyExists, err.Error())
+	}
+	if errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, db.ErrAccountNotActive) ||
+		errors.Is(err, db.ErrHoldNotAuthorized) || errors.Is(err, db.ErrHoldExpired) {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return status.Errorf(codes.Internal, "failed to create transfer: %s", err)
+}
+
+func validateCreateTransferRequest(req *pb.CreateTransferRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetFromAccountId()); err != nil {
+		violations = append(violations, fieldViolation("from_account_id", err.Error()))
+	}
+	if err := val.ValidateID(req.GetToAccountId()); err != nil {
+		violations = append(violations, fieldViolation("to_account_id", err.Error()))
+	}
+	if err := val.ValidateTransferAmount(req.GetAmount()); err != nil {
+		violations = append(violations, fieldViolation("amount", err.Error()))
+	}
+	if err := val.ValidateCurrency(req.GetCurrency()); err != nil {
+		violations = append(violations, fieldViolation("currency", err.Error()))
+	}
+	if err := val.ValidateIdempotencyKey(req.GetIdempotencyKey()); err != nil {
+		violations = append(violations, fieldViolation("idempotency_key", err.Error()))
+	}
+	return violations
+}
@@ -0,0 +1,128 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mockdb "github.com/chensheep/simple-bank-backend/db/mock"
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreateTransfer(t *testing.T) {
+	user := randomUser()
+	account1 := randomAccount(user.Username, util.USD)
+	account2 := randomAccount(util.RandomOwner(), util.USD)
+	account2.ID = account1.ID + 1
+	amount := int64(10)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.CreateTransferResponse, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				arg := db.TransferTxParams{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        amount,
+					Username:      user.Username,
+				}
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.TransferTxResult{
+						Transfer:    db.Transfer{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: amount},
+						FromAccount: account1,
+						ToAccount:   account2,
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, amount, res.GetTransfer().GetAmount())
+			},
+		},
+		{
+			name: "FrozenUser",
+			buildStubs: func(store *mockdb.MockStore) {
+				frozenUser := user
+				frozenUser.IsFrozen = true
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(frozenUser, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			store := mockdb.NewMockStore(mockCtrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			ctx := newContextWithBearerToken(t, server.tokenMaker, user.Username, user.Role, time.Minute)
+			req := &pb.CreateTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        amount,
+				Currency:      util.USD,
+			}
+
+			// the call goes through the interceptor, which enforces the policy of the method
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBankService_CreateTransfer_FullMethodName}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.CreateTransfer(ctx, req.(*pb.CreateTransferRequest))
+			}
+			res, err := server.AuthUnaryInterceptor(ctx, req, info, handler)
+
+			rsp, _ := res.(*pb.CreateTransferResponse)
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
+
+func randomUser() db.User {
+	return db.User{
+		Username:        util.RandomOwner(),
+		FullName:        util.RandomOwner(),
+		Email:           util.RandomEmail(),
+		Role:            util.DepositorRole,
+		IsEmailVerified: true,
+	}
+}
+
+func randomAccount(owner string, currency string) db.Account {
+	balance := util.RandomBalance()
+	return db.Account{
+		ID:               util.RandomInt(1, 1000),
+		Owner:            owner,
+		Balance:          balance,
+		Currency:         currency,
+		Status:           db.AccountStatusActive,
+		AvailableBalance: balance,
+	}
+}
@@ -2,14 +2,12 @@ package gapi
 
 import (
 	"context"
-	"time"
 
 	db "github.com/chensheep/simple-bank-backend/db/sqlc"
 	"github.com/chensheep/simple-bank-backend/pb"
 	"github.com/chensheep/simple-bank-backend/util"
 	"github.com/chensheep/simple-bank-backend/val"
 	"github.com/chensheep/simple-bank-backend/worker"
-	"github.com/hibiken/asynq"
 	"github.com/lib/pq"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
@@ -28,6 +26,13 @@ func (server *Server) CreateUser(ctx context.Context, req *pb.CreateUserRequest)
 		return nil, status.Errorf(codes.Internal, "failed to hash password: %s", err)
 	}
 
+	verifyEmailMessage, err := worker.NewOutboxMessage(worker.TaskSendVerifyEmail,
+		&worker.SendVerifyEmailPayload{Username: req.GetUsername()}, worker.QueueCritical, 10)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create verify email task: %s", err)
+	}
+
+	// the task is written to the outbox with the user, it is only sent once the user has been committed
 	arg := db.CreateUserTxParams{
 		CreateUserParams: db.CreateUserParams{
 			Username:       req.GetUsername(),
@@ -35,21 +40,7 @@ func (server *Server) CreateUser(ctx context.Context, req *pb.CreateUserRequest)
 			FullName:       req.GetFullName(),
 			Email:          req.GetEmail(),
 		},
-		AfterUserCreated: func(user db.User) error {
-			payload := worker.SendVerifyEmailPayload{
-				Username: user.Username,
-			}
-			options := []asynq.Option{
-				asynq.MaxRetry(10),
-				asynq.ProcessIn(10 * time.Second), // This is very important, otherwise create user transaction may not be committed yet
-				asynq.Queue(worker.QueueCritical),
-			}
-			err = server.taskDistributor.DistrubuteTaskSendVerifyEmailTask(ctx, &payload, options...)
-			if err != nil {
-				return status.Errorf(codes.Internal, "failed to distibute task send verify email : %s", err)
-			}
-			return nil
-		},
+		OutboxMessages: []db.CreateOutboxMessageParams{verifyEmailMessage},
 	}
 
 	res, err := server.store.CreateUserTx(ctx, arg)
@@ -0,0 +1,42 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) DeleteScheduledTransfer(ctx context.Context, req *pb.DeleteScheduledTransferRequest) (*pb.DeleteScheduledTransferResponse, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, unathorizedError(err)
+	}
+
+	violations := validateDeleteScheduledTransferRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	_, err = server.getOwnScheduledTransfer(ctx, authPayload, req.GetScheduledTransferId())
+	if err != nil {
+		return nil, err
+	}
+
+	err = server.store.DeleteScheduledTransfer(ctx, req.GetScheduledTransferId())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to delete scheduled transfer: %s", err)
+	}
+
+	return &pb.DeleteScheduledTransferResponse{}, nil
+}
+
+func validateDeleteScheduledTransferRequest(req *pb.DeleteScheduledTransferRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetScheduledTransferId()); err != nil {
+		violations = append(violations, fieldViolation("scheduled_transfer_id", err.Error()))
+	}
+	return violations
+}
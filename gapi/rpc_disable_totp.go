@@ -0,0 +1,73 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/login"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DisableTotp turns two-factor authentication off. It takes a code, so a stolen access token
+// can't remove the second factor, and wrong codes count as failed logins of the user
+func (server *Server) DisableTotp(ctx context.Context, req *pb.DisableTotpRequest) (*pb.DisableTotpResponse, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, unathorizedError(err)
+	}
+
+	violations := validateDisableTotpRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	md := server.ExtractMetadata(ctx)
+	if err := server.checkLoginAttempts(ctx, authPayload.Username, md.ClientIp); err != nil {
+		return nil, err
+	}
+
+	userTotp, err := server.store.GetUserTotp(ctx, authPayload.Username)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, status.Errorf(codes.Internal, "failed to get user totp: %s", err)
+	}
+	if err == sql.ErrNoRows || !userTotp.ConfirmedAt.Valid {
+		return nil, status.Error(codes.FailedPrecondition, "two-factor authentication is not enabled")
+	}
+
+	factor, ok := login.SecondFactor(userTotp, req.GetCode())
+	if ok {
+		err = server.store.DisableTotpTx(ctx, db.DisableTotpTxParams{
+			Username:     authPayload.Username,
+			SecondFactor: factor,
+		})
+		if err != nil && err != sql.ErrNoRows {
+			return nil, status.Errorf(codes.Internal, "failed to disable totp: %s", err)
+		}
+		ok = err == nil
+	}
+
+	if !ok {
+		if err := server.recordLoginFailure(ctx, authPayload.Username, md.ClientIp, true); err != nil {
+			return nil, err
+		}
+		return nil, status.Error(codes.Unauthenticated, "invalid code")
+	}
+
+	rsp := &pb.DisableTotpResponse{
+		IsDisabled: true,
+	}
+
+	return rsp, nil
+}
+
+func validateDisableTotpRequest(req *pb.DisableTotpRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateSecondFactorCode(req.GetCode()); err != nil {
+		violations = append(violations, fieldViolation("code", err.Error()))
+	}
+	return violations
+}
@@ -0,0 +1,88 @@
+package gapi
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/statement"
+	"github.com/chensheep/simple-bank-backend/val"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) DownloadStatement(ctx context.Context, req *pb.DownloadStatementRequest) (*httpbody.HttpBody, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, unathorizedError(err)
+	}
+
+	violations := validateDownloadStatementRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	format := req.GetFormat()
+	if format == "" {
+		format = statement.FormatPDF
+	}
+
+	month, _ := statement.ParseMonth(req.GetMonth())
+	if month.After(time.Now()) {
+		return nil, status.Errorf(codes.InvalidArgument, "month %s has not started yet", req.GetMonth())
+	}
+
+	account, err := server.store.GetAccount(ctx, req.GetAccountId())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Error(codes.NotFound, "account not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+
+	if !canViewAccount(authPayload, account) {
+		return nil, status.Error(codes.PermissionDenied, "cannot download the statement of other user's account")
+	}
+
+	start, end := statement.MonthPeriod(month)
+	s, err := statement.Generate(ctx, server.store, account, start, end)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate statement: %s", err)
+	}
+
+	var buf bytes.Buffer
+	err = s.Write(&buf, format)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to write statement: %s", err)
+	}
+
+	// sent as the Grpc-Metadata-Content-Disposition header through the gateway
+	_ = grpc.SetHeader(ctx, metadata.Pairs("content-disposition", fmt.Sprintf("attachment; filename=%q", s.FileName(format))))
+
+	rsp := &httpbody.HttpBody{
+		ContentType: statement.ContentType(format),
+		Data:        buf.Bytes(),
+	}
+
+	return rsp, nil
+}
+
+func validateDownloadStatementRequest(req *pb.DownloadStatementRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetAccountId()); err != nil {
+		violations = append(violations, fieldViolation("account_id", err.Error()))
+	}
+	if _, err := statement.ParseMonth(req.GetMonth()); err != nil {
+		violations = append(violations, fieldViolation("month", err.Error()))
+	}
+	if req.GetFormat() != "" && req.GetFormat() != statement.FormatCSV && req.GetFormat() != statement.FormatPDF {
+		violations = append(violations, fieldViolation("format", "must be csv or pdf"))
+	}
+	return violations
+}
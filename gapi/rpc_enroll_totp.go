@@ -0,0 +1,45 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// EnrollTotp gives the user a new totp secret, replacing the one of an enrollment not confirmed yet.
+// Two-factor authentication is only enabled once ConfirmTotp receives a code generated from it
+func (server *Server) EnrollTotp(ctx context.Context, req *pb.EnrollTotpRequest) (*pb.EnrollTotpResponse, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, unathorizedError(err)
+	}
+
+	secret, err := util.NewTotpSecret()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate totp secret: %s", err)
+	}
+
+	// a confirmed secret isn't replaced, it must be disabled with a code first
+	userTotp, err := server.store.UpsertUserTotp(ctx, db.UpsertUserTotpParams{
+		Username: authPayload.Username,
+		Secret:   secret,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Error(codes.FailedPrecondition, "two-factor authentication is already enabled")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to save totp secret: %s", err)
+	}
+
+	rsp := &pb.EnrollTotpResponse{
+		Secret:          userTotp.Secret,
+		ProvisioningUri: util.TotpProvisioningURI(totpIssuer, userTotp.Username, userTotp.Secret),
+	}
+
+	return rsp, nil
+}
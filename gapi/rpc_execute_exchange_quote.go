@@ -0,0 +1,73 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ExecuteExchangeQuote makes the cross-currency transfer at the quoted rate
+func (server *Server) ExecuteExchangeQuote(ctx context.Context, req *pb.ExecuteExchangeQuoteRequest) (*pb.ExecuteExchangeQuoteResponse, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, unathorizedError(err)
+	}
+
+	violations := validateExecuteExchangeQuoteRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	quote, err := server.store.GetExchangeQuote(ctx, req.GetQuoteId())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Error(codes.NotFound, "exchange quote not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get exchange quote: %s", err)
+	}
+
+	_, err = server.getOwnAccount(ctx, authPayload, quote.FromAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := server.store.ExchangeTransferTx(ctx, db.ExchangeTransferTxParams{
+		QuoteID: quote.ID,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrExchangeQuoteUsed) {
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		}
+		if errors.Is(err, db.ErrExchangeQuoteExpired) ||
+			errors.Is(err, db.ErrInsufficientFunds) ||
+			errors.Is(err, db.ErrAccountNotActive) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "failed to execute exchange quote: %s", err)
+	}
+
+	rsp := &pb.ExecuteExchangeQuoteResponse{
+		Quote:       convertExchangeQuote(result.Quote),
+		Transfer:    convertTransfer(result.Transfer.Transfer),
+		FromAccount: convertAccount(result.Transfer.FromAccount),
+		ToAccount:   convertAccount(result.Transfer.ToAccount),
+		FromEntry:   convertEntry(result.Transfer.FromEntry),
+		ToEntry:     convertEntry(result.Transfer.ToEntry),
+	}
+
+	return rsp, nil
+}
+
+func validateExecuteExchangeQuoteRequest(req *pb.ExecuteExchangeQuoteRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetQuoteId()); err != nil {
+		violations = append(violations, fieldViolation("quote_id", err.Error()))
+	}
+	return violations
+}
@@ -0,0 +1,64 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) FreezeAccount(ctx context.Context, req *pb.FreezeAccountRequest) (*pb.FreezeAccountResponse, error) {
+	violations := validateAccountStatusRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	account, err := server.updateAccountStatus(ctx, req.GetAccountId(), db.AccountStatusFrozen)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &pb.FreezeAccountResponse{
+		Account: convertAccount(account),
+	}
+
+	return rsp, nil
+}
+
+// updateAccountStatus changes the status of an account on behalf of an admin
+func (server *Server) updateAccountStatus(ctx context.Context, accountID int64, accountStatus string) (db.Account, error) {
+	result, err := server.store.UpdateAccountStatusTx(ctx, db.UpdateAccountStatusTxParams{
+		AccountID: accountID,
+		Status:    accountStatus,
+		ByAdmin:   true,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return result.Account, status.Error(codes.NotFound, "account not found")
+		}
+		if errors.Is(err, db.ErrInvalidAccountStatusTransition) {
+			return result.Account, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return result.Account, status.Errorf(codes.Internal, "failed to update account status: %s", err)
+	}
+
+	return result.Account, nil
+}
+
+// accountStatusRequest is implemented by the requests changing the status of an account
+type accountStatusRequest interface {
+	GetAccountId() int64
+}
+
+func validateAccountStatusRequest(req accountStatusRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetAccountId()); err != nil {
+		violations = append(violations, fieldViolation("account_id", err.Error()))
+	}
+	return violations
+}
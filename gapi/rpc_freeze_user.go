@@ -0,0 +1,48 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FreezeUser freezes or unfreezes the user. Freezing blocks all the sessions of the user, so no access token
+// can be renewed, and the methods moving money refuse the user right away. The access tokens already issued
+// keep working for the other methods until they expire, and the scheduled transfers of the user fail while frozen
+func (server *Server) FreezeUser(ctx context.Context, req *pb.FreezeUserRequest) (*pb.FreezeUserResponse, error) {
+	violations := validateFreezeUserRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	result, err := server.store.FreezeUserTx(ctx, db.FreezeUserTxParams{
+		Username: req.GetUsername(),
+		Frozen:   req.GetFrozen(),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to freeze user: %s", err)
+	}
+
+	rsp := &pb.FreezeUserResponse{
+		User:            convertUser(result.User),
+		BlockedSessions: result.BlockedSessions,
+	}
+
+	return rsp, nil
+}
+
+func validateFreezeUserRequest(req *pb.FreezeUserRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateUsername(req.GetUsername()); err != nil {
+		violations = append(violations, fieldViolation("username", err.Error()))
+	}
+	return violations
+}
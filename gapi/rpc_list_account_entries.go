@@ -0,0 +1,54 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) ListAccountEntries(ctx context.Context, req *pb.ListAccountEntriesRequest) (*pb.ListAccountEntriesResponse, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, unathorizedError(err)
+	}
+
+	filter, violations := parseAccountHistoryRequest(req, req.Direction != nil, req.MinAmount != nil, req.MaxAmount != nil)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	err = server.authorizeAccountViewer(ctx, authPayload, req.GetAccountId())
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := server.store.ListAccountEntries(ctx, db.ListAccountEntriesParams{
+		AccountID:       req.GetAccountId(),
+		Direction:       filter.direction,
+		StartTime:       filter.startTime,
+		EndTime:         filter.endTime,
+		MinAmount:       filter.minAmount,
+		MaxAmount:       filter.maxAmount,
+		CursorCreatedAt: filter.cursorCreatedAt,
+		CursorID:        filter.cursorID,
+		PageSize:        req.GetPageSize(),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list entries: %s", err)
+	}
+
+	rsp := &pb.ListAccountEntriesResponse{}
+	for _, entry := range entries {
+		rsp.Entries = append(rsp.Entries, convertEntry(entry))
+	}
+	if len(entries) == int(req.GetPageSize()) {
+		last := entries[len(entries)-1]
+		rsp.NextCursor = util.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	return rsp, nil
+}
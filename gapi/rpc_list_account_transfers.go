@@ -0,0 +1,54 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) ListAccountTransfers(ctx context.Context, req *pb.ListAccountTransfersRequest) (*pb.ListAccountTransfersResponse, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, unathorizedError(err)
+	}
+
+	filter, violations := parseAccountHistoryRequest(req, req.Direction != nil, req.MinAmount != nil, req.MaxAmount != nil)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	err = server.authorizeAccountViewer(ctx, authPayload, req.GetAccountId())
+	if err != nil {
+		return nil, err
+	}
+
+	transfers, err := server.store.ListAccountTransfers(ctx, db.ListAccountTransfersParams{
+		AccountID:       req.GetAccountId(),
+		Direction:       filter.direction,
+		StartTime:       filter.startTime,
+		EndTime:         filter.endTime,
+		MinAmount:       filter.minAmount,
+		MaxAmount:       filter.maxAmount,
+		CursorCreatedAt: filter.cursorCreatedAt,
+		CursorID:        filter.cursorID,
+		PageSize:        req.GetPageSize(),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list transfers: %s", err)
+	}
+
+	rsp := &pb.ListAccountTransfersResponse{}
+	for _, transfer := range transfers {
+		rsp.Transfers = append(rsp.Transfers, convertTransfer(transfer))
+	}
+	if len(transfers) == int(req.GetPageSize()) {
+		last := transfers[len(transfers)-1]
+		rsp.NextCursor = util.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	return rsp, nil
+}
@@ -0,0 +1,99 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mockdb "github.com/chensheep/simple-bank-backend/db/mock"
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestListAccounts(t *testing.T) {
+	user := randomUser()
+	customer := randomUser()
+	accounts := []db.Account{randomAccount(customer.Username, util.USD)}
+
+	testCases := []struct {
+		name          string
+		caller        db.User
+		owner         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.ListAccountsResponse, err error)
+	}{
+		{
+			name:   "OwnAccounts",
+			caller: customer,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAccountsParams{Owner: customer.Username, Limit: 5, Offset: 0}
+				store.EXPECT().ListAccounts(gomock.Any(), gomock.Eq(arg)).Times(1).Return(accounts, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.GetAccounts(), 1)
+			},
+		},
+		{
+			name:   "BankerListsOtherOwner",
+			caller: db.User{Username: user.Username, Role: util.BankerRole},
+			owner:  customer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAccountsParams{Owner: customer.Username, Limit: 5, Offset: 0}
+				store.EXPECT().ListAccounts(gomock.Any(), gomock.Eq(arg)).Times(1).Return(accounts, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountsResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, customer.Username, res.GetAccounts()[0].GetOwner())
+			},
+		},
+		{
+			name:   "DepositorListsOtherOwner",
+			caller: user,
+			owner:  customer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccounts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountsResponse, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			store := mockdb.NewMockStore(mockCtrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			ctx := newContextWithBearerToken(t, server.tokenMaker, tc.caller.Username, tc.caller.Role, time.Minute)
+			req := &pb.ListAccountsRequest{
+				PageId:   1,
+				PageSize: 5,
+				Owner:    tc.owner,
+			}
+
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBankService_ListAccounts_FullMethodName}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.ListAccounts(ctx, req.(*pb.ListAccountsRequest))
+			}
+			res, err := server.AuthUnaryInterceptor(ctx, req, info, handler)
+
+			rsp, _ := res.(*pb.ListAccountsResponse)
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
@@ -2,11 +2,9 @@ package gapi
 
 import (
 	"context"
-	"database/sql"
 
 	db "github.com/chensheep/simple-bank-backend/db/sqlc"
 	"github.com/chensheep/simple-bank-backend/pb"
-	"github.com/chensheep/simple-bank-backend/util"
 	"github.com/chensheep/simple-bank-backend/val"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
@@ -20,31 +18,41 @@ func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (
 		return nil, invalidArgumentError(violations)
 	}
 
-	user, err := server.store.GetUser(ctx, req.GetUsername())
+	md := server.ExtractMetadata(ctx)
+	user, err := server.authenticator.Authenticate(ctx, req.GetUsername(), req.GetPassword(), md.ClientIp)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, status.Error(codes.NotFound, "user not found")
-		}
-		return nil, status.Errorf(codes.Internal, "failed to get user %s", err)
+		return nil, loginError(err)
 	}
 
-	err = util.CheckPassword(req.GetPassword(), user.HashedPassword)
+	totpRequired, err := server.authenticator.SecondFactorRequired(ctx, user.Username)
 	if err != nil {
-		return nil, status.Errorf(codes.Unauthenticated, "invalid password: %s", err)
+		return nil, loginError(err)
+	}
+	if totpRequired {
+		// the password is right, but the login attempts are only reset once the code is
+		return server.createLoginChallenge(ctx, user.Username)
 	}
 
-	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, server.config.AccessTokenDuration)
+	return server.loginSession(ctx, user, md)
+}
+
+// loginSession resets the failed logins of the user, and creates the session and its tokens
+func (server *Server) loginSession(ctx context.Context, user db.User, md *Metadata) (*pb.LoginUserResponse, error) {
+	err := server.authenticator.ResetAttempts(ctx, user.Username)
+	if err != nil {
+		return nil, loginError(err)
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.AccessTokenDuration)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "Failed to create access token %s", err)
 	}
 
-	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.Username, server.config.RefreshTokenDuration)
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.RefreshTokenDuration)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "Failed to create refresh token %s", err)
 	}
 
-	md := server.ExtractMetadata(ctx)
-
 	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
 		ID:           refreshPayload.ID,
 		Username:     user.Username,
@@ -53,6 +61,7 @@ func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (
 		ClientIp:     md.ClientIp,
 		IsBlocked:    false,
 		ExpiredAt:    refreshPayload.ExpiredAt,
+		FamilyID:     refreshPayload.ID,
 	})
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "Failed to create session %s", err)
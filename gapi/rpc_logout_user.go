@@ -0,0 +1,39 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// LogoutUser blocks the session of the refresh token with the sessions rotated from the same login,
+// the access tokens already issued for them stay valid until they expire
+func (server *Server) LogoutUser(ctx context.Context, req *pb.LogoutUserRequest) (*pb.LogoutUserResponse, error) {
+	violations := validateLogoutUserRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	_, session, err := server.verifySession(ctx, req.GetRefreshToken())
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = server.store.BlockSessionFamily(ctx, session.FamilyID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to block session: %s", err)
+	}
+
+	return &pb.LogoutUserResponse{}, nil
+}
+
+func validateLogoutUserRequest(req *pb.LogoutUserRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateToken(req.GetRefreshToken()); err != nil {
+		violations = append(violations, fieldViolation("refresh_token", err.Error()))
+	}
+	return violations
+}
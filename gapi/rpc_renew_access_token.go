@@ -0,0 +1,104 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/val"
+	"github.com/chensheep/simple-bank-backend/worker"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// RenewAccessToken exchanges the refresh token for a new access token and a new refresh token.
+// Presenting a refresh token which was exchanged already signs out the whole session family
+func (server *Server) RenewAccessToken(ctx context.Context, req *pb.RenewAccessTokenRequest) (*pb.RenewAccessTokenResponse, error) {
+	violations := validateRenewAccessTokenRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	payload, session, err := server.verifySession(ctx, req.GetRefreshToken())
+	if err != nil {
+		return nil, err
+	}
+
+	// the role is read again so a changed role applies from the next renewal
+	user, err := server.store.GetUser(ctx, payload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user: %s", err)
+	}
+	if user.IsFrozen {
+		return nil, status.Error(codes.PermissionDenied, "user is frozen")
+	}
+
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.RefreshTokenDuration)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create refresh token: %s", err)
+	}
+
+	md := server.ExtractMetadata(ctx)
+	reuseAlert, err := worker.NewOutboxMessage(worker.TaskSendRefreshTokenReuseAlert, &worker.SendRefreshTokenReuseAlertPayload{
+		Username:   payload.Username,
+		UserAgent:  md.UserAgent,
+		ClientIp:   md.ClientIp,
+		DetectedAt: time.Now(),
+	}, worker.QueueCritical, 10)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create reuse alert task: %s", err)
+	}
+
+	result, err := server.store.RotateSessionTx(ctx, db.RotateSessionTxParams{
+		SessionID: session.ID,
+		NewSession: db.CreateSessionParams{
+			ID:           refreshPayload.ID,
+			Username:     payload.Username,
+			RefreshToken: refreshToken,
+			UserAgent:    md.UserAgent,
+			ClientIp:     md.ClientIp,
+			IsBlocked:    false,
+			ExpiredAt:    refreshPayload.ExpiredAt,
+		},
+		ReuseOutboxMessages: []db.CreateOutboxMessageParams{reuseAlert},
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRefreshTokenReused) || errors.Is(err, db.ErrSessionBlocked) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if err == sql.ErrNoRows {
+			return nil, status.Error(codes.NotFound, "session not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to rotate session: %s", err)
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.AccessTokenDuration)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create access token: %s", err)
+	}
+
+	rsp := &pb.RenewAccessTokenResponse{
+		AccessToken:           accessToken,
+		AccessTokenExpiredAt:  timestamppb.New(accessPayload.ExpiredAt),
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiredAt: timestamppb.New(refreshPayload.ExpiredAt),
+		SessionId:             result.Session.ID.String(),
+	}
+
+	return rsp, nil
+}
+
+func validateRenewAccessTokenRequest(req *pb.RenewAccessTokenRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateToken(req.GetRefreshToken()); err != nil {
+		violations = append(violations, fieldViolation("refresh_token", err.Error()))
+	}
+	return violations
+}
@@ -0,0 +1,108 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/val"
+	"github.com/chensheep/simple-bank-backend/worker"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RequestPasswordReset emails a link to reset the password to the user of the email.
+// The response is the same when no user has that email, so it doesn't tell which emails are registered,
+// and the requests are rate limited by email and by client ip whether the email is registered or not
+func (server *Server) RequestPasswordReset(ctx context.Context, req *pb.RequestPasswordResetRequest) (*pb.RequestPasswordResetResponse, error) {
+	violations := validateRequestPasswordResetRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	md := server.ExtractMetadata(ctx)
+	if err := server.checkPasswordResetRate(ctx, req.GetEmail(), md.ClientIp); err != nil {
+		return nil, err
+	}
+
+	_, err := server.store.CreatePasswordResetRequest(ctx, db.CreatePasswordResetRequestParams{
+		Email:    req.GetEmail(),
+		ClientIp: md.ClientIp,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to record password reset request: %s", err)
+	}
+
+	user, err := server.store.GetUserByEmail(ctx, req.GetEmail())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &pb.RequestPasswordResetResponse{}, nil
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user: %s", err)
+	}
+
+	resetEmailMessage, err := worker.NewOutboxMessage(worker.TaskSendPasswordResetEmail,
+		&worker.SendPasswordResetEmailPayload{Username: user.Username}, worker.QueueCritical, 10)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create password reset email task: %s", err)
+	}
+
+	_, err = server.store.CreateOutboxMessage(ctx, resetEmailMessage)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to save password reset email task: %s", err)
+	}
+
+	return &pb.RequestPasswordResetResponse{}, nil
+}
+
+// checkPasswordResetRate allows a new password reset email once the resend delay has passed since the last
+// request for the email, and up to the daily maximum of the email and of the client ip
+func (server *Server) checkPasswordResetRate(ctx context.Context, email string, clientIp string) error {
+	recent, err := server.store.CountPasswordResetRequestsByEmail(ctx, db.CountPasswordResetRequestsByEmailParams{
+		Email: email,
+		Since: time.Now().Add(-server.config.PasswordResetResendDelay),
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to count password reset requests: %s", err)
+	}
+	if recent > 0 {
+		return status.Errorf(codes.ResourceExhausted, "a password reset was requested less than %s ago", server.config.PasswordResetResendDelay)
+	}
+
+	daily, err := server.store.CountPasswordResetRequestsByEmail(ctx, db.CountPasswordResetRequestsByEmailParams{
+		Email: email,
+		Since: time.Now().Add(-24 * time.Hour),
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to count password reset requests: %s", err)
+	}
+	if daily >= server.config.PasswordResetMaxPerDay {
+		return status.Error(codes.ResourceExhausted, "too many password reset requests today")
+	}
+
+	if clientIp == "" {
+		return nil
+	}
+	dailyPerIp, err := server.store.CountPasswordResetRequestsByClientIp(ctx, db.CountPasswordResetRequestsByClientIpParams{
+		ClientIp: clientIp,
+		Since:    time.Now().Add(-24 * time.Hour),
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to count password reset requests: %s", err)
+	}
+	if dailyPerIp >= server.config.PasswordResetMaxPerIp {
+		return status.Error(codes.ResourceExhausted, "too many password reset requests today")
+	}
+
+	return nil
+}
+
+func validateRequestPasswordResetRequest(req *pb.RequestPasswordResetRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateEmail(req.GetEmail()); err != nil {
+		violations = append(violations, fieldViolation("email", err.Error()))
+	}
+	return violations
+}
@@ -0,0 +1,114 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/chensheep/simple-bank-backend/db/mock"
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestRequestPasswordReset(t *testing.T) {
+	user := randomUser()
+	clientIp := "10.0.0.1"
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CountPasswordResetRequestsByEmail(gomock.Any(), gomock.Any()).Times(2).Return(int64(0), nil)
+				store.EXPECT().CountPasswordResetRequestsByClientIp(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().
+					CreatePasswordResetRequest(gomock.Any(), gomock.Eq(db.CreatePasswordResetRequestParams{
+						Email:    user.Email,
+						ClientIp: clientIp,
+					})).
+					Times(1).
+					Return(db.PasswordResetRequest{}, nil)
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(user, nil)
+				store.EXPECT().CreateOutboxMessage(gomock.Any(), gomock.Any()).Times(1).Return(db.Outbox{}, nil)
+			},
+			checkResponse: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "UnknownEmail",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CountPasswordResetRequestsByEmail(gomock.Any(), gomock.Any()).Times(2).Return(int64(0), nil)
+				store.EXPECT().CountPasswordResetRequestsByClientIp(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().CreatePasswordResetRequest(gomock.Any(), gomock.Any()).Times(1).Return(db.PasswordResetRequest{}, nil)
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().CreateOutboxMessage(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "RequestedRecently",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CountPasswordResetRequestsByEmail(gomock.Any(), gomock.Any()).Times(1).Return(int64(1), nil)
+				store.EXPECT().CreatePasswordResetRequest(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.ResourceExhausted, st.Code())
+			},
+		},
+		{
+			name: "TooManyFromClientIp",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CountPasswordResetRequestsByEmail(gomock.Any(), gomock.Any()).Times(2).Return(int64(0), nil)
+				store.EXPECT().
+					CountPasswordResetRequestsByClientIp(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(3), nil)
+				store.EXPECT().CreatePasswordResetRequest(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.ResourceExhausted, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			store := mockdb.NewMockStore(mockCtrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.config.PasswordResetResendDelay = time.Minute
+			server.config.PasswordResetMaxPerDay = 3
+			server.config.PasswordResetMaxPerIp = 3
+
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{
+				xForwardedForHeader: []string{clientIp},
+			})
+			_, err := server.RequestPasswordReset(ctx, &pb.RequestPasswordResetRequest{Email: user.Email})
+			tc.checkResponse(t, err)
+		})
+	}
+}
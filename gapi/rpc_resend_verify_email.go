@@ -0,0 +1,109 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/chensheep/simple-bank-backend/val"
+	"github.com/chensheep/simple-bank-backend/worker"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ResendVerifyEmail sends a new verification email, to the new address when an email change is pending.
+// It is served by both services: users resend their own and are rate limited, admins resend anyone's
+func (server *Server) ResendVerifyEmail(ctx context.Context, req *pb.ResendVerifyEmailRequest) (*pb.ResendVerifyEmailResponse, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, unathorizedError(err)
+	}
+
+	violations := validateResendVerifyEmailRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	isAdmin := authPayload.Role == util.AdminRole
+	if !isAdmin && authPayload.Username != req.GetUsername() {
+		return nil, status.Error(codes.PermissionDenied, "cannot resend other user's verification email")
+	}
+
+	user, err := server.store.GetUser(ctx, req.GetUsername())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user: %s", err)
+	}
+
+	payload := &worker.SendVerifyEmailPayload{Username: user.Username}
+	if user.PendingEmail.Valid {
+		payload.Email = user.PendingEmail.String
+	} else if user.IsEmailVerified {
+		return nil, status.Error(codes.FailedPrecondition, "email is already verified")
+	}
+
+	if !isAdmin {
+		if err := server.checkVerifyEmailRate(ctx, user.Username); err != nil {
+			return nil, err
+		}
+
+		// the request is counted now, the worker only creates the verify email once the task runs
+		_, err = server.store.CreateVerifyEmailRequest(ctx, user.Username)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to record verify email request: %s", err)
+		}
+	}
+
+	verifyEmailMessage, err := worker.NewOutboxMessage(worker.TaskSendVerifyEmail, payload, worker.QueueCritical, 10)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create verify email task: %s", err)
+	}
+
+	_, err = server.store.CreateOutboxMessage(ctx, verifyEmailMessage)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to save verify email task: %s", err)
+	}
+
+	return &pb.ResendVerifyEmailResponse{}, nil
+}
+
+// checkVerifyEmailRate allows a new verification email once the resend delay has passed
+// since the last request of the user, and up to the daily maximum
+func (server *Server) checkVerifyEmailRate(ctx context.Context, username string) error {
+	recent, err := server.store.CountVerifyEmailRequests(ctx, db.CountVerifyEmailRequestsParams{
+		Username: username,
+		Since:    time.Now().Add(-server.config.VerifyEmailResendDelay),
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to count verify email requests: %s", err)
+	}
+	if recent > 0 {
+		return status.Errorf(codes.ResourceExhausted, "a verification email was requested less than %s ago", server.config.VerifyEmailResendDelay)
+	}
+
+	daily, err := server.store.CountVerifyEmailRequests(ctx, db.CountVerifyEmailRequestsParams{
+		Username: username,
+		Since:    time.Now().Add(-24 * time.Hour),
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to count verify email requests: %s", err)
+	}
+	if daily >= server.config.VerifyEmailMaxPerDay {
+		return status.Error(codes.ResourceExhausted, "too many verification emails today")
+	}
+
+	return nil
+}
+
+func validateResendVerifyEmailRequest(req *pb.ResendVerifyEmailRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateUsername(req.GetUsername()); err != nil {
+		violations = append(violations, fieldViolation("username", err.Error()))
+	}
+	return violations
+}
@@ -0,0 +1,122 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mockdb "github.com/chensheep/simple-bank-backend/db/mock"
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestResendVerifyEmail(t *testing.T) {
+	user := randomUser()
+	user.IsEmailVerified = false
+
+	testCases := []struct {
+		name          string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, err error)
+	}{
+		{
+			name: "OK",
+			role: util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().CountVerifyEmailRequests(gomock.Any(), gomock.Any()).Times(2).Return(int64(0), nil)
+				store.EXPECT().
+					CreateVerifyEmailRequest(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.VerifyEmailRequest{Username: user.Username}, nil)
+				store.EXPECT().CreateOutboxMessage(gomock.Any(), gomock.Any()).Times(1).Return(db.Outbox{}, nil)
+			},
+			checkResponse: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "RequestedRecently",
+			role: util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().CountVerifyEmailRequests(gomock.Any(), gomock.Any()).Times(1).Return(int64(1), nil)
+				store.EXPECT().CreateVerifyEmailRequest(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateOutboxMessage(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.ResourceExhausted, st.Code())
+			},
+		},
+		{
+			name: "TooManyToday",
+			role: util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				gomock.InOrder(
+					store.EXPECT().CountVerifyEmailRequests(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil),
+					store.EXPECT().CountVerifyEmailRequests(gomock.Any(), gomock.Any()).Times(1).Return(int64(3), nil),
+				)
+				store.EXPECT().CreateVerifyEmailRequest(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateOutboxMessage(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.ResourceExhausted, st.Code())
+			},
+		},
+		{
+			name: "AdminNotLimited",
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().CountVerifyEmailRequests(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateVerifyEmailRequest(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateOutboxMessage(gomock.Any(), gomock.Any()).Times(1).Return(db.Outbox{}, nil)
+			},
+			checkResponse: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			store := mockdb.NewMockStore(mockCtrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.config.VerifyEmailResendDelay = time.Minute
+			server.config.VerifyEmailMaxPerDay = 3
+
+			username := user.Username
+			if tc.role == util.AdminRole {
+				username = util.RandomOwner()
+			}
+			ctx := newContextWithBearerToken(t, server.tokenMaker, username, tc.role, time.Minute)
+			req := &pb.ResendVerifyEmailRequest{Username: user.Username}
+
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBankService_ResendVerifyEmail_FullMethodName}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.ResendVerifyEmail(ctx, req.(*pb.ResendVerifyEmailRequest))
+			}
+			_, err := server.AuthUnaryInterceptor(ctx, req, info, handler)
+			tc.checkResponse(t, err)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RevokeUserSessions blocks all the sessions of the user,
+// the access tokens already issued stay valid until they expire
+func (server *Server) RevokeUserSessions(ctx context.Context, req *pb.RevokeUserSessionsRequest) (*pb.RevokeUserSessionsResponse, error) {
+	violations := validateRevokeUserSessionsRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	count, err := server.store.BlockUserSessions(ctx, req.GetUsername())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to revoke sessions: %s", err)
+	}
+
+	rsp := &pb.RevokeUserSessionsResponse{
+		RevokedCount: count,
+	}
+
+	return rsp, nil
+}
+
+func validateRevokeUserSessionsRequest(req *pb.RevokeUserSessionsRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateUsername(req.GetUsername()); err != nil {
+		violations = append(violations, fieldViolation("username", err.Error()))
+	}
+	return violations
+}
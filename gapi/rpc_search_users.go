@@ -0,0 +1,52 @@
+package gapi
+
+import (
+	"context"
+	"strings"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// likeEscaper escapes the wildcards of a LIKE pattern, so the query is matched literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (server *Server) SearchUsers(ctx context.Context, req *pb.SearchUsersRequest) (*pb.SearchUsersResponse, error) {
+	violations := validateSearchUsersRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	users, err := server.store.SearchUsers(ctx, db.SearchUsersParams{
+		Pattern: "%" + likeEscaper.Replace(req.GetQuery()) + "%",
+		Limit:   req.GetPageSize(),
+		Offset:  (req.GetPageId() - 1) * req.GetPageSize(),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to search users: %s", err)
+	}
+
+	rsp := &pb.SearchUsersResponse{}
+	for _, user := range users {
+		rsp.Users = append(rsp.Users, convertUser(user))
+	}
+
+	return rsp, nil
+}
+
+func validateSearchUsersRequest(req *pb.SearchUsersRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateSearchQuery(req.GetQuery()); err != nil {
+		violations = append(violations, fieldViolation("query", err.Error()))
+	}
+	if err := val.ValidatePageID(req.GetPageId()); err != nil {
+		violations = append(violations, fieldViolation("page_id", err.Error()))
+	}
+	if err := val.ValidatePageSize(req.GetPageSize()); err != nil {
+		violations = append(violations, fieldViolation("page_size", err.Error()))
+	}
+	return violations
+}
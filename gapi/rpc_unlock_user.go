@@ -0,0 +1,51 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnlockUser lifts the lock put on the username after too many failed logins
+// with the code emailed to the user, the failures of the username are forgotten too
+func (server *Server) UnlockUser(ctx context.Context, req *pb.UnlockUserRequest) (*pb.UnlockUserResponse, error) {
+	violations := validateUnlockUserReq(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	_, err := server.store.UnlockLoginAttempt(ctx, db.UnlockLoginAttemptParams{
+		Scope:      db.LoginScopeUsername,
+		Identifier: req.GetUsername(),
+		UnlockCode: sql.NullString{String: req.GetUnlockCode(), Valid: true},
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Error(codes.NotFound, "invalid or expired unlock code")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to unlock user: %s", err)
+	}
+
+	rsp := &pb.UnlockUserResponse{
+		IsUnlocked: true,
+	}
+
+	return rsp, nil
+}
+
+func validateUnlockUserReq(req *pb.UnlockUserRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateUsername(req.GetUsername()); err != nil {
+		violations = append(violations, fieldViolation("username", err.Error()))
+	}
+	if err := val.ValidateSecretCode(req.GetUnlockCode()); err != nil {
+		violations = append(violations, fieldViolation("unlock_code", err.Error()))
+	}
+
+	return violations
+}
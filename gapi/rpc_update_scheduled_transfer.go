@@ -0,0 +1,88 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/chensheep/simple-bank-backend/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) UpdateScheduledTransfer(ctx context.Context, req *pb.UpdateScheduledTransferRequest) (*pb.UpdateScheduledTransferResponse, error) {
+	authPayload, err := authPayloadFromContext(ctx)
+	if err != nil {
+		return nil, unathorizedError(err)
+	}
+
+	violations := validateUpdateScheduledTransferRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	scheduledTransfer, err := server.getOwnScheduledTransfer(ctx, authPayload, req.GetScheduledTransferId())
+	if err != nil {
+		return nil, err
+	}
+
+	arg := db.UpdateScheduledTransferParams{
+		ID: scheduledTransfer.ID,
+		IsActive: sql.NullBool{
+			Bool:  req.GetIsActive(),
+			Valid: req.IsActive != nil,
+		},
+	}
+
+	if req.Amount != nil {
+		fromAccount, err := server.store.GetAccount(ctx, scheduledTransfer.FromAccountID)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to get account: %s", err)
+		}
+		if err := server.checkStepUp(fromAccount.Currency, req.GetAmount()); err != nil {
+			return nil, err
+		}
+		arg.Amount = sql.NullInt64{Int64: req.GetAmount(), Valid: true}
+	}
+
+	// a new schedule, or resuming a paused one, starts from now instead of catching up
+	schedule := scheduledTransfer.Schedule
+	if req.Schedule != nil {
+		schedule = req.GetSchedule()
+		arg.Schedule = sql.NullString{String: schedule, Valid: true}
+	}
+	if req.Schedule != nil || (req.GetIsActive() && !scheduledTransfer.IsActive) {
+		nextRunAt, err := util.NextScheduleTime(schedule, time.Now())
+		if err != nil {
+			return nil, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{fieldViolation("schedule", err.Error())})
+		}
+		arg.NextRunAt = sql.NullTime{Time: nextRunAt, Valid: true}
+	}
+
+	scheduledTransfer, err = server.store.UpdateScheduledTransfer(ctx, arg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to update scheduled transfer: %s", err)
+	}
+
+	rsp := &pb.UpdateScheduledTransferResponse{
+		ScheduledTransfer: convertScheduledTransfer(scheduledTransfer),
+	}
+
+	return rsp, nil
+}
+
+func validateUpdateScheduledTransferRequest(req *pb.UpdateScheduledTransferRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetScheduledTransferId()); err != nil {
+		violations = append(violations, fieldViolation("scheduled_transfer_id", err.Error()))
+	}
+	if req.Amount != nil {
+		if err := val.ValidateTransferAmount(req.GetAmount()); err != nil {
+			violations = append(violations, fieldViolation("amount", err.Error()))
+		}
+	}
+	return violations
+}
@@ -0,0 +1,148 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/chensheep/simple-bank-backend/db/mock"
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestUpdateScheduledTransfer(t *testing.T) {
+	user := randomUser()
+	account := randomAccount(user.Username, util.USD)
+	scheduledTransfer := db.ScheduledTransfer{
+		ID:            util.RandomInt(1, 1000),
+		Owner:         user.Username,
+		FromAccountID: account.ID,
+		ToAccountID:   account.ID + 1,
+		Amount:        10,
+		Schedule:      "@every 24h",
+		NextRunAt:     time.Now().Add(time.Hour),
+	}
+
+	testCases := []struct {
+		name          string
+		req           *pb.UpdateScheduledTransferRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.UpdateScheduledTransferResponse, err error)
+	}{
+		{
+			name: "Resume",
+			req: &pb.UpdateScheduledTransferRequest{
+				ScheduledTransferId: scheduledTransfer.ID,
+				IsActive:            proto.Bool(true),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduledTransfer.ID)).Times(1).Return(scheduledTransfer, nil)
+				store.EXPECT().
+					UpdateScheduledTransfer(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateScheduledTransferParams) (db.ScheduledTransfer, error) {
+						require.Equal(t, sql.NullBool{Bool: true, Valid: true}, arg.IsActive)
+						require.False(t, arg.Amount.Valid)
+						require.True(t, arg.NextRunAt.Valid)
+
+						updated := scheduledTransfer
+						updated.IsActive = true
+						updated.NextRunAt = arg.NextRunAt.Time
+						return updated, nil
+					})
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateScheduledTransferResponse, err error) {
+				require.NoError(t, err)
+				require.True(t, res.GetScheduledTransfer().GetIsActive())
+			},
+		},
+		{
+			name: "StepUpRequired",
+			req: &pb.UpdateScheduledTransferRequest{
+				ScheduledTransferId: scheduledTransfer.ID,
+				Amount:              proto.Int64(500),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduledTransfer.ID)).Times(1).Return(scheduledTransfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateScheduledTransferResponse, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name: "OtherUser",
+			req: &pb.UpdateScheduledTransferRequest{
+				ScheduledTransferId: scheduledTransfer.ID,
+				Amount:              proto.Int64(20),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				other := scheduledTransfer
+				other.Owner = util.RandomOwner()
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduledTransfer.ID)).Times(1).Return(other, nil)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateScheduledTransferResponse, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name: "InvalidSchedule",
+			req: &pb.UpdateScheduledTransferRequest{
+				ScheduledTransferId: scheduledTransfer.ID,
+				Schedule:            proto.String("every day"),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduledTransfer.ID)).Times(1).Return(scheduledTransfer, nil)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateScheduledTransferResponse, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			store := mockdb.NewMockStore(mockCtrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.stepUpThresholds = map[string]int64{util.USD: 500}
+			ctx := newContextWithBearerToken(t, server.tokenMaker, user.Username, user.Role, time.Minute)
+
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBankService_UpdateScheduledTransfer_FullMethodName}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.UpdateScheduledTransfer(ctx, req.(*pb.UpdateScheduledTransferRequest))
+			}
+			res, err := server.AuthUnaryInterceptor(ctx, tc.req, info, handler)
+
+			rsp, _ := res.(*pb.UpdateScheduledTransferResponse)
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
@@ -9,13 +9,14 @@ import (
 	"github.com/chensheep/simple-bank-backend/pb"
 	"github.com/chensheep/simple-bank-backend/util"
 	"github.com/chensheep/simple-bank-backend/val"
+	"github.com/chensheep/simple-bank-backend/worker"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
 func (server *Server) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
-	authPayload, err := server.authorizeUser(ctx)
+	authPayload, err := authPayloadFromContext(ctx)
 	if err != nil {
 		return nil, unathorizedError(err)
 	}
@@ -29,19 +30,13 @@ func (server *Server) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest)
 		return nil, status.Error(codes.PermissionDenied, "cannot update other user's info")
 	}
 
-	if authPayload.Username != req.GetUsername() {
-		return nil, status.Error(codes.PermissionDenied, "cannot update other user's info")
-	}
-
-	arg := db.UpdateUserParams{
-		Username: req.GetUsername(),
-		FullName: sql.NullString{
-			String: req.GetFullName(),
-			Valid:  req.FullName != nil,
-		},
-		Email: sql.NullString{
-			String: req.GetEmail(),
-			Valid:  req.Email != nil,
+	arg := db.UpdateUserTxParams{
+		UpdateUserParams: db.UpdateUserParams{
+			Username: req.GetUsername(),
+			FullName: sql.NullString{
+				String: req.GetFullName(),
+				Valid:  req.FullName != nil,
+			},
 		},
 	}
 
@@ -50,17 +45,24 @@ func (server *Server) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest)
 		if err != nil {
 			return nil, status.Errorf(codes.Internal, "failed to hash password: %s", err)
 		}
-		arg.HashedPassword = sql.NullString{
+		arg.UpdateUserParams.HashedPassword = sql.NullString{
 			String: hashedPassword,
 			Valid:  true,
 		}
-		arg.PasswordChangedAt = sql.NullTime{
+		arg.UpdateUserParams.PasswordChangedAt = sql.NullTime{
 			Time:  time.Now(),
 			Valid: true,
 		}
 	}
 
-	user, err := server.store.UpdateUser(ctx, arg)
+	if req.Email != nil {
+		err = server.changeEmail(ctx, &arg, req.GetEmail())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := server.store.UpdateUserTx(ctx, arg)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, status.Error(codes.NotFound, "user not found")
@@ -69,12 +71,55 @@ func (server *Server) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest)
 	}
 
 	rsp := &pb.UpdateUserResponse{
-		User: convertUser(user),
+		User: convertUser(result.User),
 	}
 
 	return rsp, nil
 }
 
+// changeEmail stores the new email as pending and sends the link to confirm it to the new address,
+// with a notice to the current one. Asking for the current email cancels a pending change
+func (server *Server) changeEmail(ctx context.Context, arg *db.UpdateUserTxParams, email string) error {
+	user, err := server.store.GetUser(ctx, arg.UpdateUserParams.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return status.Error(codes.NotFound, "user not found")
+		}
+		return status.Errorf(codes.Internal, "failed to get user: %s", err)
+	}
+
+	arg.ChangeEmail = true
+	if email == user.Email {
+		return nil
+	}
+
+	other, err := server.store.GetUserByEmail(ctx, email)
+	if err == nil && other.Username != user.Username {
+		return status.Error(codes.AlreadyExists, "email is already in use")
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return status.Errorf(codes.Internal, "failed to get user by email: %s", err)
+	}
+
+	verifyEmailMessage, err := worker.NewOutboxMessage(worker.TaskSendVerifyEmail,
+		&worker.SendVerifyEmailPayload{Username: user.Username, Email: email}, worker.QueueCritical, 10)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to create verify email task: %s", err)
+	}
+	noticeMessage, err := worker.NewOutboxMessage(worker.TaskSendEmailChangeNotice, &worker.SendEmailChangeNoticePayload{
+		Username: user.Username,
+		OldEmail: user.Email,
+		NewEmail: email,
+	}, worker.QueueCritical, 10)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to create email change notice task: %s", err)
+	}
+
+	arg.PendingEmail = sql.NullString{String: email, Valid: true}
+	arg.OutboxMessages = []db.CreateOutboxMessageParams{verifyEmailMessage, noticeMessage}
+	return nil
+}
+
 func validateUpdateUserRequest(req *pb.UpdateUserRequest) (violations []*errdetails.BadRequest_FieldViolation) {
 	if err := val.ValidateUsername(req.GetUsername()); err != nil {
 		violations = append(violations, fieldViolation("username", err.Error()))
@@ -0,0 +1,68 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/chensheep/simple-bank-backend/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UpsertExchangeRates replaces the given rates all at once,
+// with the same checks as the rates loaded from EXCHANGE_RATES_FILE
+func (server *Server) UpsertExchangeRates(ctx context.Context, req *pb.UpsertExchangeRatesRequest) (*pb.UpsertExchangeRatesResponse, error) {
+	violations := validateUpsertExchangeRatesRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	arg := db.UpsertExchangeRatesTxParams{}
+	for _, rate := range req.GetRates() {
+		arg.Rates = append(arg.Rates, db.UpsertExchangeRateParams{
+			FromCurrency: strings.ToUpper(rate.GetFromCurrency()),
+			ToCurrency:   strings.ToUpper(rate.GetToCurrency()),
+			Rate:         strings.TrimSpace(rate.GetRate()),
+		})
+	}
+
+	result, err := server.store.UpsertExchangeRatesTx(ctx, arg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to save exchange rates: %s", err)
+	}
+
+	rsp := &pb.UpsertExchangeRatesResponse{}
+	for _, rate := range result.Rates {
+		rsp.Rates = append(rsp.Rates, convertExchangeRate(rate))
+	}
+
+	return rsp, nil
+}
+
+func validateUpsertExchangeRatesRequest(req *pb.UpsertExchangeRatesRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if len(req.GetRates()) == 0 {
+		violations = append(violations, fieldViolation("rates", "must not be empty"))
+	}
+	for i, rate := range req.GetRates() {
+		from := strings.ToUpper(rate.GetFromCurrency())
+		to := strings.ToUpper(rate.GetToCurrency())
+		if err := val.ValidateCurrency(from); err != nil {
+			violations = append(violations, fieldViolation(fmt.Sprintf("rates[%d].from_currency", i), err.Error()))
+		}
+		if err := val.ValidateCurrency(to); err != nil {
+			violations = append(violations, fieldViolation(fmt.Sprintf("rates[%d].to_currency", i), err.Error()))
+		}
+		if from == to {
+			violations = append(violations, fieldViolation(fmt.Sprintf("rates[%d].to_currency", i), "must differ from from_currency"))
+		}
+		if _, err := util.ConvertAmount(1, strings.TrimSpace(rate.GetRate())); err != nil {
+			violations = append(violations, fieldViolation(fmt.Sprintf("rates[%d].rate", i), err.Error()))
+		}
+	}
+	return violations
+}
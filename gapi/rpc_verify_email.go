@@ -2,10 +2,12 @@ package gapi
 
 import (
 	"context"
+	"errors"
 
 	db "github.com/chensheep/simple-bank-backend/db/sqlc"
 	"github.com/chensheep/simple-bank-backend/pb"
 	"github.com/chensheep/simple-bank-backend/val"
+	"github.com/lib/pq"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -22,6 +24,12 @@ func (server *Server) VerifyEmail(ctx context.Context, req *pb.VerifyEmailReques
 		SecretCode: req.GetSecretCode(),
 	})
 	if err != nil {
+		if errors.Is(err, db.ErrVerifyEmailOutdated) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return nil, status.Error(codes.AlreadyExists, "email is already in use")
+		}
 		return nil, status.Errorf(codes.Internal, "cannot verify email: %v", err)
 	}
 
@@ -0,0 +1,37 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/val"
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// VerifyLoginTotp finishes the login started by LoginUser for a user with two-factor authentication.
+// Wrong codes count as failed logins of the user, and use up the challenge after a few tries
+func (server *Server) VerifyLoginTotp(ctx context.Context, req *pb.VerifyLoginTotpRequest) (*pb.LoginUserResponse, error) {
+	violations := validateVerifyLoginTotpRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	md := server.ExtractMetadata(ctx)
+	user, err := server.authenticator.VerifyChallenge(ctx, uuid.MustParse(req.GetChallengeToken()), req.GetCode(), md.ClientIp)
+	if err != nil {
+		return nil, loginError(err)
+	}
+
+	return server.loginSession(ctx, user, md)
+}
+
+func validateVerifyLoginTotpRequest(req *pb.VerifyLoginTotpRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateSessionID(req.GetChallengeToken()); err != nil {
+		violations = append(violations, fieldViolation("challenge_token", err.Error()))
+	}
+	if err := val.ValidateSecondFactorCode(req.GetCode()); err != nil {
+		violations = append(violations, fieldViolation("code", err.Error()))
+	}
+	return violations
+}
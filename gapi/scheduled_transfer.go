@@ -0,0 +1,28 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/token"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// getOwnScheduledTransfer loads the scheduled transfer and checks it belongs to the authenticated user
+func (server *Server) getOwnScheduledTransfer(ctx context.Context, authPayload *token.Payload, id int64) (db.ScheduledTransfer, error) {
+	scheduledTransfer, err := server.store.GetScheduledTransfer(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return scheduledTransfer, status.Error(codes.NotFound, "scheduled transfer not found")
+		}
+		return scheduledTransfer, status.Errorf(codes.Internal, "failed to get scheduled transfer: %s", err)
+	}
+
+	if authPayload.Username != scheduledTransfer.Owner {
+		return scheduledTransfer, status.Error(codes.PermissionDenied, "cannot access other user's scheduled transfer")
+	}
+
+	return scheduledTransfer, nil
+}
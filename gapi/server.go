@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/login"
 	"github.com/chensheep/simple-bank-backend/pb"
 	"github.com/chensheep/simple-bank-backend/util"
 	"github.com/chensheep/simple-bank-backend/worker"
@@ -11,25 +12,42 @@ import (
 	"github.com/chensheep/simple-bank-backend/token"
 )
 
+// Server serves both the SimpleBankService and the AdminService
 type Server struct {
 	pb.UnimplementedSimpleBankServiceServer
+	pb.UnimplementedAdminServiceServer
 	config          util.Config
 	store           db.Store
 	tokenMaker      token.Maker
 	taskDistributor worker.TaskDistrubutor
+	authenticator   *login.Authenticator
+	// stepUpThresholds is the amount per currency from which a transfer needs a second factor
+	stepUpThresholds map[string]int64
 }
 
 func NewServer(config util.Config, store db.Store, taskDistributor worker.TaskDistrubutor) (*Server, error) {
+	err := checkMethodPolicies(pb.SimpleBankService_ServiceDesc, pb.AdminService_ServiceDesc)
+	if err != nil {
+		return nil, err
+	}
+
 	tokenMaker, err := token.NewJWTMaker(config.TokenSymmetricKey)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
 
+	stepUpThresholds, err := util.ParseCurrencyAmounts(config.StepUpThresholds)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse step-up thresholds: %w", err)
+	}
+
 	server := &Server{
-		config:          config,
-		store:           store,
-		tokenMaker:      tokenMaker,
-		taskDistributor: taskDistributor,
+		config:           config,
+		store:            store,
+		tokenMaker:       tokenMaker,
+		taskDistributor:  taskDistributor,
+		authenticator:    login.NewAuthenticator(store, config),
+		stepUpThresholds: stepUpThresholds,
 	}
 
 	return server, nil
@@ -0,0 +1,55 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/token"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// verifySession checks the refresh token belongs to a session which is neither revoked nor expired
+func (server *Server) verifySession(ctx context.Context, refreshToken string) (*token.Payload, db.Session, error) {
+	payload, err := server.tokenMaker.VerifyToken(refreshToken)
+	if err != nil {
+		return nil, db.Session{}, status.Errorf(codes.Unauthenticated, "invalid refresh token: %s", err)
+	}
+
+	session, err := server.store.GetSession(ctx, payload.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, session, status.Error(codes.NotFound, "session not found")
+		}
+		return nil, session, status.Errorf(codes.Internal, "failed to get session: %s", err)
+	}
+
+	if session.IsBlocked {
+		return nil, session, status.Error(codes.Unauthenticated, "session is blocked")
+	}
+	if session.Username != payload.Username {
+		return nil, session, status.Error(codes.Unauthenticated, "incorrect session user")
+	}
+	if session.RefreshToken != refreshToken {
+		return nil, session, status.Error(codes.Unauthenticated, "mismatch session token")
+	}
+	if time.Now().After(session.ExpiredAt) {
+		return nil, session, status.Error(codes.Unauthenticated, "session is expired")
+	}
+
+	return payload, session, nil
+}
+
+func convertSession(session db.Session) *pb.Session {
+	return &pb.Session{
+		Id:        session.ID.String(),
+		UserAgent: session.UserAgent,
+		ClientIp:  session.ClientIp,
+		CreatedAt: timestamppb.New(session.CreatedAt),
+		ExpiredAt: timestamppb.New(session.ExpiredAt),
+	}
+}
@@ -0,0 +1,100 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/pb"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/chensheep/simple-bank-backend/worker"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// transferChallengeMaxFailures is how many wrong codes a pending transfer takes before it is cancelled
+const transferChallengeMaxFailures = 5
+
+// requiresStepUp tells whether the transfer reaches the step-up threshold of its currency,
+// currencies without a threshold never need a second factor
+func (server *Server) requiresStepUp(currency string, amount int64) bool {
+	threshold, ok := server.stepUpThresholds[currency]
+	return ok && amount >= threshold
+}
+
+// createPendingTransfer holds the amount of a transfer over the step-up threshold until the user confirms it
+// with ConfirmTransfer. Users with two-factor authentication answer with a totp code, the others with a code
+// sent by email along with the amount and the destination
+func (server *Server) createPendingTransfer(ctx context.Context, username string, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	arg := db.CreatePendingTransferTxParams{
+		ChallengeID:    uuid.New(),
+		Username:       username,
+		FromAccountID:  req.GetFromAccountId(),
+		ToAccountID:    req.GetToAccountId(),
+		Amount:         req.GetAmount(),
+		IdempotencyKey: req.GetIdempotencyKey(),
+		Method:         db.TransferChallengeMethodTotp,
+		ExpiredAt:      time.Now().Add(server.config.StepUpDuration),
+	}
+
+	userTotp, err := server.store.GetUserTotp(ctx, username)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, status.Errorf(codes.Internal, "failed to get user totp: %s", err)
+	}
+	if err == sql.ErrNoRows || !userTotp.ConfirmedAt.Valid {
+		code, err := util.NewEmailCode()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to generate transfer code: %s", err)
+		}
+
+		codeEmail, err := worker.NewOutboxMessage(worker.TaskSendTransferCode, &worker.SendTransferCodePayload{
+			Username:      username,
+			Code:          code,
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   arg.ToAccountID,
+			Amount:        arg.Amount,
+			Currency:      req.GetCurrency(),
+			ExpiredAt:     arg.ExpiredAt,
+		}, worker.QueueCritical, 10)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to create transfer code task: %s", err)
+		}
+
+		arg.Method = db.TransferChallengeMethodEmail
+		arg.HashedCode = util.HashEmailCode(code)
+		arg.OutboxMessages = []db.CreateOutboxMessageParams{codeEmail}
+	}
+
+	result, err := server.store.CreatePendingTransferTx(ctx, arg)
+	if err != nil {
+		return nil, transferError(err)
+	}
+
+	// a retry of a transfer confirmed already gets the transfer made
+	if result.Confirmed {
+		return convertTransferTxResult(result.Transfer), nil
+	}
+
+	rsp := &pb.CreateTransferResponse{
+		FromAccount:        convertAccount(result.FromAccount),
+		StepUpRequired:     true,
+		ChallengeId:        result.TransferChallenge.ID.String(),
+		StepUpMethod:       result.TransferChallenge.Method,
+		ChallengeExpiredAt: timestamppb.New(result.TransferChallenge.ExpiredAt),
+	}
+
+	return rsp, nil
+}
+
+// checkStepUp rejects the amounts over the step-up threshold moved by the methods which can't hold them
+// for a challenge: exchange quotes, batch transfers, and scheduled transfers, checked when they are set up
+// since nobody is there to answer a challenge when they run. Those amounts are sent with CreateTransfer
+func (server *Server) checkStepUp(currency string, amount int64) error {
+	if server.requiresStepUp(currency, amount) {
+		return status.Errorf(codes.PermissionDenied, "%d %s needs a second factor, send it with CreateTransfer", amount, currency)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/chensheep/simple-bank-backend/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// totpIssuer is the name the authenticator apps show the codes under
+	totpIssuer = "SimpleBank"
+	// recoveryCodeCount is how many recovery codes are issued when two-factor authentication is enabled
+	recoveryCodeCount = 10
+)
+
+// createLoginChallenge answers a login with the right password of a user with two-factor authentication,
+// the tokens are only created once the challenge is answered with a code by VerifyLoginTotp
+func (server *Server) createLoginChallenge(ctx context.Context, username string) (*pb.LoginUserResponse, error) {
+	challenge, err := server.authenticator.CreateChallenge(ctx, username)
+	if err != nil {
+		return nil, loginError(err)
+	}
+
+	rsp := &pb.LoginUserResponse{
+		TotpRequired:       true,
+		ChallengeToken:     challenge.ID.String(),
+		ChallengeExpiredAt: timestamppb.New(challenge.ExpiredAt),
+	}
+
+	return rsp, nil
+}
@@ -0,0 +1,138 @@
+package login
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/chensheep/simple-bank-backend/worker"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// baseDelay is the wait after the first failed login, it doubles with every failure
+	baseDelay = time.Second
+	maxDelay  = 30 * time.Second
+)
+
+// LockedError rejects a login while the username or the client ip is locked,
+// or is still waiting out the delay of its last failure
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, try again in %s", e.RetryAfter.Round(time.Second))
+}
+
+// Delay is how long a login is rejected for after failedCount failures in a row
+func Delay(failedCount int32) time.Duration {
+	if failedCount <= 0 {
+		return 0
+	}
+	delay := baseDelay
+	for i := int32(1); i < failedCount && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// CheckAttempts returns a *LockedError while the username or the client ip is locked out.
+// Unknown usernames are tracked like the others, so the answer doesn't tell whether the username exists
+func (authenticator *Authenticator) CheckAttempts(ctx context.Context, username string, clientIp string) error {
+	scopes := []db.GetLoginAttemptParams{
+		{Scope: db.LoginScopeUsername, Identifier: username},
+	}
+	if clientIp != "" {
+		scopes = append(scopes, db.GetLoginAttemptParams{Scope: db.LoginScopeClientIp, Identifier: clientIp})
+	}
+
+	now := time.Now()
+	windowStart := now.Add(-authenticator.config.LoginLockoutDuration)
+	var retryAt time.Time
+	for _, arg := range scopes {
+		attempt, err := authenticator.store.GetLoginAttempt(ctx, arg)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			return fmt.Errorf("failed to get login attempts: %w", err)
+		}
+
+		if attempt.LockedUntil.Valid && attempt.LockedUntil.Time.After(retryAt) {
+			retryAt = attempt.LockedUntil.Time
+		}
+		if attempt.LastFailedAt.After(windowStart) {
+			if delayedUntil := attempt.LastFailedAt.Add(Delay(attempt.FailedCount)); delayedUntil.After(retryAt) {
+				retryAt = delayedUntil
+			}
+		}
+	}
+
+	if retryAt.After(now) {
+		return &LockedError{RetryAfter: retryAt.Sub(now)}
+	}
+	return nil
+}
+
+// RecordFailure counts the failed login. When it locks the username of an existing user,
+// an email with the code to unlock it is sent to the user
+func (authenticator *Authenticator) RecordFailure(ctx context.Context, username string, clientIp string, userExists bool) error {
+	unlockCode, err := util.NewSecretCode()
+	if err != nil {
+		return fmt.Errorf("failed to generate unlock code: %w", err)
+	}
+
+	arg := db.RecordLoginFailureTxParams{
+		Username:            username,
+		ClientIp:            clientIp,
+		WindowStart:         time.Now().Add(-authenticator.config.LoginLockoutDuration),
+		MaxUsernameFailures: authenticator.config.LoginMaxFailures,
+		MaxClientIpFailures: authenticator.config.LoginMaxFailuresPerIp,
+		LockedUntil:         time.Now().Add(authenticator.config.LoginLockoutDuration),
+		UnlockCode:          unlockCode,
+	}
+
+	if userExists {
+		unlockEmail, err := worker.NewOutboxMessage(worker.TaskSendUnlockEmail, &worker.SendUnlockEmailPayload{
+			Username:    username,
+			UnlockCode:  arg.UnlockCode,
+			LockedUntil: arg.LockedUntil,
+		}, worker.QueueCritical, 10)
+		if err != nil {
+			return fmt.Errorf("failed to create unlock email task: %w", err)
+		}
+		arg.LockOutboxMessages = []db.CreateOutboxMessageParams{unlockEmail}
+	}
+
+	result, err := authenticator.store.RecordLoginFailureTx(ctx, arg)
+	if err != nil {
+		return fmt.Errorf("failed to record failed login: %w", err)
+	}
+
+	if result.UsernameLocked {
+		log.Warn().Str("username", username).Str("client_ip", clientIp).
+			Time("locked_until", arg.LockedUntil).Msg("username locked after too many failed logins")
+	}
+	return nil
+}
+
+// ResetAttempts forgets the failed logins of the username once the user has logged in. The failures
+// of the client ip are kept, so logging in to one account doesn't reset the count of the ip guessing
+// the passwords of others
+func (authenticator *Authenticator) ResetAttempts(ctx context.Context, username string) error {
+	err := authenticator.store.DeleteLoginAttempt(ctx, db.DeleteLoginAttemptParams{
+		Scope:      db.LoginScopeUsername,
+		Identifier: username,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reset login attempts: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package login
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	mockdb "github.com/chensheep/simple-bank-backend/db/mock"
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelay(t *testing.T) {
+	require.Equal(t, time.Duration(0), Delay(0))
+	require.Equal(t, time.Second, Delay(1))
+	require.Equal(t, 2*time.Second, Delay(2))
+	require.Equal(t, 16*time.Second, Delay(5))
+	require.Equal(t, maxDelay, Delay(6))
+	require.Equal(t, maxDelay, Delay(100))
+}
+
+func TestCheckAttempts(t *testing.T) {
+	username := util.RandomOwner()
+	clientIp := "192.0.2.1"
+
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name: "NoFailures",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginAttempt{}, sql.ErrNoRows)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "Delayed",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginAttempt(gomock.Any(), gomock.Eq(db.GetLoginAttemptParams{Scope: db.LoginScopeUsername, Identifier: username})).
+					Times(1).
+					Return(db.LoginAttempt{FailedCount: 3, LastFailedAt: time.Now()}, nil)
+				store.EXPECT().
+					GetLoginAttempt(gomock.Any(), gomock.Eq(db.GetLoginAttemptParams{Scope: db.LoginScopeClientIp, Identifier: clientIp})).
+					Times(1).
+					Return(db.LoginAttempt{}, sql.ErrNoRows)
+			},
+			checkError: func(t *testing.T, err error) {
+				var lockedErr *LockedError
+				require.ErrorAs(t, err, &lockedErr)
+				require.InDelta(t, Delay(3), lockedErr.RetryAfter, float64(time.Second))
+			},
+		},
+		{
+			name: "ClientIpLocked",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginAttempt(gomock.Any(), gomock.Eq(db.GetLoginAttemptParams{Scope: db.LoginScopeUsername, Identifier: username})).
+					Times(1).
+					Return(db.LoginAttempt{}, sql.ErrNoRows)
+				store.EXPECT().
+					GetLoginAttempt(gomock.Any(), gomock.Eq(db.GetLoginAttemptParams{Scope: db.LoginScopeClientIp, Identifier: clientIp})).
+					Times(1).
+					Return(db.LoginAttempt{
+						FailedCount:  50,
+						LastFailedAt: time.Now().Add(-time.Hour),
+						LockedUntil:  sql.NullTime{Time: time.Now().Add(10 * time.Minute), Valid: true},
+					}, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				var lockedErr *LockedError
+				require.ErrorAs(t, err, &lockedErr)
+				require.Greater(t, lockedErr.RetryAfter, 9*time.Minute)
+			},
+		},
+		{
+			name: "ExpiredFailures",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginAttempt{
+						FailedCount:  3,
+						LastFailedAt: time.Now().Add(-time.Hour),
+						LockedUntil:  sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true},
+					}, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.LoginAttempt{}, sql.ErrConnDone)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+				var lockedErr *LockedError
+				require.False(t, errors.As(err, &lockedErr))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			authenticator := NewAuthenticator(store, util.Config{LoginLockoutDuration: 15 * time.Minute})
+			err := authenticator.CheckAttempts(context.Background(), username, clientIp)
+			tc.checkError(t, err)
+		})
+	}
+}
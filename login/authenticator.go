@@ -0,0 +1,70 @@
+package login
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/util"
+)
+
+// dummyPasswordHash is checked when the user doesn't exist,
+// so an unknown username takes as long to reject as a wrong password
+const dummyPasswordHash = "$2a$10$isuSL2jmMTZKlgyOv97Jqu5ZZVellZF7JzTRskEtcv5uB/RIzgdOW"
+
+var (
+	// ErrInvalidCredentials is returned for an unknown username and a wrong password alike
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUserFrozen         = errors.New("user is frozen")
+)
+
+// Authenticator checks the credentials of the users logging in, and locks out the usernames
+// and client ips failing too often. The gRPC and the HTTP servers share it, so both enforce the same limits
+type Authenticator struct {
+	store  db.Store
+	config util.Config
+}
+
+func NewAuthenticator(store db.Store, config util.Config) *Authenticator {
+	return &Authenticator{
+		store:  store,
+		config: config,
+	}
+}
+
+// Authenticate checks the password of the user, after making sure the username and the client ip
+// aren't locked out. A wrong password is counted as a failed login, and so is an unknown username,
+// so the answer doesn't tell whether the username exists
+func (authenticator *Authenticator) Authenticate(ctx context.Context, username string, password string, clientIp string) (db.User, error) {
+	if err := authenticator.CheckAttempts(ctx, username, clientIp); err != nil {
+		return db.User{}, err
+	}
+
+	userExists := true
+	hashedPassword := dummyPasswordHash
+	user, err := authenticator.store.GetUser(ctx, username)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			return db.User{}, fmt.Errorf("failed to get user: %w", err)
+		}
+		userExists = false
+	} else {
+		hashedPassword = user.HashedPassword
+	}
+
+	err = util.CheckPassword(password, hashedPassword)
+	if err != nil || !userExists {
+		if err := authenticator.RecordFailure(ctx, username, clientIp, userExists); err != nil {
+			return db.User{}, err
+		}
+		return db.User{}, ErrInvalidCredentials
+	}
+
+	if user.IsFrozen {
+		return db.User{}, ErrUserFrozen
+	}
+
+	return user, nil
+}
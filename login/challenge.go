@@ -0,0 +1,126 @@
+package login
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/chensheep/simple-bank-backend/val"
+	"github.com/google/uuid"
+)
+
+// challengeMaxFailures is how many wrong codes a login challenge takes before it is used up
+const challengeMaxFailures = 5
+
+var ErrInvalidChallenge = errors.New("invalid or expired login challenge")
+
+// SecondFactorRequired tells whether the user has confirmed two-factor authentication,
+// the login then only creates a challenge until it is answered with a code
+func (authenticator *Authenticator) SecondFactorRequired(ctx context.Context, username string) (bool, error) {
+	userTotp, err := authenticator.store.GetUserTotp(ctx, username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get user totp: %w", err)
+	}
+	return userTotp.ConfirmedAt.Valid, nil
+}
+
+// CreateChallenge answers a login with the right password of a user with two-factor authentication,
+// the tokens are only created once the challenge is answered by VerifyChallenge
+func (authenticator *Authenticator) CreateChallenge(ctx context.Context, username string) (db.LoginChallenge, error) {
+	challenge, err := authenticator.store.CreateLoginChallenge(ctx, db.CreateLoginChallengeParams{
+		ID:        uuid.New(),
+		Username:  username,
+		ExpiredAt: time.Now().Add(authenticator.config.TotpChallengeDuration),
+	})
+	if err != nil {
+		return challenge, fmt.Errorf("failed to create login challenge: %w", err)
+	}
+	return challenge, nil
+}
+
+// VerifyChallenge finishes the login of a user with two-factor authentication, and returns the user
+// to create the session for. Wrong codes count as failed logins of the user, and use up the challenge
+// after a few tries
+func (authenticator *Authenticator) VerifyChallenge(ctx context.Context, challengeID uuid.UUID, code string, clientIp string) (db.User, error) {
+	challenge, err := authenticator.store.GetLoginChallenge(ctx, challengeID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return db.User{}, ErrInvalidChallenge
+		}
+		return db.User{}, fmt.Errorf("failed to get login challenge: %w", err)
+	}
+	if challenge.IsUsed || time.Now().After(challenge.ExpiredAt) {
+		return db.User{}, ErrInvalidChallenge
+	}
+
+	if err := authenticator.CheckAttempts(ctx, challenge.Username, clientIp); err != nil {
+		return db.User{}, err
+	}
+
+	user, err := authenticator.store.GetUser(ctx, challenge.Username)
+	if err != nil {
+		return db.User{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.IsFrozen {
+		return db.User{}, ErrUserFrozen
+	}
+
+	userTotp, err := authenticator.store.GetUserTotp(ctx, user.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return db.User{}, ErrInvalidChallenge
+		}
+		return db.User{}, fmt.Errorf("failed to get user totp: %w", err)
+	}
+
+	factor, ok := SecondFactor(userTotp, code)
+	if ok {
+		_, err = authenticator.store.CompleteLoginChallengeTx(ctx, db.CompleteLoginChallengeTxParams{
+			ChallengeID:  challenge.ID,
+			Username:     user.Username,
+			SecondFactor: factor,
+		})
+		if err != nil && err != sql.ErrNoRows {
+			return db.User{}, fmt.Errorf("failed to complete login challenge: %w", err)
+		}
+		ok = err == nil
+	}
+
+	if !ok {
+		_, err = authenticator.store.RecordLoginChallengeFailure(ctx, db.RecordLoginChallengeFailureParams{
+			MaxFailures: challengeMaxFailures,
+			ID:          challenge.ID,
+		})
+		if err != nil && err != sql.ErrNoRows {
+			return db.User{}, fmt.Errorf("failed to record login challenge failure: %w", err)
+		}
+		if err := authenticator.RecordFailure(ctx, user.Username, clientIp, true); err != nil {
+			return db.User{}, err
+		}
+		return db.User{}, ErrInvalidCredentials
+	}
+
+	return user, nil
+}
+
+// SecondFactor tells how the code of the user is used up: by the time step of a totp code, or
+// as a recovery code. Totp codes are checked here, while a recovery code is only known to be valid
+// once the store has used it, so ok is false only for codes which can't be valid
+func SecondFactor(userTotp db.UserTotp, code string) (factor db.SecondFactor, ok bool) {
+	if val.ValidateTotpCode(code) != nil {
+		return db.SecondFactor{HashedRecoveryCode: util.HashRecoveryCode(code)}, true
+	}
+
+	step, ok := util.ValidateTotpCode(userTotp.Secret, code, time.Now())
+	if !ok || step <= userTotp.LastUsedStep {
+		return db.SecondFactor{}, false
+	}
+	return db.SecondFactor{Step: step}, true
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"embed"
+	"expvar"
+	"fmt"
 	"io/fs"
 	"net"
 	"net/http"
@@ -52,15 +54,81 @@ func main() {
 
 	store := db.NewSQLStore(conn)
 
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		runReconciliation(config, store)
+		return
+	}
+
+	if config.ExchangeRatesFile != "" {
+		loadExchangeRates(config.ExchangeRatesFile, store)
+	}
+
 	redisClientOpt := asynq.RedisClientOpt{Addr: config.RedisServerAddress}
 	taskDistributor := worker.NewRedisDistrubuter(redisClientOpt)
-	go runTaskProcessor(config, redisClientOpt, store)
+	go runTaskProcessor(config, redisClientOpt, store, taskDistributor)
+	go runTaskScheduler(redisClientOpt)
+	go runOutboxRelay(config, store, taskDistributor)
 
+	if config.AdminServerAddress != "" {
+		go createAdminServer(config)
+	}
 	go createGatewayServer(config, store, taskDistributor)
 	createGRPCServer(config, store, taskDistributor)
 	log.Info().Msg("main existed")
 }
 
+func loadExchangeRates(path string, store db.Store) {
+	rates, err := util.LoadExchangeRates(path)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot load exchange rates")
+	}
+
+	arg := db.UpsertExchangeRatesTxParams{}
+	for _, rate := range rates {
+		arg.Rates = append(arg.Rates, db.UpsertExchangeRateParams{
+			FromCurrency: rate.FromCurrency,
+			ToCurrency:   rate.ToCurrency,
+			Rate:         rate.Rate,
+		})
+	}
+
+	_, err = store.UpsertExchangeRatesTx(context.Background(), arg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot save exchange rates")
+	}
+
+	log.Info().Int("count", len(rates)).Msg("exchange rates loaded")
+}
+
+// runReconciliation checks the ledger once, prints the discrepancies found
+// and exits with a non-zero status if there are any
+func runReconciliation(config util.Config, store db.Store) {
+	result, err := store.ReconcileTx(context.Background())
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot reconcile ledger")
+	}
+
+	fmt.Printf("reconciliation run #%d: %s, %d discrepancies\n", result.Run.ID, result.Run.Status, result.Run.DiscrepancyCount)
+	for _, discrepancy := range result.Discrepancies {
+		fmt.Printf("%s\taccount=%d\ttransfer=%d\texpected=%d\tactual=%d\t%s\n",
+			discrepancy.Kind, discrepancy.AccountID.Int64, discrepancy.TransferID.Int64,
+			discrepancy.Expected, discrepancy.Actual, discrepancy.Details)
+	}
+
+	if result.Run.Status != db.ReconciliationStatusMismatch {
+		return
+	}
+
+	if config.ReconciliationAlertEmail != "" {
+		emailSender := email.NewGmailSender(config.EmailSenderName, config.EmailSenderAddress, config.EmailSenderPassword)
+		err = worker.SendReconciliationAlert(emailSender, config.ReconciliationAlertEmail, result)
+		if err != nil {
+			log.Error().Err(err).Msg("cannot send reconciliation alert")
+		}
+	}
+	os.Exit(1)
+}
+
 func createGinServer(config util.Config, store db.Store) {
 	server, err := api.NewServer(config, store)
 	if err != nil {
@@ -73,9 +141,9 @@ func createGinServer(config util.Config, store db.Store) {
 	}
 }
 
-func runTaskProcessor(config util.Config, redisClientOpt asynq.RedisClientOpt, store db.Store) {
+func runTaskProcessor(config util.Config, redisClientOpt asynq.RedisClientOpt, store db.Store, taskDistributor worker.TaskDistrubutor) {
 	emailSender := email.NewGmailSender(config.EmailSenderName, config.EmailSenderAddress, config.EmailSenderPassword)
-	processor := worker.NewRedisTaskProcessor(redisClientOpt, store, emailSender)
+	processor := worker.NewRedisTaskProcessor(redisClientOpt, config, store, emailSender, taskDistributor)
 	log.Info().Msg("start task processor")
 	err := processor.Start()
 	if err != nil {
@@ -84,15 +152,35 @@ func runTaskProcessor(config util.Config, redisClientOpt asynq.RedisClientOpt, s
 	log.Info().Msg("task processor existed")
 }
 
+func runTaskScheduler(redisClientOpt asynq.RedisClientOpt) {
+	scheduler := worker.NewRedisTaskScheduler(redisClientOpt)
+	log.Info().Msg("start task scheduler")
+	err := scheduler.Start()
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot start task scheduler")
+	}
+}
+
+func runOutboxRelay(config util.Config, store db.Store, taskDistributor worker.TaskDistrubutor) {
+	relay := worker.NewOutboxRelay(store, taskDistributor, config.OutboxRelayInterval)
+	log.Info().Msg("start outbox relay")
+	err := relay.Start(context.Background())
+	if err != nil {
+		log.Fatal().Err(err).Msg("outbox relay stopped")
+	}
+}
+
 func createGRPCServer(config util.Config, store db.Store, taskDistributor worker.TaskDistrubutor) {
 	server, err := gapi.NewServer(config, store, taskDistributor)
 	if err != nil {
 		log.Fatal().Err(err).Msg("cannot create server")
 	}
 
-	grpcLogger := grpc.UnaryInterceptor(gapi.GrpcLogger)
-	grpcServer := grpc.NewServer(grpcLogger)
+	unaryInterceptors := grpc.ChainUnaryInterceptor(gapi.GrpcLogger, server.AuthUnaryInterceptor)
+	streamInterceptors := grpc.ChainStreamInterceptor(server.AuthStreamInterceptor)
+	grpcServer := grpc.NewServer(unaryInterceptors, streamInterceptors)
 	pb.RegisterSimpleBankServiceServer(grpcServer, server)
+	pb.RegisterAdminServiceServer(grpcServer, server)
 	reflection.Register(grpcServer)
 
 	lis, err := net.Listen("tcp", config.GRPCServerAddress)
@@ -127,10 +215,15 @@ func createGatewayServer(config util.Config, store db.Store, taskDistributor wor
 	})
 
 	grpcMux := runtime.NewServeMux(jsonOpts)
-	err = pb.RegisterSimpleBankServiceHandlerServer(ctx, grpcMux, server)
+	gatewayServer := gapi.NewGatewayServer(server)
+	err = pb.RegisterSimpleBankServiceHandlerServer(ctx, grpcMux, gatewayServer)
 	if err != nil {
 		log.Fatal().Err(err).Msg("cannot register handler server")
 	}
+	err = pb.RegisterAdminServiceHandlerServer(ctx, grpcMux, gatewayServer)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot register admin handler server")
+	}
 
 	mux := http.NewServeMux()
 	mux.Handle("/", grpcMux)
@@ -156,3 +249,21 @@ func createGatewayServer(config util.Config, store db.Store, taskDistributor wor
 
 	log.Info().Msg("gRPC gateway existed")
 }
+
+// createAdminServer serves the internal metrics on their own listener, which is meant to be bound
+// to localhost or a private network rather than exposed with the gateway
+func createAdminServer(config util.Config) {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	listener, err := net.Listen("tcp", config.AdminServerAddress)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot create admin listener")
+	}
+
+	log.Info().Msgf("start admin HTTP server on %s", listener.Addr().String())
+	err = http.Serve(listener, mux)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot start admin HTTP server")
+	}
+}
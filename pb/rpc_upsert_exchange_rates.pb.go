@@ -0,0 +1,216 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.30.0
+// 	protoc        v3.15.8
+// source: rpc_upsert_exchange_rates.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type UpsertExchangeRatesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Rates []*ExchangeRate `protobuf:"bytes,1,rep,name=rates,proto3" json:"rates,omitempty"`
+}
+
+func (x *UpsertExchangeRatesRequest) Reset() {
+	*x = UpsertExchangeRatesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_upsert_exchange_rates_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UpsertExchangeRatesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpsertExchangeRatesRequest) ProtoMessage() {}
+
+func (x *UpsertExchangeRatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_upsert_exchange_rates_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpsertExchangeRatesRequest.ProtoReflect.Descriptor instead.
+func (*UpsertExchangeRatesRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_upsert_exchange_rates_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *UpsertExchangeRatesRequest) GetRates() []*ExchangeRate {
+	if x != nil {
+		return x.Rates
+	}
+	return nil
+}
+
+type UpsertExchangeRatesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Rates []*ExchangeRate `protobuf:"bytes,1,rep,name=rates,proto3" json:"rates,omitempty"`
+}
+
+func (x *UpsertExchangeRatesResponse) Reset() {
+	*x = UpsertExchangeRatesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_upsert_exchange_rates_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UpsertExchangeRatesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpsertExchangeRatesResponse) ProtoMessage() {}
+
+func (x *UpsertExchangeRatesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_upsert_exchange_rates_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpsertExchangeRatesResponse.ProtoReflect.Descriptor instead.
+func (*UpsertExchangeRatesResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_upsert_exchange_rates_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *UpsertExchangeRatesResponse) GetRates() []*ExchangeRate {
+	if x != nil {
+		return x.Rates
+	}
+	return nil
+}
+
+var File_rpc_upsert_exchange_rates_proto protoreflect.FileDescriptor
+
+var file_rpc_upsert_exchange_rates_proto_rawDesc = []byte{
+	0x0a, 0x1f, 0x72, 0x70, 0x63, 0x5f, 0x75, 0x70, 0x73, 0x65, 0x72, 0x74, 0x5f, 0x65, 0x78, 0x63,
+	0x68, 0x61, 0x6e, 0x67, 0x65, 0x5f, 0x72, 0x61, 0x74, 0x65, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x12, 0x02, 0x70, 0x62, 0x1a, 0x13, 0x65, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x5f,
+	0x72, 0x61, 0x74, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x44, 0x0a, 0x1a, 0x55, 0x70,
+	0x73, 0x65, 0x72, 0x74, 0x45, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x61, 0x74, 0x65,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x05, 0x72, 0x61, 0x74, 0x65,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x70, 0x62, 0x2e, 0x45, 0x78, 0x63,
+	0x68, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x61, 0x74, 0x65, 0x52, 0x05, 0x72, 0x61, 0x74, 0x65, 0x73,
+	0x22, 0x45, 0x0a, 0x1b, 0x55, 0x70, 0x73, 0x65, 0x72, 0x74, 0x45, 0x78, 0x63, 0x68, 0x61, 0x6e,
+	0x67, 0x65, 0x52, 0x61, 0x74, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x26, 0x0a, 0x05, 0x72, 0x61, 0x74, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10,
+	0x2e, 0x70, 0x62, 0x2e, 0x45, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x61, 0x74, 0x65,
+	0x52, 0x05, 0x72, 0x61, 0x74, 0x65, 0x73, 0x42, 0x2d, 0x5a, 0x2b, 0x67, 0x69, 0x74, 0x68, 0x75,
+	0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x63, 0x68, 0x65, 0x6e, 0x73, 0x68, 0x65, 0x65, 0x70, 0x2f,
+	0x73, 0x69, 0x6d, 0x70, 0x6c, 0x65, 0x2d, 0x62, 0x61, 0x6e, 0x6b, 0x2d, 0x62, 0x61, 0x63, 0x6b,
+	0x65, 0x6e, 0x64, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_rpc_upsert_exchange_rates_proto_rawDescOnce sync.Once
+	file_rpc_upsert_exchange_rates_proto_rawDescData = file_rpc_upsert_exchange_rates_proto_rawDesc
+)
+
+func file_rpc_upsert_exchange_rates_proto_rawDescGZIP() []byte {
+	file_rpc_upsert_exchange_rates_proto_rawDescOnce.Do(func() {
+		file_rpc_upsert_exchange_rates_proto_rawDescData = protoimpl.X.CompressGZIP(file_rpc_upsert_exchange_rates_proto_rawDescData)
+	})
+	return file_rpc_upsert_exchange_rates_proto_rawDescData
+}
+
+var file_rpc_upsert_exchange_rates_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_rpc_upsert_exchange_rates_proto_goTypes = []interface{}{
+	(*UpsertExchangeRatesRequest)(nil),  // 0: pb.UpsertExchangeRatesRequest
+	(*UpsertExchangeRatesResponse)(nil), // 1: pb.UpsertExchangeRatesResponse
+	(*ExchangeRate)(nil),                // 2: pb.ExchangeRate
+}
+var file_rpc_upsert_exchange_rates_proto_depIdxs = []int32{
+	2, // 0: pb.UpsertExchangeRatesRequest.rates:type_name -> pb.ExchangeRate
+	2, // 1: pb.UpsertExchangeRatesResponse.rates:type_name -> pb.ExchangeRate
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_rpc_upsert_exchange_rates_proto_init() }
+func file_rpc_upsert_exchange_rates_proto_init() {
+	if File_rpc_upsert_exchange_rates_proto != nil {
+		return
+	}
+	file_exchange_rate_proto_init()
+	if !protoimpl.UnsafeEnabled {
+		file_rpc_upsert_exchange_rates_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UpsertExchangeRatesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_upsert_exchange_rates_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UpsertExchangeRatesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_rpc_upsert_exchange_rates_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_rpc_upsert_exchange_rates_proto_goTypes,
+		DependencyIndexes: file_rpc_upsert_exchange_rates_proto_depIdxs,
+		MessageInfos:      file_rpc_upsert_exchange_rates_proto_msgTypes,
+	}.Build()
+	File_rpc_upsert_exchange_rates_proto = out.File
+	file_rpc_upsert_exchange_rates_proto_rawDesc = nil
+	file_rpc_upsert_exchange_rates_proto_goTypes = nil
+	file_rpc_upsert_exchange_rates_proto_depIdxs = nil
+}
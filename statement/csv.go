@@ -0,0 +1,39 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// WriteCSV writes one row per entry with the running balance,
+// between an opening and a closing balance row
+func (s Statement) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	rows := [][]string{
+		{"date", "entry_id", "description", "amount", "balance"},
+		{s.PeriodStart.Format(time.RFC3339), "", "Opening balance", "", strconv.FormatInt(s.OpeningBalance, 10)},
+	}
+
+	balance := s.OpeningBalance
+	for _, entry := range s.Entries {
+		balance += entry.Amount
+		rows = append(rows, []string{
+			entry.CreatedAt.Format(time.RFC3339),
+			strconv.FormatInt(entry.ID, 10),
+			describeEntry(entry),
+			strconv.FormatInt(entry.Amount, 10),
+			strconv.FormatInt(balance, 10),
+		})
+	}
+
+	rows = append(rows, []string{s.lastDay().Format(time.RFC3339), "", "Closing balance", "", strconv.FormatInt(s.ClosingBalance, 10)})
+
+	err := writer.WriteAll(rows)
+	if err != nil {
+		return err
+	}
+	return writer.Error()
+}
@@ -0,0 +1,103 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	pdfLinesPerPage = 54
+	pdfFontSize     = 9
+	pdfLeading      = 14
+)
+
+// WritePDF writes the statement as a plain text PDF, in a monospace font
+// so the columns line up
+func (s Statement) WritePDF(w io.Writer) error {
+	lines := []string{
+		"Simple Bank - Account statement",
+		"",
+		fmt.Sprintf("Account:  #%d (%s)", s.Account.ID, s.Account.Currency),
+		fmt.Sprintf("Owner:    %s", s.Account.Owner),
+		fmt.Sprintf("Period:   %s to %s", s.PeriodStart.Format("2006-01-02"), s.lastDay().Format("2006-01-02")),
+		"",
+		fmt.Sprintf("%-20s %-32s %14s %14s", "Date", "Description", "Amount", "Balance"),
+		strings.Repeat("-", 83),
+		fmt.Sprintf("%-20s %-32s %14s %14d", s.PeriodStart.Format("2006-01-02 15:04"), "Opening balance", "", s.OpeningBalance),
+	}
+
+	balance := s.OpeningBalance
+	for _, entry := range s.Entries {
+		balance += entry.Amount
+		lines = append(lines, fmt.Sprintf("%-20s %-32s %14d %14d",
+			entry.CreatedAt.UTC().Format("2006-01-02 15:04"), describeEntry(entry), entry.Amount, balance))
+	}
+
+	lines = append(lines,
+		fmt.Sprintf("%-20s %-32s %14s %14d", s.lastDay().Format("2006-01-02 15:04"), "Closing balance", "", s.ClosingBalance),
+	)
+
+	_, err := w.Write(renderPDF(lines))
+	return err
+}
+
+// renderPDF lays the lines out on as many A4 pages as needed.
+// Objects 1 to 3 are the catalog, the page tree and the font,
+// then each page is followed by its content stream
+func renderPDF(lines []string) []byte {
+	var pages [][]string
+	for len(lines) > pdfLinesPerPage {
+		pages = append(pages, lines[:pdfLinesPerPage])
+		lines = lines[pdfLinesPerPage:]
+	}
+	pages = append(pages, lines)
+
+	var objects []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier >>",
+	)
+
+	for i, page := range pages {
+		var content bytes.Buffer
+		fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n40 800 Td\n", pdfFontSize, pdfLeading)
+		for _, line := range page {
+			fmt.Fprintf(&content, "(%s) Tj T*\n", escapePDFString(line))
+		}
+		content.WriteString("ET")
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return buf.Bytes()
+}
+
+func escapePDFString(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(s)
+}
@@ -0,0 +1,117 @@
+package statement
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+)
+
+const (
+	FormatCSV = "csv"
+	FormatPDF = "pdf"
+
+	monthLayout = "2006-01"
+)
+
+// Statement is the activity of an account over a period, the balances are
+// computed from the entries so they match the ledger
+type Statement struct {
+	Account        db.Account
+	PeriodStart    time.Time
+	PeriodEnd      time.Time // exclusive
+	OpeningBalance int64
+	ClosingBalance int64
+	Entries        []db.Entry
+}
+
+// ParseMonth parses a month like 2023-05, in UTC
+func ParseMonth(month string) (time.Time, error) {
+	t, err := time.Parse(monthLayout, month)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month %q, must be like 2023-05", month)
+	}
+	return t, nil
+}
+
+// MonthPeriod returns the first instant of the month of t and of the month after
+func MonthPeriod(t time.Time) (time.Time, time.Time) {
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return start, start.AddDate(0, 1, 0)
+}
+
+// Generate builds the statement of the account for the period [start, end)
+func Generate(ctx context.Context, q db.Querier, account db.Account, start, end time.Time) (Statement, error) {
+	opening, err := q.SumAccountEntriesBefore(ctx, db.SumAccountEntriesBeforeParams{
+		AccountID: account.ID,
+		Before:    start,
+	})
+	if err != nil {
+		return Statement{}, fmt.Errorf("failed to get opening balance: %w", err)
+	}
+
+	entries, err := q.ListAccountEntriesInPeriod(ctx, db.ListAccountEntriesInPeriodParams{
+		AccountID: account.ID,
+		StartTime: start,
+		EndTime:   end,
+	})
+	if err != nil {
+		return Statement{}, fmt.Errorf("failed to list entries: %w", err)
+	}
+
+	closing := opening
+	for _, entry := range entries {
+		closing += entry.Amount
+	}
+
+	return Statement{
+		Account:        account,
+		PeriodStart:    start,
+		PeriodEnd:      end,
+		OpeningBalance: opening,
+		ClosingBalance: closing,
+		Entries:        entries,
+	}, nil
+}
+
+// FileName returns the name of the statement file in the given format
+func (s Statement) FileName(format string) string {
+	return fmt.Sprintf("statement-%d-%s.%s", s.Account.ID, s.PeriodStart.Format(monthLayout), format)
+}
+
+// Write writes the statement in the given format, csv or pdf
+func (s Statement) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatCSV:
+		return s.WriteCSV(w)
+	case FormatPDF:
+		return s.WritePDF(w)
+	}
+	return fmt.Errorf("unsupported statement format %q", format)
+}
+
+// ContentType returns the MIME type of the format
+func ContentType(format string) string {
+	if format == FormatCSV {
+		return "text/csv"
+	}
+	return "application/pdf"
+}
+
+// lastDay is the last day covered by the statement, for display
+func (s Statement) lastDay() time.Time {
+	return s.PeriodEnd.Add(-time.Nanosecond)
+}
+
+func describeEntry(entry db.Entry) string {
+	description := "Credit"
+	if entry.Amount < 0 {
+		description = "Debit"
+	}
+	if entry.TransferID.Valid {
+		description = fmt.Sprintf("%s, transfer #%d", description, entry.TransferID.Int64)
+	}
+	return description
+}
@@ -0,0 +1,118 @@
+package statement
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/chensheep/simple-bank-backend/db/mock"
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func randomStatement(t *testing.T) Statement {
+	account := db.Account{
+		ID:       util.RandomInt(1, 1000),
+		Owner:    util.RandomOwner(),
+		Currency: util.USD,
+	}
+	start, end := MonthPeriod(time.Date(2023, 5, 17, 0, 0, 0, 0, time.UTC))
+
+	entries := []db.Entry{
+		{ID: 1, AccountID: account.ID, Amount: 50, CreatedAt: start.Add(time.Hour), TransferID: sql.NullInt64{Int64: 7, Valid: true}},
+		{ID: 2, AccountID: account.ID, Amount: -30, CreatedAt: start.Add(2 * time.Hour)},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		SumAccountEntriesBefore(gomock.Any(), gomock.Eq(db.SumAccountEntriesBeforeParams{AccountID: account.ID, Before: start})).
+		Times(1).
+		Return(int64(100), nil)
+	store.EXPECT().
+		ListAccountEntriesInPeriod(gomock.Any(), gomock.Eq(db.ListAccountEntriesInPeriodParams{AccountID: account.ID, StartTime: start, EndTime: end})).
+		Times(1).
+		Return(entries, nil)
+
+	statement, err := Generate(context.Background(), store, account, start, end)
+	require.NoError(t, err)
+	return statement
+}
+
+func TestGenerate(t *testing.T) {
+	statement := randomStatement(t)
+	require.Equal(t, int64(100), statement.OpeningBalance)
+	require.Equal(t, int64(120), statement.ClosingBalance)
+	require.Len(t, statement.Entries, 2)
+	require.Equal(t, fmt.Sprintf("statement-%d-2023-05.pdf", statement.Account.ID), statement.FileName(FormatPDF))
+}
+
+func TestMonthPeriod(t *testing.T) {
+	start, end := MonthPeriod(time.Date(2023, 12, 31, 23, 59, 0, 0, time.UTC))
+	require.Equal(t, time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC), start)
+	require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), end)
+
+	month, err := ParseMonth("2023-02")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), month)
+
+	_, err = ParseMonth("2023-13")
+	require.Error(t, err)
+}
+
+func TestWriteCSV(t *testing.T) {
+	statement := randomStatement(t)
+
+	var buf bytes.Buffer
+	err := statement.WriteCSV(&buf)
+	require.NoError(t, err)
+
+	expected := "date,entry_id,description,amount,balance\n" +
+		"2023-05-01T00:00:00Z,,Opening balance,,100\n" +
+		"2023-05-01T01:00:00Z,1,\"Credit, transfer #7\",50,150\n" +
+		"2023-05-01T02:00:00Z,2,Debit,-30,120\n" +
+		"2023-05-31T23:59:59Z,,Closing balance,,120\n"
+	require.Equal(t, expected, buf.String())
+}
+
+func TestWritePDF(t *testing.T) {
+	statement := randomStatement(t)
+	for i := 0; i < 100; i++ {
+		statement.Entries = append(statement.Entries, statement.Entries[1])
+	}
+
+	var buf bytes.Buffer
+	err := statement.WritePDF(&buf)
+	require.NoError(t, err)
+
+	pdf := buf.String()
+	require.True(t, strings.HasPrefix(pdf, "%PDF-1.4\n"))
+	require.True(t, strings.HasSuffix(pdf, "%%EOF\n"))
+	require.Contains(t, pdf, "/Count 3")
+	require.Contains(t, pdf, "(Simple Bank - Account statement) Tj")
+
+	// every object must start at the offset given by the cross-reference table
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllStringSubmatch(pdf, -1)
+	require.Len(t, offsets, 3+2*3)
+	for i, offset := range offsets {
+		n, err := strconv.Atoi(offset[1])
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(pdf[n:], fmt.Sprintf("%d 0 obj", i+1)))
+	}
+
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(pdf)
+	require.NotNil(t, startxref)
+	n, err := strconv.Atoi(startxref[1])
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(pdf[n:], "xref\n"))
+}
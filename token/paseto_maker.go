@@ -22,9 +22,9 @@ func NewPasetoMaker(symmetricKey string) (Maker, error) {
 	return &PasetoMaker{symmetricKey: []byte(symmetricKey), paseto: paseto.NewV2()}, nil
 }
 
-func (maker *PasetoMaker) CreateToken(username string, duration time.Duration) (string, *Payload, error) {
+func (maker *PasetoMaker) CreateToken(username string, role string, duration time.Duration) (string, *Payload, error) {
 
-	payload, err := NewPayload(username, duration)
+	payload, err := NewPayload(username, role, duration)
 	if err != nil {
 		return "", nil, err
 	}
@@ -1,24 +1,43 @@
 package util
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Environment          string        `mastructure:"ENVIORNMENT"`
-	DBDriver             string        `mapstructure:"DB_DRIVER"`
-	DBSource             string        `mapstructure:"DB_SOURCE"`
-	HTTPServerAddress    string        `mapstructure:"HTTP_SERVER_ADDRESS"`
-	GRPCServerAddress    string        `mapstructure:"GRPC_SERVER_ADDRESS"`
-	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
-	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
-	RedisServerAddress   string        `mapstructure:"REDIS_SERVER_ADDRESS"`
-	EmailSenderName      string        `mapstructure:"EMAIL_SENDER_NAME"`
-	EmailSenderAddress   string        `mapstructure:"EMAIL_SENDER_ADDRESS"`
-	EmailSenderPassword  string        `mapstructure:"EMAIL_SENDER_PASSWORD"`
+	Environment              string        `mastructure:"ENVIORNMENT"`
+	DBDriver                 string        `mapstructure:"DB_DRIVER"`
+	DBSource                 string        `mapstructure:"DB_SOURCE"`
+	HTTPServerAddress        string        `mapstructure:"HTTP_SERVER_ADDRESS"`
+	GRPCServerAddress        string        `mapstructure:"GRPC_SERVER_ADDRESS"`
+	AdminServerAddress       string        `mapstructure:"ADMIN_SERVER_ADDRESS"`
+	TokenSymmetricKey        string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	AccessTokenDuration      time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration     time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	RedisServerAddress       string        `mapstructure:"REDIS_SERVER_ADDRESS"`
+	EmailSenderName          string        `mapstructure:"EMAIL_SENDER_NAME"`
+	EmailSenderAddress       string        `mapstructure:"EMAIL_SENDER_ADDRESS"`
+	EmailSenderPassword      string        `mapstructure:"EMAIL_SENDER_PASSWORD"`
+	HoldDuration             time.Duration `mapstructure:"HOLD_DURATION"`
+	ExchangeRatesFile        string        `mapstructure:"EXCHANGE_RATES_FILE"`
+	ExchangeQuoteDuration    time.Duration `mapstructure:"EXCHANGE_QUOTE_DURATION"`
+	ReconciliationAlertEmail string        `mapstructure:"RECONCILIATION_ALERT_EMAIL"`
+	OutboxRelayInterval      time.Duration `mapstructure:"OUTBOX_RELAY_INTERVAL"`
+	LoginMaxFailures         int32         `mapstructure:"LOGIN_MAX_FAILURES"`
+	LoginMaxFailuresPerIp    int32         `mapstructure:"LOGIN_MAX_FAILURES_PER_IP"`
+	LoginLockoutDuration     time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"`
+	RequireVerifiedEmail     bool          `mapstructure:"REQUIRE_VERIFIED_EMAIL"`
+	VerifyEmailResendDelay   time.Duration `mapstructure:"VERIFY_EMAIL_RESEND_DELAY"`
+	VerifyEmailMaxPerDay     int64         `mapstructure:"VERIFY_EMAIL_MAX_PER_DAY"`
+	PasswordResetResendDelay time.Duration `mapstructure:"PASSWORD_RESET_RESEND_DELAY"`
+	PasswordResetMaxPerDay   int64         `mapstructure:"PASSWORD_RESET_MAX_PER_DAY"`
+	PasswordResetMaxPerIp    int64         `mapstructure:"PASSWORD_RESET_MAX_PER_IP"`
+	TotpChallengeDuration    time.Duration `mapstructure:"TOTP_CHALLENGE_DURATION"`
+	StepUpThresholds         string        `mapstructure:"STEP_UP_THRESHOLDS"`
+	StepUpDuration           time.Duration `mapstructure:"STEP_UP_DURATION"`
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -29,6 +48,11 @@ func LoadConfig(path string) (config Config, err error) {
 
 	viper.AutomaticEnv()
 
+	// a zero maximum would lock a username at its first failed login
+	viper.SetDefault("LOGIN_MAX_FAILURES", 5)
+	viper.SetDefault("LOGIN_MAX_FAILURES_PER_IP", 50)
+	viper.SetDefault("LOGIN_LOCKOUT_DURATION", 15*time.Minute)
+
 	err = viper.ReadInConfig()
 	if err != nil {
 		return
@@ -39,5 +63,10 @@ func LoadConfig(path string) (config Config, err error) {
 		return
 	}
 
+	if config.LoginMaxFailures <= 0 || config.LoginMaxFailuresPerIp <= 0 {
+		err = fmt.Errorf("LOGIN_MAX_FAILURES and LOGIN_MAX_FAILURES_PER_IP must be positive")
+		return
+	}
+
 	return
 }
@@ -0,0 +1,26 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigLoginDefaults(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "app.env"), []byte("DB_DRIVER=postgres\n"), 0o600)
+	require.NoError(t, err)
+
+	config, err := LoadConfig(dir)
+	require.NoError(t, err)
+	require.Equal(t, int32(5), config.LoginMaxFailures)
+	require.Equal(t, int32(50), config.LoginMaxFailuresPerIp)
+	require.Equal(t, 15*time.Minute, config.LoginLockoutDuration)
+
+	t.Setenv("LOGIN_MAX_FAILURES", "0")
+	_, err = LoadConfig(dir)
+	require.Error(t, err)
+}
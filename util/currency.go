@@ -1,5 +1,11 @@
 package util
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 const (
 	USD = "USD"
 	EUR = "EUR"
@@ -13,3 +19,27 @@ func IsSupportedCurrency(currency string) bool {
 	}
 	return false
 }
+
+// ParseCurrencyAmounts parses amounts per currency written as "USD:100000,EUR:100000",
+// in the smallest unit of each currency
+func ParseCurrencyAmounts(s string) (map[string]int64, error) {
+	amounts := make(map[string]int64)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		currency, value, found := strings.Cut(pair, ":")
+		currency = strings.ToUpper(strings.TrimSpace(currency))
+		if !found || !IsSupportedCurrency(currency) {
+			return nil, fmt.Errorf("invalid currency amount %q", pair)
+		}
+		amount, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil || amount <= 0 {
+			return nil, fmt.Errorf("invalid currency amount %q", pair)
+		}
+		amounts[currency] = amount
+	}
+	return amounts, nil
+}
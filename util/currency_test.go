@@ -0,0 +1,26 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCurrencyAmounts(t *testing.T) {
+	amounts, err := ParseCurrencyAmounts("USD:100000, twd:3000000,")
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{USD: 100000, TWD: 3000000}, amounts)
+
+	amounts, err = ParseCurrencyAmounts("")
+	require.NoError(t, err)
+	require.Empty(t, amounts)
+
+	_, err = ParseCurrencyAmounts("JPY:100")
+	require.Error(t, err)
+
+	_, err = ParseCurrencyAmounts("USD:0")
+	require.Error(t, err)
+
+	_, err = ParseCurrencyAmounts("USD100")
+	require.Error(t, err)
+}
@@ -0,0 +1,44 @@
+package util
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor returns an opaque page cursor pointing at the row with the given
+// created_at and id, for keyset pagination ordered by (created_at, id)
+func EncodeCursor(createdAt time.Time, id int64) string {
+	raw := fmt.Sprintf("%d,%d", createdAt.UnixMicro(), id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a cursor returned by EncodeCursor
+func DecodeCursor(cursor string) (time.Time, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+
+	micros, id, found := strings.Cut(string(raw), ",")
+	if !found {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+
+	usec, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+
+	rowID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || rowID <= 0 {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+
+	return time.UnixMicro(usec), rowID, nil
+}
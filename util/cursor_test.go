@@ -0,0 +1,26 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor(t *testing.T) {
+	createdAt := time.Now().Truncate(time.Microsecond)
+	id := RandomInt(1, 1000)
+
+	cursor := EncodeCursor(createdAt, id)
+	require.NotEmpty(t, cursor)
+
+	gotCreatedAt, gotID, err := DecodeCursor(cursor)
+	require.NoError(t, err)
+	require.True(t, createdAt.Equal(gotCreatedAt))
+	require.Equal(t, id, gotID)
+
+	for _, invalid := range []string{"", "!!!", "bm9jb21tYQ", "eCwx", "MSx4", "MSww"} {
+		_, _, err = DecodeCursor(invalid)
+		require.ErrorIs(t, err, ErrInvalidCursor)
+	}
+}
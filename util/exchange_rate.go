@@ -0,0 +1,78 @@
+package util
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+)
+
+type ExchangeRate struct {
+	FromCurrency string
+	ToCurrency   string
+	Rate         string
+}
+
+// LoadExchangeRates reads the rates from a csv file, each line holds the from currency,
+// the to currency and the amount of the to currency for one unit of the from currency.
+// Lines starting with # are ignored
+func LoadExchangeRates(path string) ([]ExchangeRate, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseExchangeRates(file)
+}
+
+func ParseExchangeRates(r io.Reader) ([]ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse exchange rates: %w", err)
+	}
+
+	rates := make([]ExchangeRate, 0, len(records))
+	for _, record := range records {
+		rate := ExchangeRate{
+			FromCurrency: strings.ToUpper(record[0]),
+			ToCurrency:   strings.ToUpper(record[1]),
+			Rate:         strings.TrimSpace(record[2]),
+		}
+		if !IsSupportedCurrency(rate.FromCurrency) || !IsSupportedCurrency(rate.ToCurrency) {
+			return nil, fmt.Errorf("unsupported exchange rate %s/%s", rate.FromCurrency, rate.ToCurrency)
+		}
+		if rate.FromCurrency == rate.ToCurrency {
+			return nil, fmt.Errorf("exchange rate %s/%s must be between different currencies", rate.FromCurrency, rate.ToCurrency)
+		}
+		if _, err := ConvertAmount(1, rate.Rate); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+// ConvertAmount applies the exchange rate to the amount, rounding down to the smallest unit
+func ConvertAmount(amount int64, rate string) (int64, error) {
+	r, ok := new(big.Rat).SetString(rate)
+	if !ok || r.Sign() <= 0 {
+		return 0, fmt.Errorf("invalid exchange rate: %s", rate)
+	}
+
+	converted := new(big.Rat).Mul(new(big.Rat).SetInt64(amount), r)
+	result := new(big.Int).Quo(converted.Num(), converted.Denom())
+	if !result.IsInt64() {
+		return 0, fmt.Errorf("converted amount overflows: %s", result)
+	}
+
+	return result.Int64(), nil
+}
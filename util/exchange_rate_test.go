@@ -0,0 +1,43 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertAmount(t *testing.T) {
+	amount, err := ConvertAmount(100, "30.5")
+	require.NoError(t, err)
+	require.Equal(t, int64(3050), amount)
+
+	// rounds down to the smallest unit
+	amount, err = ConvertAmount(100, "0.0327868852")
+	require.NoError(t, err)
+	require.Equal(t, int64(3), amount)
+
+	_, err = ConvertAmount(100, "0")
+	require.Error(t, err)
+
+	_, err = ConvertAmount(100, "abc")
+	require.Error(t, err)
+}
+
+func TestParseExchangeRates(t *testing.T) {
+	rates, err := ParseExchangeRates(strings.NewReader("# from,to,rate\nUSD,TWD,30.5\nusd, eur, 0.92\n"))
+	require.NoError(t, err)
+	require.Equal(t, []ExchangeRate{
+		{FromCurrency: USD, ToCurrency: TWD, Rate: "30.5"},
+		{FromCurrency: USD, ToCurrency: EUR, Rate: "0.92"},
+	}, rates)
+
+	_, err = ParseExchangeRates(strings.NewReader("USD,JPY,150\n"))
+	require.Error(t, err)
+
+	_, err = ParseExchangeRates(strings.NewReader("USD,USD,1\n"))
+	require.Error(t, err)
+
+	_, err = ParseExchangeRates(strings.NewReader("USD,TWD,-1\n"))
+	require.Error(t, err)
+}
@@ -0,0 +1,18 @@
+package util
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// NextScheduleTime returns the first time after the given one matching the schedule,
+// which is a standard cron expression or a descriptor like @monthly or @every 24h
+func NextScheduleTime(schedule string, after time.Time) (time.Time, error) {
+	s, err := cron.ParseStandard(schedule)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid schedule %q: %w", schedule, err)
+	}
+	return s.Next(after), nil
+}
@@ -0,0 +1,23 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextScheduleTime(t *testing.T) {
+	after := time.Date(2023, 6, 15, 10, 30, 0, 0, time.Local)
+
+	next, err := NextScheduleTime("0 9 1 * *", after)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2023, 7, 1, 9, 0, 0, 0, time.Local), next)
+
+	next, err = NextScheduleTime("@every 24h", after)
+	require.NoError(t, err)
+	require.Equal(t, after.Add(24*time.Hour), next)
+
+	_, err = NextScheduleTime("every day", after)
+	require.Error(t, err)
+}
@@ -0,0 +1,20 @@
+package util
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// secretCodeSize is the size in bytes of the secret codes sent by email, 256 bits so they can't be guessed
+const secretCodeSize = 32
+
+// NewSecretCode returns a random hex code for the links sent by email, like the one unlocking a user.
+// It comes from crypto/rand, unlike RandomString which is predictable and only meant for tests
+func NewSecretCode() (string, error) {
+	b := make([]byte, secretCodeSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret code: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,21 @@
+package util
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSecretCode(t *testing.T) {
+	code, err := NewSecretCode()
+	require.NoError(t, err)
+	require.Len(t, code, 2*secretCodeSize)
+
+	_, err = hex.DecodeString(code)
+	require.NoError(t, err)
+
+	other, err := NewSecretCode()
+	require.NoError(t, err)
+	require.NotEqual(t, code, other)
+}
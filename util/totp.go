@@ -0,0 +1,137 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP as in RFC 6238 with the parameters authenticator apps default to
+const (
+	TotpPeriod = 30 * time.Second
+	TotpDigits = 6
+	// totpSkew is how many periods before and after the current one are accepted, for clock drift
+	totpSkew = 1
+	// totpSecretSize is the size in bytes of the secret, 160 bits as recommended for HMAC-SHA1
+	totpSecretSize = 20
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewTotpSecret returns a random base32 secret to share with the authenticator app
+func NewTotpSecret() (string, error) {
+	secret := make([]byte, totpSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TotpStep returns the time step t falls in
+func TotpStep(t time.Time) int64 {
+	return t.Unix() / int64(TotpPeriod/time.Second)
+}
+
+// TotpCode returns the code of the secret for the time step
+func TotpCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", fmt.Errorf("invalid totp secret: %w", err)
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// dynamic truncation, RFC 4226 section 5.3
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < TotpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", TotpDigits, value%mod), nil
+}
+
+// ValidateTotpCode checks the code against the time steps around t,
+// and returns the step it matched so the caller can refuse to accept it twice
+func ValidateTotpCode(secret string, code string, t time.Time) (int64, bool) {
+	if len(code) != TotpDigits {
+		return 0, false
+	}
+
+	current := TotpStep(t)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		expected, err := TotpCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// TotpProvisioningURI returns the otpauth URI authenticator apps read from a QR code
+func TotpProvisioningURI(issuer string, account string, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(TotpDigits))
+	params.Set("period", fmt.Sprint(int64(TotpPeriod/time.Second)))
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, params.Encode())
+}
+
+// NewRecoveryCode returns a random single-use code formatted as xxxxx-xxxxx
+func NewRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate recovery code: %w", err)
+	}
+	code := strings.ToLower(totpEncoding.EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// HashRecoveryCode returns the hash the recovery code is stored as. The codes are random enough
+// for a plain sha256, so a code can be looked up by its hash instead of comparing it with each bcrypt hash
+func HashRecoveryCode(code string) string {
+	return hashCode(strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", "")))
+}
+
+// NewEmailCode returns a random code of TotpDigits digits, to send by email where no authenticator app is set up
+func NewEmailCode() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < TotpDigits; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate email code: %w", err)
+	}
+	return fmt.Sprintf("%0*d", TotpDigits, n), nil
+}
+
+// HashEmailCode returns the hash the emailed code is stored as, it is only valid for a few tries
+func HashEmailCode(code string) string {
+	return hashCode(strings.TrimSpace(code))
+}
+
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,84 @@
+package util
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTotpCode(t *testing.T) {
+	// test vectors of RFC 6238 appendix B for SHA1, truncated to 6 digits
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	testCases := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1111111111, code: "050471"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+	}
+
+	for _, tc := range testCases {
+		code, err := TotpCode(secret, TotpStep(time.Unix(tc.unix, 0)))
+		require.NoError(t, err)
+		require.Equal(t, tc.code, code)
+	}
+}
+
+func TestValidateTotpCode(t *testing.T) {
+	secret, err := NewTotpSecret()
+	require.NoError(t, err)
+
+	now := time.Now()
+	step := TotpStep(now)
+
+	for _, s := range []int64{step - 1, step, step + 1} {
+		code, err := TotpCode(secret, s)
+		require.NoError(t, err)
+
+		matched, ok := ValidateTotpCode(secret, code, now)
+		require.True(t, ok)
+		require.Equal(t, s, matched)
+	}
+
+	code, err := TotpCode(secret, step-2)
+	require.NoError(t, err)
+	_, ok := ValidateTotpCode(secret, code, now)
+	require.False(t, ok)
+
+	_, ok = ValidateTotpCode(secret, "12345", now)
+	require.False(t, ok)
+}
+
+func TestTotpProvisioningURI(t *testing.T) {
+	uri := TotpProvisioningURI("SimpleBank", "alice", "JBSWY3DPEHPK3PXP")
+	require.True(t, strings.HasPrefix(uri, "otpauth://totp/SimpleBank:alice?"))
+	require.Contains(t, uri, "secret=JBSWY3DPEHPK3PXP")
+	require.Contains(t, uri, "issuer=SimpleBank")
+}
+
+func TestRecoveryCode(t *testing.T) {
+	code, err := NewRecoveryCode()
+	require.NoError(t, err)
+	require.Len(t, code, 11)
+	require.Equal(t, byte('-'), code[5])
+
+	require.Equal(t, HashRecoveryCode(code), HashRecoveryCode(strings.ToUpper(strings.ReplaceAll(code, "-", ""))))
+
+	other, err := NewRecoveryCode()
+	require.NoError(t, err)
+	require.NotEqual(t, HashRecoveryCode(code), HashRecoveryCode(other))
+}
+
+func TestEmailCode(t *testing.T) {
+	code, err := NewEmailCode()
+	require.NoError(t, err)
+	require.Len(t, code, TotpDigits)
+	require.Equal(t, HashEmailCode(code), HashEmailCode(" "+code+" "))
+}
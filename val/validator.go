@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"net/mail"
 	"regexp"
+
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/google/uuid"
 )
 
 var (
@@ -72,3 +75,106 @@ func ValidateSecretCode(id string) error {
 	// TODO: validate secret code
 	return ValidateString(id, 32, 128)
 }
+
+func ValidateToken(token string) error {
+	return ValidateString(token, 1, 1024)
+}
+
+func ValidateSessionID(id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return fmt.Errorf("must be a uuid")
+	}
+	return nil
+}
+
+func ValidateID(id int64) error {
+	if id <= 0 {
+		return fmt.Errorf("must be a positive integer")
+	}
+	return nil
+}
+
+func ValidateAmount(amount int64) error {
+	if amount < 0 {
+		return fmt.Errorf("must not be negative")
+	}
+	return nil
+}
+
+func ValidateTransferAmount(amount int64) error {
+	if amount <= 0 {
+		return fmt.Errorf("must be a positive integer")
+	}
+	return nil
+}
+
+func ValidateCurrency(currency string) error {
+	if !util.IsSupportedCurrency(currency) {
+		return fmt.Errorf("unsupported currency %s", currency)
+	}
+	return nil
+}
+
+func ValidateIdempotencyKey(key string) error {
+	return ValidateString(key, 0, 255)
+}
+
+func ValidatePageID(pageID int32) error {
+	if pageID < 1 {
+		return fmt.Errorf("must be a positive integer")
+	}
+	return nil
+}
+
+func ValidatePageSize(pageSize int32) error {
+	if pageSize < 1 || pageSize > 100 {
+		return fmt.Errorf("must be between 1 and 100")
+	}
+	return nil
+}
+
+func ValidateDirection(direction string) error {
+	if direction != "in" && direction != "out" {
+		return fmt.Errorf("must be in or out")
+	}
+	return nil
+}
+
+func ValidateSearchQuery(query string) error {
+	return ValidateString(query, 1, 100)
+}
+
+func ValidateAdjustmentAmount(amount int64) error {
+	if amount == 0 {
+		return fmt.Errorf("must not be zero")
+	}
+	return nil
+}
+
+func ValidateReason(reason string) error {
+	return ValidateString(reason, 3, 500)
+}
+
+func ValidateTotpCode(code string) error {
+	if len(code) != util.TotpDigits {
+		return fmt.Errorf("must be %d digits", util.TotpDigits)
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return fmt.Errorf("must be %d digits", util.TotpDigits)
+		}
+	}
+	return nil
+}
+
+// ValidateSecondFactorCode accepts a totp code or a recovery code
+func ValidateSecondFactorCode(code string) error {
+	return ValidateString(code, util.TotpDigits, 20)
+}
+
+func ValidateBatchTransferMode(mode string) error {
+	if mode != "" && mode != "all_or_nothing" && mode != "best_effort" {
+		return fmt.Errorf("must be all_or_nothing or best_effort")
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package worker
 import (
 	"context"
 
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
 	"github.com/hibiken/asynq"
 )
 
@@ -11,6 +12,15 @@ type TaskDistrubutor interface {
 		context.Context,
 		*SendVerifyEmailPayload,
 		...asynq.Option) error
+	DistrubuteTaskExecuteScheduledTransferTask(
+		context.Context,
+		*ExecuteScheduledTransferPayload,
+		...asynq.Option) error
+	DistrubuteTaskSendStatementTask(
+		context.Context,
+		*SendStatementPayload,
+		...asynq.Option) error
+	DistrubuteOutboxMessage(context.Context, db.Outbox) error
 }
 
 type RedisDistrubutor struct {
@@ -0,0 +1,122 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	outboxRelayBatchSize       = 100
+	defaultOutboxRelayInterval = time.Second
+	// published tasks are kept this long, so publishing a message again
+	// within the window is rejected by asynq as a duplicate task id
+	outboxTaskRetention = 24 * time.Hour
+)
+
+// NewOutboxMessage builds the outbox row of a task, it has to be written in the
+// same transaction as the change the task is about
+func NewOutboxMessage(taskType string, payload interface{}, queue string, maxRetry int) (db.CreateOutboxMessageParams, error) {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return db.CreateOutboxMessageParams{}, fmt.Errorf("failed to marhal task payload: %w", err)
+	}
+
+	return db.CreateOutboxMessageParams{
+		TaskType: taskType,
+		Payload:  jsonPayload,
+		Queue:    queue,
+		MaxRetry: int32(maxRetry),
+	}, nil
+}
+
+func outboxTaskID(messageID int64) string {
+	return fmt.Sprintf("outbox:%d", messageID)
+}
+
+func (d *RedisDistrubutor) DistrubuteOutboxMessage(ctx context.Context, message db.Outbox) error {
+	task := asynq.NewTask(message.TaskType, message.Payload,
+		asynq.TaskID(outboxTaskID(message.ID)),
+		asynq.Queue(message.Queue),
+		asynq.MaxRetry(int(message.MaxRetry)),
+		asynq.Retention(outboxTaskRetention),
+	)
+	taskInfo, err := d.client.EnqueueContext(ctx, task)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		// enqueued by a relay which failed to mark the message published
+		log.Info().Int64("outbox_id", message.ID).Str("type", task.Type()).Msg("outbox message already enqueued")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not enqueue task: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).Str("queue", taskInfo.Queue).
+		Int("max_retry", taskInfo.MaxRetry).Int64("outbox_id", message.ID).Msg("enqueued task")
+
+	return nil
+}
+
+// OutboxRelay polls the outbox and publishes the messages of committed transactions,
+// each of them is enqueued exactly once
+type OutboxRelay struct {
+	store       db.Store
+	distributor TaskDistrubutor
+	interval    time.Duration
+}
+
+func NewOutboxRelay(store db.Store, distributor TaskDistrubutor, interval time.Duration) *OutboxRelay {
+	if interval <= 0 {
+		interval = defaultOutboxRelayInterval
+	}
+	return &OutboxRelay{
+		store:       store,
+		distributor: distributor,
+		interval:    interval,
+	}
+}
+
+// Start relays the outbox every interval until the context is cancelled
+func (relay *OutboxRelay) Start(ctx context.Context) error {
+	ticker := time.NewTicker(relay.interval)
+	defer ticker.Stop()
+
+	for {
+		count, err := relay.Relay(ctx)
+		if err != nil {
+			log.Error().Err(err).Int("count", count).Msg("failed to relay outbox")
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Relay publishes the pending messages batch by batch and returns how many were published
+func (relay *OutboxRelay) Relay(ctx context.Context) (int, error) {
+	count := 0
+	for {
+		result, err := relay.store.RelayOutboxTx(ctx, db.RelayOutboxTxParams{
+			Limit: outboxRelayBatchSize,
+			Publish: func(message db.Outbox) error {
+				return relay.distributor.DistrubuteOutboxMessage(ctx, message)
+			},
+		})
+		count += len(result.Messages)
+		if err != nil {
+			return count, err
+		}
+		if len(result.Messages) < outboxRelayBatchSize {
+			return count, nil
+		}
+	}
+}
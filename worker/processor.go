@@ -5,6 +5,7 @@ import (
 
 	db "github.com/chensheep/simple-bank-backend/db/sqlc"
 	"github.com/chensheep/simple-bank-backend/email"
+	"github.com/chensheep/simple-bank-backend/util"
 	"github.com/hibiken/asynq"
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog/log"
@@ -19,15 +20,29 @@ const (
 type TaskProcessor interface {
 	Start() error
 	ProcessTaskSendVerifyEmail(context.Context, *asynq.Task) error
+	ProcessTaskExpireHolds(context.Context, *asynq.Task) error
+	ProcessTaskEnqueueScheduledTransfers(context.Context, *asynq.Task) error
+	ProcessTaskExecuteScheduledTransfer(context.Context, *asynq.Task) error
+	ProcessTaskReconcileLedger(context.Context, *asynq.Task) error
+	ProcessTaskEnqueueMonthlyStatements(context.Context, *asynq.Task) error
+	ProcessTaskSendStatement(context.Context, *asynq.Task) error
+	ProcessTaskSendRefreshTokenReuseAlert(context.Context, *asynq.Task) error
+	ProcessTaskSendUnlockEmail(context.Context, *asynq.Task) error
+	ProcessTaskSendPasswordResetEmail(context.Context, *asynq.Task) error
+	ProcessTaskSendEmailChangeNotice(context.Context, *asynq.Task) error
+	ProcessTaskSendTransferCode(context.Context, *asynq.Task) error
+	ProcessTaskSendScheduledTransferFailure(context.Context, *asynq.Task) error
 }
 
 type RedisTaskProcessor struct {
 	server      *asynq.Server
+	config      util.Config
 	store       db.Store
 	emailSender email.EmailSender
+	distributor TaskDistrubutor
 }
 
-func NewRedisTaskProcessor(r asynq.RedisConnOpt, store db.Store, emailSender email.EmailSender) *RedisTaskProcessor {
+func NewRedisTaskProcessor(r asynq.RedisConnOpt, config util.Config, store db.Store, emailSender email.EmailSender, distributor TaskDistrubutor) *RedisTaskProcessor {
 	logger := NewLogger()
 	redis.SetLogger(logger)
 
@@ -45,14 +60,28 @@ func NewRedisTaskProcessor(r asynq.RedisConnOpt, store db.Store, emailSender ema
 	})
 	return &RedisTaskProcessor{
 		server:      server,
+		config:      config,
 		store:       store,
 		emailSender: emailSender,
+		distributor: distributor,
 	}
 }
 
 func (processor *RedisTaskProcessor) Start() error {
 	mux := asynq.NewServeMux()
 	mux.HandleFunc(TaskSendVerifyEmail, processor.ProcessTaskSendVerifyEmail)
+	mux.HandleFunc(TaskExpireHolds, processor.ProcessTaskExpireHolds)
+	mux.HandleFunc(TaskEnqueueScheduledTransfers, processor.ProcessTaskEnqueueScheduledTransfers)
+	mux.HandleFunc(TaskExecuteScheduledTransfer, processor.ProcessTaskExecuteScheduledTransfer)
+	mux.HandleFunc(TaskReconcileLedger, processor.ProcessTaskReconcileLedger)
+	mux.HandleFunc(TaskEnqueueMonthlyStatements, processor.ProcessTaskEnqueueMonthlyStatements)
+	mux.HandleFunc(TaskSendStatement, processor.ProcessTaskSendStatement)
+	mux.HandleFunc(TaskSendRefreshTokenReuseAlert, processor.ProcessTaskSendRefreshTokenReuseAlert)
+	mux.HandleFunc(TaskSendUnlockEmail, processor.ProcessTaskSendUnlockEmail)
+	mux.HandleFunc(TaskSendPasswordResetEmail, processor.ProcessTaskSendPasswordResetEmail)
+	mux.HandleFunc(TaskSendEmailChangeNotice, processor.ProcessTaskSendEmailChangeNotice)
+	mux.HandleFunc(TaskSendTransferCode, processor.ProcessTaskSendTransferCode)
+	mux.HandleFunc(TaskSendScheduledTransferFailure, processor.ProcessTaskSendScheduledTransferFailure)
 	// ...register other handlers...
 
 	if err := processor.server.Start(mux); err != nil {
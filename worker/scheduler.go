@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+type TaskScheduler interface {
+	Start() error
+}
+
+type RedisTaskScheduler struct {
+	scheduler *asynq.Scheduler
+}
+
+func NewRedisTaskScheduler(r asynq.RedisConnOpt) *RedisTaskScheduler {
+	scheduler := asynq.NewScheduler(r, &asynq.SchedulerOpts{
+		Logger: NewLogger(),
+		PostEnqueueFunc: func(info *asynq.TaskInfo, err error) {
+			if err != nil {
+				log.Error().Err(err).Msg("failed to enqueue periodic task")
+			}
+		},
+	})
+	return &RedisTaskScheduler{
+		scheduler: scheduler,
+	}
+}
+
+func (s *RedisTaskScheduler) Start() error {
+	_, err := s.scheduler.Register("@every 1m", asynq.NewTask(TaskExpireHolds, nil), asynq.Queue(QueueDefault))
+	if err != nil {
+		return err
+	}
+	_, err = s.scheduler.Register("@every 1m", asynq.NewTask(TaskEnqueueScheduledTransfers, nil), asynq.Queue(QueueCritical))
+	if err != nil {
+		return err
+	}
+	_, err = s.scheduler.Register("@daily", asynq.NewTask(TaskReconcileLedger, nil), asynq.Queue(QueueLow))
+	if err != nil {
+		return err
+	}
+	// statements of the previous month are sent once it is over
+	_, err = s.scheduler.Register("0 1 1 * *", asynq.NewTask(TaskEnqueueMonthlyStatements, nil), asynq.Queue(QueueLow))
+	if err != nil {
+		return err
+	}
+	// ...register other periodic tasks...
+
+	return s.scheduler.Start()
+}
@@ -0,0 +1,35 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	TaskExpireHolds = "task:expire_holds"
+
+	expireHoldsBatchSize = 100
+)
+
+// ProcessTaskExpireHolds releases the authorized holds which have passed their expiration time
+func (processor *RedisTaskProcessor) ProcessTaskExpireHolds(ctx context.Context, t *asynq.Task) error {
+	holdIDs, err := processor.store.ListExpiredHolds(ctx, expireHoldsBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to list expired holds: %w", err)
+	}
+
+	for _, holdID := range holdIDs {
+		_, err := processor.store.ExpireHoldTx(ctx, holdID)
+		if err != nil {
+			// the hold may have been captured or voided in the meantime
+			log.Error().Err(err).Int64("hold_id", holdID).Msg("failed to expire hold")
+		}
+	}
+
+	log.Info().Str("type", t.Type()).Int("count", len(holdIDs)).Msg("processed task")
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/email"
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const TaskReconcileLedger = "task:reconcile_ledger"
+
+// ProcessTaskReconcileLedger checks account balances and transfers against the entries,
+// and alerts by email when a discrepancy is found
+func (processor *RedisTaskProcessor) ProcessTaskReconcileLedger(ctx context.Context, t *asynq.Task) error {
+	result, err := processor.store.ReconcileTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to reconcile ledger: %w", err)
+	}
+
+	if result.Run.Status == db.ReconciliationStatusMismatch {
+		log.Error().Int64("run_id", result.Run.ID).Int32("discrepancy_count", result.Run.DiscrepancyCount).
+			Msg("ledger reconciliation found discrepancies")
+
+		if processor.config.ReconciliationAlertEmail == "" {
+			log.Warn().Msg("no reconciliation alert email configured")
+		} else {
+			err = SendReconciliationAlert(processor.emailSender, processor.config.ReconciliationAlertEmail, result)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	log.Info().Str("type", t.Type()).Int64("run_id", result.Run.ID).
+		Str("status", result.Run.Status).Msg("processed task")
+
+	return nil
+}
+
+// SendReconciliationAlert emails the discrepancies found by a reconciliation run
+func SendReconciliationAlert(emailSender email.EmailSender, to string, result db.ReconcileTxResult) error {
+	subject := fmt.Sprintf("Ledger reconciliation #%d found %d discrepancies", result.Run.ID, result.Run.DiscrepancyCount)
+
+	var rows strings.Builder
+	for _, discrepancy := range result.Discrepancies {
+		target := fmt.Sprintf("account #%d", discrepancy.AccountID.Int64)
+		if discrepancy.Kind == db.DiscrepancyKindTransferEntries {
+			target = fmt.Sprintf("transfer #%d", discrepancy.TransferID.Int64)
+		}
+		fmt.Fprintf(&rows, "<tr><td>%s</td><td>%s</td><td>%d</td><td>%d</td><td>%s</td></tr>\n",
+			discrepancy.Kind, target, discrepancy.Expected, discrepancy.Actual, discrepancy.Details)
+	}
+
+	content := fmt.Sprintf(`
+		The ledger reconciliation run #%d at %s found the following discrepancies:<br/>
+		<table>
+		<tr><th>Kind</th><th>Target</th><th>Expected</th><th>Actual</th><th>Details</th></tr>
+		%s</table>
+	`, result.Run.ID, result.Run.CreatedAt.Format("2006-01-02 15:04:05 MST"), rows.String())
+	err := emailSender.SendEmail([]string{to}, []string{}, []string{}, subject, content, []string{})
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
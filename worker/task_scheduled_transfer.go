@@ -0,0 +1,218 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	TaskEnqueueScheduledTransfers = "task:enqueue_scheduled_transfers"
+	TaskExecuteScheduledTransfer  = "task:execute_scheduled_transfer"
+
+	ScheduledTransferRunSucceeded = "succeeded"
+	ScheduledTransferRunFailed    = "failed"
+
+	enqueueScheduledTransfersBatchSize = 100
+)
+
+// errOwnerFrozen fails the runs of a frozen user, who can't move money until an admin unfreezes them
+var errOwnerFrozen = errors.New("the owner of the scheduled transfer is frozen")
+
+type ExecuteScheduledTransferPayload struct {
+	ScheduledTransferID int64     `json:"scheduled_transfer_id"`
+	RunAt               time.Time `json:"run_at"`
+}
+
+func (d *RedisDistrubutor) DistrubuteTaskExecuteScheduledTransferTask(ctx context.Context,
+	payload *ExecuteScheduledTransferPayload,
+	opts ...asynq.Option) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marhal task payload: %w", err)
+	}
+
+	task := asynq.NewTask(TaskExecuteScheduledTransfer, jsonPayload, opts...)
+	taskInfo, err := d.client.EnqueueContext(ctx, task)
+	if err != nil {
+		return fmt.Errorf("could not enqueue task: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).Str("queue", taskInfo.Queue).
+		Int("max_retry", taskInfo.MaxRetry).Msg("enqueued task")
+
+	return nil
+}
+
+// ProcessTaskEnqueueScheduledTransfers enqueues a transfer task for each due scheduled transfer,
+// then moves the scheduled transfer to its next run
+func (processor *RedisTaskProcessor) ProcessTaskEnqueueScheduledTransfers(ctx context.Context, t *asynq.Task) error {
+	scheduledTransfers, err := processor.store.ListDueScheduledTransfers(ctx, enqueueScheduledTransfersBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to list due scheduled transfers: %w", err)
+	}
+
+	now := time.Now()
+	for _, scheduledTransfer := range scheduledTransfers {
+		payload := &ExecuteScheduledTransferPayload{
+			ScheduledTransferID: scheduledTransfer.ID,
+			RunAt:               scheduledTransfer.NextRunAt,
+		}
+		// the task id keeps a run from being enqueued twice if the update below fails
+		taskID := fmt.Sprintf("scheduled_transfer:%d:%d", scheduledTransfer.ID, scheduledTransfer.NextRunAt.Unix())
+		err := processor.distributor.DistrubuteTaskExecuteScheduledTransferTask(ctx, payload,
+			asynq.TaskID(taskID),
+			asynq.MaxRetry(5),
+			asynq.Queue(QueueCritical),
+		)
+		if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+			log.Error().Err(err).Int64("scheduled_transfer_id", scheduledTransfer.ID).Msg("failed to enqueue scheduled transfer")
+			continue
+		}
+
+		// runs missed while the worker was down are skipped rather than executed in a burst
+		nextRunAt, err := util.NextScheduleTime(scheduledTransfer.Schedule, now)
+		if err != nil {
+			log.Error().Err(err).Int64("scheduled_transfer_id", scheduledTransfer.ID).Msg("failed to get next run")
+			continue
+		}
+
+		_, err = processor.store.UpdateScheduledTransfer(ctx, db.UpdateScheduledTransferParams{
+			ID: scheduledTransfer.ID,
+			NextRunAt: sql.NullTime{
+				Time:  nextRunAt,
+				Valid: true,
+			},
+		})
+		if err != nil {
+			log.Error().Err(err).Int64("scheduled_transfer_id", scheduledTransfer.ID).Msg("failed to update next run")
+		}
+	}
+
+	log.Info().Str("type", t.Type()).Int("count", len(scheduledTransfers)).Msg("processed task")
+
+	return nil
+}
+
+// ProcessTaskExecuteScheduledTransfer makes one run of a scheduled transfer and records its result,
+// the owner is emailed when the transfer is rejected
+func (processor *RedisTaskProcessor) ProcessTaskExecuteScheduledTransfer(ctx context.Context, t *asynq.Task) error {
+	var p ExecuteScheduledTransferPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	scheduledTransfer, err := processor.store.GetScheduledTransfer(ctx, p.ScheduledTransferID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("scheduled transfer %d not found: %w", p.ScheduledTransferID, asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get scheduled transfer: %w", err)
+	}
+
+	if !scheduledTransfer.IsActive {
+		log.Info().Str("type", t.Type()).Int64("scheduled_transfer_id", scheduledTransfer.ID).
+			Msg("skipped inactive scheduled transfer")
+		return nil
+	}
+
+	// a run is final once recorded, a retry of its task after a failed transfer must not try it again
+	run, err := processor.store.GetScheduledTransferRun(ctx, db.GetScheduledTransferRunParams{
+		ScheduledTransferID: scheduledTransfer.ID,
+		RunAt:               p.RunAt,
+	})
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to get scheduled transfer run: %w", err)
+	}
+	if err == nil {
+		log.Info().Str("type", t.Type()).Int64("scheduled_transfer_id", scheduledTransfer.ID).
+			Str("status", run.Status).Msg("skipped scheduled transfer run already made")
+		return nil
+	}
+
+	owner, err := processor.store.GetUser(ctx, scheduledTransfer.Owner)
+	if err != nil {
+		return fmt.Errorf("failed to get owner: %w", err)
+	}
+	if owner.IsFrozen {
+		return processor.failScheduledTransfer(ctx, scheduledTransfer, p.RunAt, errOwnerFrozen)
+	}
+
+	// a retried run replays the transfer already made instead of making another one
+	result, err := processor.store.TransferTx(ctx, db.TransferTxParams{
+		FromAccountID:  scheduledTransfer.FromAccountID,
+		ToAccountID:    scheduledTransfer.ToAccountID,
+		Amount:         scheduledTransfer.Amount,
+		IdempotencyKey: fmt.Sprintf("scheduled_transfer:%d:%d", scheduledTransfer.ID, p.RunAt.Unix()),
+		Username:       scheduledTransfer.Owner,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrInsufficientFunds) ||
+			errors.Is(err, db.ErrAccountNotActive) ||
+			errors.Is(err, db.ErrIdempotencyKeyConflict) {
+			return processor.failScheduledTransfer(ctx, scheduledTransfer, p.RunAt, err)
+		}
+		return fmt.Errorf("failed to transfer: %w", err)
+	}
+
+	_, err = processor.store.CreateScheduledTransferRun(ctx, db.CreateScheduledTransferRunParams{
+		ScheduledTransferID: scheduledTransfer.ID,
+		RunAt:               p.RunAt,
+		Status:              ScheduledTransferRunSucceeded,
+		TransferID: sql.NullInt64{
+			Int64: result.Transfer.ID,
+			Valid: true,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record scheduled transfer run: %w", err)
+	}
+
+	log.Info().Str("type", t.Type()).Bytes("payload", t.Payload()).
+		Int64("transfer_id", result.Transfer.ID).Msg("processed task")
+
+	return nil
+}
+
+// failScheduledTransfer records the rejected run with the email to its owner, which is sent by its own task
+// so that a failure to send it doesn't retry the run
+func (processor *RedisTaskProcessor) failScheduledTransfer(ctx context.Context, scheduledTransfer db.ScheduledTransfer, runAt time.Time, transferErr error) error {
+	failureEmail, err := NewOutboxMessage(TaskSendScheduledTransferFailure, &SendScheduledTransferFailurePayload{
+		Username:            scheduledTransfer.Owner,
+		ScheduledTransferID: scheduledTransfer.ID,
+		FromAccountID:       scheduledTransfer.FromAccountID,
+		ToAccountID:         scheduledTransfer.ToAccountID,
+		Amount:              scheduledTransfer.Amount,
+		RunAt:               runAt,
+		Error:               transferErr.Error(),
+	}, QueueDefault, 10)
+	if err != nil {
+		return fmt.Errorf("failed to create scheduled transfer failure task: %w", err)
+	}
+
+	_, err = processor.store.CreateScheduledTransferRunTx(ctx, db.CreateScheduledTransferRunTxParams{
+		CreateScheduledTransferRunParams: db.CreateScheduledTransferRunParams{
+			ScheduledTransferID: scheduledTransfer.ID,
+			RunAt:               runAt,
+			Status:              ScheduledTransferRunFailed,
+			Error:               transferErr.Error(),
+		},
+		OutboxMessages: []db.CreateOutboxMessageParams{failureEmail},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record scheduled transfer run: %w", err)
+	}
+
+	log.Info().Int64("scheduled_transfer_id", scheduledTransfer.ID).Err(transferErr).
+		Msg("scheduled transfer failed")
+
+	return nil
+}
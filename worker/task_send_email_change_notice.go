@@ -0,0 +1,55 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	TaskSendEmailChangeNotice = "task:send_email_change_notice"
+)
+
+// SendEmailChangeNoticePayload warns the current address that a change to a new one was requested
+type SendEmailChangeNoticePayload struct {
+	Username string `json:"username"`
+	OldEmail string `json:"old_email"`
+	NewEmail string `json:"new_email"`
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskSendEmailChangeNotice(ctx context.Context, t *asynq.Task) error {
+	var p SendEmailChangeNoticePayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	user, err := processor.store.GetUser(ctx, p.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("user %s not found: %w", p.Username, asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	to := []string{p.OldEmail}
+	subject := "Simple Bank: a change of your email was requested"
+	content := fmt.Sprintf(`
+		Hello %s, <br/>
+		Someone asked to change the email of your account to %s.<br/>
+		It will only change once the new address is confirmed.<br/>
+		If this wasn't you, please reset your password and sign out all your sessions.<br/>
+	`, user.FullName, p.NewEmail)
+	err = processor.emailSender.SendEmail(to, []string{}, []string{}, subject, content, []string{})
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	log.Info().Str("type", t.Type()).Bytes("payload", t.Payload()).
+		Str("email", p.OldEmail).Msg("processed task")
+
+	return nil
+}
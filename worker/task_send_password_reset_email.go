@@ -0,0 +1,71 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/util"
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	TaskSendPasswordResetEmail = "task:send_password_reset_email"
+)
+
+type SendPasswordResetEmailPayload struct {
+	Username string `json:"username"`
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskSendPasswordResetEmail(ctx context.Context, t *asynq.Task) error {
+	var p SendPasswordResetEmailPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	user, err := processor.store.GetUser(ctx, p.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("user %s not found: %w", p.Username, asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// the secret code is all it takes to change the password, so it must not be guessable
+	secretCode, err := util.NewSecretCode()
+	if err != nil {
+		return fmt.Errorf("failed to generate secret code: %w", err)
+	}
+
+	passwordReset, err := processor.store.CreatePasswordReset(ctx, db.CreatePasswordResetParams{
+		Username:   user.Username,
+		SecretCode: secretCode,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create password reset: %w", err)
+	}
+
+	// the page behind the link asks for the new password and sends it to /v1/reset_password
+	resetUrl := fmt.Sprintf("http://localhost:8080/reset_password?reset_id=%d&secret_code=%s", passwordReset.ID, passwordReset.SecretCode)
+	to := []string{user.Email}
+	subject := "Simple Bank: reset your password"
+	content := fmt.Sprintf(`
+		Hello %s, <br/>
+		We received a request to reset the password of your account.<br/>
+		Please <a href="%s"> click here </a> to choose a new one, the link expires in 15 minutes.<br/>
+		All your sessions will be signed out once the password is changed.<br/>
+		If you didn't ask for it, you can ignore this email.<br/>
+	`, user.FullName, resetUrl)
+	err = processor.emailSender.SendEmail(to, []string{}, []string{}, subject, content, []string{})
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	log.Info().Str("type", t.Type()).Bytes("payload", t.Payload()).
+		Str("email", user.Email).Msg("processed task")
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	TaskSendRefreshTokenReuseAlert = "task:send_refresh_token_reuse_alert"
+)
+
+// SendRefreshTokenReuseAlertPayload describes the client which presented a refresh token
+// that had already been exchanged
+type SendRefreshTokenReuseAlertPayload struct {
+	Username   string    `json:"username"`
+	UserAgent  string    `json:"user_agent"`
+	ClientIp   string    `json:"client_ip"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskSendRefreshTokenReuseAlert(ctx context.Context, t *asynq.Task) error {
+	var p SendRefreshTokenReuseAlertPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	user, err := processor.store.GetUser(ctx, p.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("user %s not found: %w", p.Username, asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	to := []string{user.Email}
+	subject := "Simple Bank: one of your sessions has been signed out"
+	content := fmt.Sprintf(`
+		Hello %s, <br/>
+		At %s an old sign-in token of yours was used again from %s (%s).<br/>
+		This can mean someone else got hold of it, so we have signed out that session.<br/>
+		Please log in again, and change your password if this wasn't you.<br/>
+	`, user.FullName, p.DetectedAt.Format(time.RFC1123), p.ClientIp, p.UserAgent)
+	err = processor.emailSender.SendEmail(to, []string{}, []string{}, subject, content, []string{})
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	log.Info().Str("type", t.Type()).Bytes("payload", t.Payload()).
+		Str("email", user.Email).Msg("processed task")
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	TaskSendScheduledTransferFailure = "task:send_scheduled_transfer_failure"
+)
+
+// SendScheduledTransferFailurePayload describes the run of a scheduled transfer which was rejected
+type SendScheduledTransferFailurePayload struct {
+	Username            string    `json:"username"`
+	ScheduledTransferID int64     `json:"scheduled_transfer_id"`
+	FromAccountID       int64     `json:"from_account_id"`
+	ToAccountID         int64     `json:"to_account_id"`
+	Amount              int64     `json:"amount"`
+	RunAt               time.Time `json:"run_at"`
+	Error               string    `json:"error"`
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskSendScheduledTransferFailure(ctx context.Context, t *asynq.Task) error {
+	var p SendScheduledTransferFailurePayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	user, err := processor.store.GetUser(ctx, p.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("user %s not found: %w", p.Username, asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	to := []string{user.Email}
+	subject := "Your scheduled transfer has failed"
+	content := fmt.Sprintf(`
+		Hello %s, <br/>
+		The scheduled transfer #%d of %d from account #%d to account #%d planned at %s has failed: %s.<br/>
+	`, user.FullName, p.ScheduledTransferID, p.Amount, p.FromAccountID,
+		p.ToAccountID, p.RunAt.Format(time.RFC1123), p.Error)
+	err = processor.emailSender.SendEmail(to, []string{}, []string{}, subject, content, []string{})
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	log.Info().Str("type", t.Type()).Str("username", user.Username).
+		Int64("scheduled_transfer_id", p.ScheduledTransferID).Msg("processed task")
+
+	return nil
+}
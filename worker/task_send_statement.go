@@ -0,0 +1,175 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	db "github.com/chensheep/simple-bank-backend/db/sqlc"
+	"github.com/chensheep/simple-bank-backend/statement"
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	TaskEnqueueMonthlyStatements = "task:enqueue_monthly_statements"
+	TaskSendStatement            = "task:send_statement"
+
+	enqueueMonthlyStatementsBatchSize = 100
+)
+
+type SendStatementPayload struct {
+	AccountID int64 `json:"account_id"`
+	// Month is like 2023-05
+	Month string `json:"month"`
+}
+
+func (d *RedisDistrubutor) DistrubuteTaskSendStatementTask(ctx context.Context,
+	payload *SendStatementPayload,
+	opts ...asynq.Option) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marhal task payload: %w", err)
+	}
+
+	task := asynq.NewTask(TaskSendStatement, jsonPayload, opts...)
+	taskInfo, err := d.client.EnqueueContext(ctx, task)
+	if err != nil {
+		return fmt.Errorf("could not enqueue task: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).Str("queue", taskInfo.Queue).
+		Int("max_retry", taskInfo.MaxRetry).Msg("enqueued task")
+
+	return nil
+}
+
+// ProcessTaskEnqueueMonthlyStatements enqueues a statement task of the previous month
+// for each account which is not closed
+func (processor *RedisTaskProcessor) ProcessTaskEnqueueMonthlyStatements(ctx context.Context, t *asynq.Task) error {
+	month := time.Now().UTC().AddDate(0, 0, -1).Format("2006-01")
+
+	count := 0
+	arg := db.ListActiveAccountsParams{
+		BatchSize: enqueueMonthlyStatementsBatchSize,
+	}
+	for {
+		accounts, err := processor.store.ListActiveAccounts(ctx, arg)
+		if err != nil {
+			return fmt.Errorf("failed to list accounts: %w", err)
+		}
+
+		for _, account := range accounts {
+			payload := &SendStatementPayload{
+				AccountID: account.ID,
+				Month:     month,
+			}
+			// the task id keeps a statement from being sent twice if this task is retried
+			taskID := fmt.Sprintf("statement:%d:%s", account.ID, month)
+			err := processor.distributor.DistrubuteTaskSendStatementTask(ctx, payload,
+				asynq.TaskID(taskID),
+				asynq.MaxRetry(5),
+				asynq.Queue(QueueLow),
+			)
+			if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+				return fmt.Errorf("failed to enqueue statement of account %d: %w", account.ID, err)
+			}
+			count++
+		}
+
+		if len(accounts) < int(arg.BatchSize) {
+			break
+		}
+		arg.AfterID = accounts[len(accounts)-1].ID
+	}
+
+	log.Info().Str("type", t.Type()).Str("month", month).Int("count", count).Msg("processed task")
+
+	return nil
+}
+
+// ProcessTaskSendStatement renders the statement of an account for a month
+// as CSV and PDF, and emails both to the owner
+func (processor *RedisTaskProcessor) ProcessTaskSendStatement(ctx context.Context, t *asynq.Task) error {
+	var p SendStatementPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	month, err := statement.ParseMonth(p.Month)
+	if err != nil {
+		return fmt.Errorf("%v: %w", err, asynq.SkipRetry)
+	}
+
+	account, err := processor.store.GetAccount(ctx, p.AccountID)
+	if err != nil {
+		return fmt.Errorf("failed to get account: %w", err)
+	}
+
+	user, err := processor.store.GetUser(ctx, account.Owner)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	start, end := statement.MonthPeriod(month)
+	s, err := statement.Generate(ctx, processor.store, account, start, end)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "statement")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files, err := writeStatementFiles(dir, s)
+	if err != nil {
+		return err
+	}
+
+	to := []string{user.Email}
+	subject := fmt.Sprintf("Your Simple Bank statement for %s", month.Format("January 2006"))
+	content := fmt.Sprintf(`
+		Hello %s, <br/>
+		Please find attached the statement of your account #%d for %s.<br/>
+		Opening balance: %d %s<br/>
+		Closing balance: %d %s<br/>
+	`, user.FullName, account.ID, month.Format("January 2006"),
+		s.OpeningBalance, account.Currency, s.ClosingBalance, account.Currency)
+	err = processor.emailSender.SendEmail(to, []string{}, []string{}, subject, content, files)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	log.Info().Str("type", t.Type()).Bytes("payload", t.Payload()).
+		Str("email", user.Email).Msg("processed task")
+
+	return nil
+}
+
+func writeStatementFiles(dir string, s statement.Statement) ([]string, error) {
+	var files []string
+	for _, format := range []string{statement.FormatCSV, statement.FormatPDF} {
+		path := filepath.Join(dir, s.FileName(format))
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create statement file: %w", err)
+		}
+
+		err = s.Write(f, format)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to write statement file: %w", err)
+		}
+		files = append(files, path)
+	}
+
+	return files, nil
+}
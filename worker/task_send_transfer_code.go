@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	TaskSendTransferCode = "task:send_transfer_code"
+)
+
+// SendTransferCodePayload describes the pending transfer the emailed code confirms,
+// so the user can check what is approved before entering the code
+type SendTransferCodePayload struct {
+	Username      string    `json:"username"`
+	Code          string    `json:"code"`
+	FromAccountID int64     `json:"from_account_id"`
+	ToAccountID   int64     `json:"to_account_id"`
+	Amount        int64     `json:"amount"`
+	Currency      string    `json:"currency"`
+	ExpiredAt     time.Time `json:"expired_at"`
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskSendTransferCode(ctx context.Context, t *asynq.Task) error {
+	var p SendTransferCodePayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	if time.Now().After(p.ExpiredAt) {
+		log.Info().Str("type", t.Type()).Str("username", p.Username).Msg("pending transfer has already expired, skip transfer code")
+		return nil
+	}
+
+	user, err := processor.store.GetUser(ctx, p.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("user %s not found: %w", p.Username, asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	to := []string{user.Email}
+	subject := "Simple Bank: confirm your transfer"
+	content := fmt.Sprintf(`
+		Hello %s, <br/>
+		A transfer of %d %s from account #%d to account #%d is waiting for your confirmation.<br/>
+		Your confirmation code is <b>%s</b>, it is valid until %s.<br/>
+		If you didn't make this transfer, don't share the code and change your password.<br/>
+	`, user.FullName, p.Amount, p.Currency, p.FromAccountID, p.ToAccountID, p.Code, p.ExpiredAt.Format(time.RFC1123))
+	err = processor.emailSender.SendEmail(to, []string{}, []string{}, subject, content, []string{})
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	log.Info().Str("type", t.Type()).Str("username", user.Username).
+		Str("email", user.Email).Msg("processed task")
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	TaskSendUnlockEmail = "task:send_unlock_email"
+)
+
+// SendUnlockEmailPayload describes the lock put on the username after too many failed logins
+type SendUnlockEmailPayload struct {
+	Username    string    `json:"username"`
+	UnlockCode  string    `json:"unlock_code"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskSendUnlockEmail(ctx context.Context, t *asynq.Task) error {
+	var p SendUnlockEmailPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	if time.Now().After(p.LockedUntil) {
+		log.Info().Str("type", t.Type()).Str("username", p.Username).Msg("lock has already expired, skip unlock email")
+		return nil
+	}
+
+	user, err := processor.store.GetUser(ctx, p.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("user %s not found: %w", p.Username, asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	unlockUrl := fmt.Sprintf("http://localhost:8080/v1/unlock_user?username=%s&unlock_code=%s", user.Username, p.UnlockCode)
+	to := []string{user.Email}
+	subject := "Simple Bank: your account has been locked"
+	content := fmt.Sprintf(`
+		Hello %s, <br/>
+		There were too many failed attempts to log in to your account, so logins are blocked until %s.<br/>
+		If it was you, <a href="%s"> click here </a> to unlock your account now.<br/>
+		If it wasn't you, someone may be guessing your password, please change it once you are back in.<br/>
+	`, user.FullName, p.LockedUntil.Format(time.RFC1123), unlockUrl)
+	err = processor.emailSender.SendEmail(to, []string{}, []string{}, subject, content, []string{})
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	log.Info().Str("type", t.Type()).Str("username", user.Username).
+		Str("email", user.Email).Msg("processed task")
+
+	return nil
+}
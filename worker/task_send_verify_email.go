@@ -2,6 +2,7 @@ package worker
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 
@@ -17,6 +18,8 @@ const (
 
 type SendVerifyEmailPayload struct {
 	Username string `json:"username"`
+	// Email is the new address of an email change, the email of the user is verified when empty
+	Email string `json:"email,omitempty"`
 }
 
 func (d *RedisDistrubutor) DistrubuteTaskSendVerifyEmailTask(ctx context.Context,
@@ -48,16 +51,27 @@ func (processor *RedisTaskProcessor) ProcessTaskSendVerifyEmail(ctx context.Cont
 
 	user, err := processor.store.GetUser(ctx, p.Username)
 	if err != nil {
-		// Retry if user not found because create user transaction may not be committed yet
-		// if err == sql.ErrNoRows {
-		// 	return fmt.Errorf("user %s not found: %w", p.Username, asynq.SkipRetry)
-		// }
+		// the task is published from the outbox after the user has been committed
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("user %s not found: %w", p.Username, asynq.SkipRetry)
+		}
 		return fmt.Errorf("failed to get user: %w", err)
 	}
 
+	// the new address of an email change waits in pending_email until it is verified
+	email := user.Email
+	if p.Email != "" && p.Email != user.Email {
+		if !user.PendingEmail.Valid || user.PendingEmail.String != p.Email {
+			log.Info().Str("type", t.Type()).Bytes("payload", t.Payload()).
+				Msg("email change has been replaced or cancelled, skip verify email")
+			return nil
+		}
+		email = p.Email
+	}
+
 	arg := db.CreateVerifyEmailParams{
 		Username:   user.Username,
-		Email:      user.Email,
+		Email:      email,
 		SecretCode: util.RandomString(32),
 	}
 	verifyEmail, err := processor.store.CreateVerifyEmail(ctx, arg)
@@ -66,19 +80,27 @@ func (processor *RedisTaskProcessor) ProcessTaskSendVerifyEmail(ctx context.Cont
 	}
 
 	verfifyUrl := fmt.Sprintf("http://localhost:8080/v1/verify_email?email_id=%d&secret_code=%s", verifyEmail.ID, verifyEmail.SecretCode)
-	to := []string{user.Email}
+	to := []string{email}
 	subject := "Welcome to Simple Bank! Please verify your email"
 	content := fmt.Sprintf(`
 		Welcome to Simple Bank, %s! <br/>
 		Please <a href="%s"> click here </a> to verify your email.<br/>
 	`, user.FullName, verfifyUrl)
+	if email != user.Email {
+		subject = "Simple Bank: please confirm your new email"
+		content = fmt.Sprintf(`
+		Hello %s, <br/>
+		Please <a href="%s"> click here </a> to confirm this address as the new email of your account.<br/>
+		Your email will not change until it is confirmed.<br/>
+	`, user.FullName, verfifyUrl)
+	}
 	err = processor.emailSender.SendEmail(to, []string{}, []string{}, subject, content, []string{})
 	if err != nil {
 		return fmt.Errorf("failed to send email: %w", err)
 	}
 
 	log.Info().Str("type", t.Type()).Bytes("payload", t.Payload()).
-		Str("email", user.Email).Msg("processed task")
+		Str("email", email).Msg("processed task")
 
 	return nil
 }